@@ -0,0 +1,198 @@
+package main
+
+import (
+	"backend/pkg/db"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const usage = `usage: backend [command]
+
+Sans commande, démarre le serveur.
+
+  serve                       démarre le serveur
+  migrate up                  applique toutes les migrations en attente
+  migrate down [N]            annule les N dernières migrations (1 par défaut)
+  migrate to N                amène la base à la version N
+  migrate status              affiche la version courante et les migrations en attente
+  db backup <file>            copie la base SQLite vers file (API de sauvegarde en ligne)
+  db restore <file>           remplace la base par file, serveur arrêté
+  db vacuum                   compacte la base
+  db check                    lance integrity_check et foreign_key_check
+  seed --users N --posts M    génère des comptes et des publications de test
+
+La base est choisie par la section database du fichier CONFIG_FILE,
+ou par DB_DRIVER, DB_DSN et DB_MIGRATIONS_DIR.
+`
+
+// runCommand exécute la sous-commande passée en argument
+func runCommand(args []string) error {
+	switch args[0] {
+	case "serve":
+		return run()
+	case "migrate":
+		return migrateCommand(args[1:])
+	case "db":
+		return dbCommand(args[1:])
+	case "seed":
+		return seedCommand(args[1:])
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// openStore ouvre la base configurée, sans appliquer les migrations
+func openStore() (*db.DBStore, db.Config, error) {
+	appConfig, err := loadConfig()
+	if err != nil {
+		return nil, db.Config{}, err
+	}
+	cfg := appConfig.Database.DB()
+	store, err := db.Open(cfg)
+	if err != nil {
+		return nil, cfg, err
+	}
+	return store, cfg, nil
+}
+
+func migrateCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: migrate up|down [N]|to N|status")
+	}
+	store, cfg, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "up":
+		return store.Migrate(cfg.MigrationsDir)
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			if steps, err = strconv.Atoi(args[1]); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid number of steps %q", args[1])
+			}
+		}
+		if err := store.MigrateDown(cfg.MigrationsDir, steps); err != nil {
+			return err
+		}
+		fmt.Printf("reverted %d migration(s)\n", steps)
+		return nil
+	case "to":
+		if len(args) < 2 {
+			return errors.New("usage: migrate to N")
+		}
+		version, err := strconv.ParseUint(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		if err := store.MigrateTo(cfg.MigrationsDir, uint(version)); err != nil {
+			return err
+		}
+		fmt.Printf("database is now at version %d\n", version)
+		return nil
+	case "status":
+		current, dirty, migrations, err := store.MigrationStatus(cfg.MigrationsDir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("driver: %s\ncurrent version: %d", cfg.Driver, current)
+		if dirty {
+			fmt.Print(" (dirty, fix it with migrate to N)")
+		}
+		fmt.Println()
+		for _, m := range migrations {
+			state := "pending"
+			if m.Applied {
+				state = "applied"
+			}
+			fmt.Printf("  %-8s %s\n", state, m.Name)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown migrate command %q", args[0])
+	}
+}
+
+func dbCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("usage: db backup <file>|restore <file>|vacuum|check")
+	}
+	store, _, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	switch args[0] {
+	case "backup", "restore":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: db %s <file>", args[0])
+		}
+		if args[0] == "backup" {
+			err = store.Backup(args[1])
+		} else {
+			err = store.Restore(args[1])
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s completed: %s\n", args[0], args[1])
+		return nil
+	case "vacuum":
+		if err := store.Vacuum(); err != nil {
+			return err
+		}
+		fmt.Println("vacuum completed")
+		return nil
+	case "check":
+		problems, err := store.Check()
+		if err != nil {
+			return err
+		}
+		if len(problems) > 0 {
+			for _, p := range problems {
+				fmt.Println("  -", p)
+			}
+			return fmt.Errorf("database check found %d problem(s)", len(problems))
+		}
+		fmt.Println("ok")
+		return nil
+	default:
+		return fmt.Errorf("unknown db command %q", args[0])
+	}
+}
+
+func seedCommand(args []string) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	users := fs.Int("users", 10, "number of users to create")
+	posts := fs.Int("posts", 50, "number of posts to create")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, cfg, err := openStore()
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	// la base doit être à jour avant d'y insérer des données
+	if err := store.Migrate(cfg.MigrationsDir); err != nil {
+		return err
+	}
+	if err := store.Seed(*users, *posts); err != nil {
+		return err
+	}
+	fmt.Printf("seeded %d users and %d posts (password: %s)\n", *users, *posts, db.SeedPassword)
+	return nil
+}
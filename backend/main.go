@@ -1,9 +1,13 @@
 package main
 
 import (
+	"backend/pkg/config"
 	"backend/pkg/controllers"
 	"backend/pkg/db"
+	"backend/pkg/mail"
+	"backend/pkg/throttle"
 	"backend/pkg/wsk"
+	"backend/pkg/zwt"
 	"context"
 	"fmt"
 	"log"
@@ -15,30 +19,84 @@ import (
 )
 
 func main() {
-	if err := run(); err != nil {
+	var err error
+	if len(os.Args) > 1 {
+		err = runCommand(os.Args[1:])
+	} else {
+		err = run()
+	}
+	if err != nil {
 		log.Printf("Error to run : %v\n", err)
 		os.Exit(1)
 	}
 }
 
+// loadConfig lit le fichier désigné par CONFIG_FILE (facultatif) puis l'environnement
+func loadConfig() (config.Config, error) {
+	cfg, err := config.Load(os.Getenv("CONFIG_FILE"))
+	if err != nil {
+		return cfg, fmt.Errorf("failed to load configuration : %w", err)
+	}
+	return cfg, nil
+}
+
 // Fonction principale pour exécuter le serveur
 func run() error {
-	store := &db.DBStore{}
-	wsChat := wsk.NewWebsocketChat()
-	srv := controllers.NewServer(store, wsChat)
+	cfg, err := loadConfig()
+	if err != nil {
+		return err
+	}
+
+	tokenConfig, err := cfg.JWT.Tokens()
+	if err != nil {
+		return fmt.Errorf("failed to load token configuration : %w", err)
+	}
+	tokens, err := zwt.NewService(tokenConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create token service : %w", err)
+	}
 
-	db, err := store.OpenDatabase()
+	mailer, err := mail.New(cfg.Mail.Mailer())
+	if err != nil {
+		return fmt.Errorf("failed to configure mailer : %w", err)
+	}
+
+	dbConfig := cfg.Database.DB()
+
+	// un seul pool de connexions, partagé par tous les handlers pendant toute la vie du serveur
+	store, err := db.Open(dbConfig)
 	if err != nil {
 		return fmt.Errorf("failed to open database : %w", err)
 	}
 
 	// Fonction pour fermer la base de données à la fin
 	defer func() {
-		if err := srv.Store.CloseDatabase(db); err != nil {
+		if err := store.Close(); err != nil {
 			log.Printf("error when closing database : %v\n", err)
 		}
 	}()
 
+	// les migrations ne sont appliquées qu'au démarrage, pas à chaque requête
+	if err := store.Migrate(dbConfig.MigrationsDir); err != nil {
+		return fmt.Errorf("failed to apply migrations : %w", err)
+	}
+
+	// on refuse de démarrer si une table ou une colonne utilisée par le code manque
+	if err := store.CheckSchema(); err != nil {
+		return err
+	}
+
+	// les compteurs de tentatives de connexion sont persistés pour survivre aux redémarrages
+	limiter := throttle.NewSQLiteLimiter(store.DB())
+
+	wsChat := wsk.NewWebsocketChat(tokens)
+	srv := controllers.NewServer(cfg, store, wsChat, tokens, mailer, limiter)
+
+	// comme pour le schéma, on refuse de démarrer si /api/v1 et sa spécification OpenAPI divergent
+	if err := srv.CheckOpenAPI(); err != nil {
+		return err
+	}
+
 	// Configuration pour écouter les signaux d'arrêt
 	signalChan := make(chan os.Signal, 1)
 	done := make(chan struct{})
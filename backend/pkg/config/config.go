@@ -0,0 +1,302 @@
+package config
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/mail"
+	"backend/pkg/zwt"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Config regroupe toute la configuration du serveur.
+// Elle est construite dans cet ordre : valeurs par défaut, fichier JSON, variables d'environnement,
+// puis validée en une fois
+type Config struct {
+	Server   ServerConfig   `json:"server"`
+	Uploads  UploadsConfig  `json:"uploads"`
+	Database DatabaseConfig `json:"database"`
+	JWT      JWTConfig      `json:"jwt"`
+	OAuth    OAuthConfig    `json:"oauth"`
+	Mail     MailConfig     `json:"mail"`
+}
+
+// ServerConfig décrit où le serveur écoute et sous quelle adresse il est joignable
+type ServerConfig struct {
+	Addr           string   `json:"addr"`            // adresse d'écoute, ex. "localhost:8079" ou ":8080"
+	PublicURL      string   `json:"public_url"`      // URL publique du backend (images, callbacks OAuth)
+	AppBaseURL     string   `json:"app_base_url"`    // URL du frontend utilisée dans les liens envoyés par email
+	AllowedOrigins []string `json:"allowed_origins"` // origines autorisées par CORS, le frontend par défaut
+}
+
+// UploadsConfig décrit où sont rangées les images téléversées et sous quel chemin elles sont servies
+type UploadsConfig struct {
+	Dir     string `json:"dir"`
+	URLPath string `json:"url_path"`
+}
+
+// DatabaseConfig reprend db.Config avec des durées lisibles dans le fichier
+type DatabaseConfig struct {
+	Driver        string   `json:"driver"`
+	DSN           string   `json:"dsn"`
+	MigrationsDir string   `json:"migrations_dir"`
+	ForeignKeys   bool     `json:"foreign_keys"`
+	JournalMode   string   `json:"journal_mode"`
+	BusyTimeout   Duration `json:"busy_timeout"`
+	Synchronous   string   `json:"synchronous"`
+}
+
+// JWTConfig décrit les clés et la durée de vie des tokens d'accès
+type JWTConfig struct {
+	Issuer   string   `json:"issuer"`
+	Audience string   `json:"audience"`
+	TTL      Duration `json:"ttl"`
+	Leeway   Duration `json:"leeway"`
+	KeysFile string   `json:"keys_file"` // jeu de clés {"signing_kid": "...", "keys": [...]} pour la rotation
+	Secret   string   `json:"secret"`    // une seule clé HS256 (base64)
+	KeyID    string   `json:"kid"`
+}
+
+// OAuthConfig liste les fournisseurs activés et la clé qui signe le paramètre "state"
+type OAuthConfig struct {
+	StateSecret string                         `json:"state_secret"` // base64, au moins 32 octets
+	Providers   map[string]OAuthProviderConfig `json:"providers"`
+}
+
+// OAuthProviderConfig décrit un fournisseur. Les endpoints vides gardent ceux du fournisseur réel
+type OAuthProviderConfig struct {
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RedirectURL  string `json:"redirect_url"`
+	AuthURL      string `json:"auth_url"`
+	TokenURL     string `json:"token_url"`
+	UserInfoURL  string `json:"userinfo_url"`
+	EmailsURL    string `json:"emails_url"` // GitHub uniquement
+}
+
+// MailConfig décrit l'envoi des emails : SMTP si smtp_host est défini, sinon un fichier .eml par email
+// dans dir, sinon les emails sont seulement affichés dans les logs
+type MailConfig struct {
+	From         string `json:"from"` // adresse d'expédition
+	SMTPHost     string `json:"smtp_host"`
+	SMTPPort     int    `json:"smtp_port"`
+	SMTPUsername string `json:"smtp_username"`
+	SMTPPassword string `json:"smtp_password"`
+	Dir          string `json:"dir"` // développement uniquement
+}
+
+// OAuthProviderNames liste les fournisseurs connus du serveur
+var OAuthProviderNames = []string{"google", "github"}
+
+// Duration est une time.Duration écrite "15m" ou "30s" dans le fichier
+type Duration struct {
+	time.Duration
+}
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"15m\": %w", err)
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	d.Duration = v
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+// Default renvoie la configuration de développement local
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:       "localhost:8079",
+			AppBaseURL: "http://localhost:3000",
+		},
+		Uploads: UploadsConfig{
+			Dir:     "./image_path",
+			URLPath: "/image_path/",
+		},
+		Database: DatabaseConfig{
+			Driver:      db.DriverSQLite,
+			ForeignKeys: true,
+			JournalMode: "WAL",
+			BusyTimeout: Duration{5 * time.Second},
+			Synchronous: "NORMAL",
+		},
+		JWT: JWTConfig{
+			Issuer:   "social-network",
+			Audience: "social-network-web",
+			TTL:      Duration{15 * time.Minute},
+			Leeway:   Duration{30 * time.Second},
+			KeyID:    "default",
+		},
+		Mail: MailConfig{
+			From:     "no-reply@social-network.local",
+			SMTPPort: 587,
+		},
+	}
+}
+
+// Load construit la configuration à partir du fichier path (ignoré s'il est vide) et de l'environnement
+func Load(path string) (Config, error) {
+	cfg := Default()
+	if path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return Config{}, err
+	}
+	cfg.fillDerived()
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// readFile applique le fichier JSON par-dessus la configuration courante.
+// Une clé inconnue est refusée pour ne pas ignorer une faute de frappe
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// fillDerived complète les valeurs qui dépendent d'autres réglages
+func (c *Config) fillDerived() {
+	if c.Server.PublicURL == "" {
+		c.Server.PublicURL = "http://" + publicHost(c.Server.Addr)
+	}
+	c.Server.PublicURL = strings.TrimSuffix(c.Server.PublicURL, "/")
+	if len(c.Server.AllowedOrigins) == 0 {
+		c.Server.AllowedOrigins = []string{c.Server.AppBaseURL}
+	}
+
+	switch c.Database.Driver {
+	case db.DriverSQLite:
+		if c.Database.DSN == "" {
+			c.Database.DSN = db.DefaultPath
+		}
+		if c.Database.MigrationsDir == "" {
+			c.Database.MigrationsDir = db.DefaultMigrationsDir
+		}
+	case db.DriverPostgres:
+		if c.Database.MigrationsDir == "" {
+			c.Database.MigrationsDir = db.DefaultPostgresMigrationsDir
+		}
+	}
+
+	for name, p := range c.OAuth.Providers {
+		if p.RedirectURL == "" {
+			p.RedirectURL = c.Server.PublicURL + "/auth/" + name + "/callback"
+			c.OAuth.Providers[name] = p
+		}
+	}
+}
+
+// publicHost devine l'hôte joignable depuis l'adresse d'écoute (":8080" -> "localhost:8080")
+func publicHost(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return net.JoinHostPort(host, port)
+}
+
+// ImageURL renvoie l'URL publique d'une image téléversée
+func (c Config) ImageURL(filename string) string {
+	return c.Server.PublicURL + c.Uploads.URLPath + url.PathEscape(filename)
+}
+
+// ImageFile est l'inverse de ImageURL : chemin sur disque d'une image téléversée,
+// false si l'URL ne désigne pas un fichier de uploads.dir
+func (c Config) ImageFile(imageURL string) (string, bool) {
+	name, ok := strings.CutPrefix(imageURL, c.Server.PublicURL+c.Uploads.URLPath)
+	if !ok {
+		return "", false
+	}
+	name, err := url.PathUnescape(name)
+	if err != nil || name == "" || name != filepath.Base(name) {
+		return "", false
+	}
+	return filepath.Join(c.Uploads.Dir, name), true
+}
+
+// DB renvoie la configuration attendue par db.Open
+func (c DatabaseConfig) DB() db.Config {
+	return db.Config{
+		Driver:        c.Driver,
+		DSN:           c.DSN,
+		MigrationsDir: c.MigrationsDir,
+		ForeignKeys:   c.ForeignKeys,
+		JournalMode:   strings.ToUpper(c.JournalMode),
+		BusyTimeout:   c.BusyTimeout.Duration,
+		Synchronous:   strings.ToUpper(c.Synchronous),
+	}
+}
+
+// Mailer renvoie la configuration attendue par mail.New
+func (c MailConfig) Mailer() mail.Config {
+	return mail.Config{
+		From:         c.From,
+		SMTPHost:     c.SMTPHost,
+		SMTPPort:     c.SMTPPort,
+		SMTPUsername: c.SMTPUsername,
+		SMTPPassword: c.SMTPPassword,
+		Dir:          c.Dir,
+	}
+}
+
+// Tokens renvoie la configuration du service de tokens.
+// Sans jeu de clés ni secret, une clé HS256 éphémère est générée (développement uniquement)
+func (c JWTConfig) Tokens() (zwt.Config, error) {
+	cfg := zwt.Config{
+		Issuer:   c.Issuer,
+		Audience: c.Audience,
+		TTL:      c.TTL.Duration,
+		Leeway:   c.Leeway.Duration,
+	}
+
+	switch {
+	case c.KeysFile != "":
+		kid, keys, err := zwt.LoadKeysFile(c.KeysFile)
+		if err != nil {
+			return cfg, err
+		}
+		cfg.SigningKeyID, cfg.Keys = kid, keys
+	case c.Secret != "":
+		cfg.SigningKeyID = c.KeyID
+		cfg.Keys = []zwt.KeyConfig{{ID: c.KeyID, Alg: zwt.AlgHS256, Secret: c.Secret}}
+	default:
+		log.Println("⚠️ No JWT key configured, generating an ephemeral HS256 key (tokens will not survive a restart)")
+		key, err := zwt.EphemeralKey()
+		if err != nil {
+			return cfg, err
+		}
+		cfg.SigningKeyID, cfg.Keys = key.ID, []zwt.KeyConfig{key}
+	}
+	return cfg, nil
+}
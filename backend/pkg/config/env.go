@@ -0,0 +1,118 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// applyEnv remplace les valeurs par celles de l'environnement quand la variable est définie :
+//   - SERVER_ADDR (ou PORT), PUBLIC_URL, APP_BASE_URL, CORS_ALLOWED_ORIGINS (séparées par des virgules)
+//   - UPLOAD_DIR, UPLOAD_URL_PATH
+//   - DB_DRIVER, DB_DSN, DB_MIGRATIONS_DIR, DB_FOREIGN_KEYS, DB_JOURNAL_MODE, DB_BUSY_TIMEOUT, DB_SYNCHRONOUS
+//   - JWT_ISSUER, JWT_AUDIENCE, JWT_TTL, JWT_LEEWAY, JWT_KEYS_FILE, JWT_SECRET, JWT_KID
+//   - OAUTH_STATE_SECRET, OAUTH_<GOOGLE|GITHUB>_CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL, _AUTH_URL,
+//     _TOKEN_URL, _USERINFO_URL, _EMAILS_URL
+//   - MAIL_FROM, SMTP_HOST, SMTP_PORT, SMTP_USERNAME, SMTP_PASSWORD, MAIL_DIR
+func (c *Config) applyEnv() error {
+	if v := os.Getenv("PORT"); v != "" {
+		c.Server.Addr = ":" + v
+	}
+	setString(&c.Server.Addr, "SERVER_ADDR")
+	setString(&c.Server.PublicURL, "PUBLIC_URL")
+	setString(&c.Server.AppBaseURL, "APP_BASE_URL")
+	if v := os.Getenv("CORS_ALLOWED_ORIGINS"); v != "" {
+		c.Server.AllowedOrigins = nil
+		for _, origin := range strings.Split(v, ",") {
+			if origin = strings.TrimSpace(origin); origin != "" {
+				c.Server.AllowedOrigins = append(c.Server.AllowedOrigins, origin)
+			}
+		}
+	}
+
+	setString(&c.Uploads.Dir, "UPLOAD_DIR")
+	setString(&c.Uploads.URLPath, "UPLOAD_URL_PATH")
+
+	setString(&c.Database.Driver, "DB_DRIVER")
+	setString(&c.Database.DSN, "DB_DSN")
+	setString(&c.Database.MigrationsDir, "DB_MIGRATIONS_DIR")
+	if v := os.Getenv("DB_FOREIGN_KEYS"); v != "" {
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("invalid DB_FOREIGN_KEYS: %w", err)
+		}
+		c.Database.ForeignKeys = b
+	}
+	setString(&c.Database.JournalMode, "DB_JOURNAL_MODE")
+	setString(&c.Database.Synchronous, "DB_SYNCHRONOUS")
+	if err := setDuration(&c.Database.BusyTimeout, "DB_BUSY_TIMEOUT"); err != nil {
+		return err
+	}
+
+	setString(&c.JWT.Issuer, "JWT_ISSUER")
+	setString(&c.JWT.Audience, "JWT_AUDIENCE")
+	setString(&c.JWT.KeysFile, "JWT_KEYS_FILE")
+	setString(&c.JWT.Secret, "JWT_SECRET")
+	setString(&c.JWT.KeyID, "JWT_KID")
+	if err := setDuration(&c.JWT.TTL, "JWT_TTL"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.JWT.Leeway, "JWT_LEEWAY"); err != nil {
+		return err
+	}
+
+	setString(&c.OAuth.StateSecret, "OAUTH_STATE_SECRET")
+	for _, name := range OAuthProviderNames {
+		prefix := "OAUTH_" + strings.ToUpper(name) + "_"
+		p, ok := c.OAuth.Providers[name]
+		if !ok && os.Getenv(prefix+"CLIENT_ID") == "" {
+			continue
+		}
+		setString(&p.ClientID, prefix+"CLIENT_ID")
+		setString(&p.ClientSecret, prefix+"CLIENT_SECRET")
+		setString(&p.RedirectURL, prefix+"REDIRECT_URL")
+		setString(&p.AuthURL, prefix+"AUTH_URL")
+		setString(&p.TokenURL, prefix+"TOKEN_URL")
+		setString(&p.UserInfoURL, prefix+"USERINFO_URL")
+		setString(&p.EmailsURL, prefix+"EMAILS_URL")
+		if c.OAuth.Providers == nil {
+			c.OAuth.Providers = map[string]OAuthProviderConfig{}
+		}
+		c.OAuth.Providers[name] = p
+	}
+
+	setString(&c.Mail.From, "MAIL_FROM")
+	setString(&c.Mail.SMTPHost, "SMTP_HOST")
+	if v := os.Getenv("SMTP_PORT"); v != "" {
+		port, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("invalid SMTP_PORT: %w", err)
+		}
+		c.Mail.SMTPPort = port
+	}
+	setString(&c.Mail.SMTPUsername, "SMTP_USERNAME")
+	setString(&c.Mail.SMTPPassword, "SMTP_PASSWORD")
+	setString(&c.Mail.Dir, "MAIL_DIR")
+	return nil
+}
+
+func setString(dst *string, name string) {
+	if v := os.Getenv(name); v != "" {
+		*dst = v
+	}
+}
+
+func setDuration(dst *Duration, name string) error {
+	v := os.Getenv(name)
+	if v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", name, err)
+	}
+	dst.Duration = d
+	return nil
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"backend/pkg/db"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// Validate vérifie toute la configuration et rapporte tous les problèmes d'un coup
+func (c Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...any) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		add("server.addr %q must be host:port or :port", c.Server.Addr)
+	}
+	if !isHTTPURL(c.Server.PublicURL) {
+		add("server.public_url %q must be an absolute http(s) URL", c.Server.PublicURL)
+	}
+	if !isHTTPURL(c.Server.AppBaseURL) {
+		add("server.app_base_url %q must be an absolute http(s) URL", c.Server.AppBaseURL)
+	}
+	for _, origin := range c.Server.AllowedOrigins {
+		if origin != "*" && !isHTTPURL(origin) {
+			add("server.allowed_origins: %q must be an absolute http(s) URL or *", origin)
+		}
+	}
+
+	if c.Uploads.Dir == "" {
+		add("uploads.dir is required")
+	}
+	if !strings.HasPrefix(c.Uploads.URLPath, "/") || !strings.HasSuffix(c.Uploads.URLPath, "/") {
+		add("uploads.url_path %q must start and end with /", c.Uploads.URLPath)
+	}
+
+	switch c.Database.Driver {
+	case db.DriverSQLite:
+		if !slices.Contains([]string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}, strings.ToUpper(c.Database.JournalMode)) {
+			add("database.journal_mode %q is not a SQLite journal mode", c.Database.JournalMode)
+		}
+		if !slices.Contains([]string{"OFF", "NORMAL", "FULL", "EXTRA"}, strings.ToUpper(c.Database.Synchronous)) {
+			add("database.synchronous %q must be OFF, NORMAL, FULL or EXTRA", c.Database.Synchronous)
+		}
+		if c.Database.BusyTimeout.Duration < 0 {
+			add("database.busy_timeout must not be negative")
+		}
+	case db.DriverPostgres:
+		if c.Database.DSN == "" {
+			add("database.dsn is required when database.driver is %s", db.DriverPostgres)
+		}
+	default:
+		add("database.driver %q must be %s or %s", c.Database.Driver, db.DriverSQLite, db.DriverPostgres)
+	}
+
+	if c.JWT.Issuer == "" || c.JWT.Audience == "" {
+		add("jwt.issuer and jwt.audience are required")
+	}
+	if c.JWT.TTL.Duration <= 0 {
+		add("jwt.ttl must be positive")
+	}
+	if c.JWT.Leeway.Duration < 0 {
+		add("jwt.leeway must not be negative")
+	}
+	if c.JWT.Secret != "" {
+		if _, err := base64.StdEncoding.DecodeString(c.JWT.Secret); err != nil {
+			add("jwt.secret must be base64")
+		}
+	}
+
+	if c.OAuth.StateSecret != "" {
+		if key, err := base64.StdEncoding.DecodeString(c.OAuth.StateSecret); err != nil || len(key) < 32 {
+			add("oauth.state_secret must be at least 32 base64-encoded bytes")
+		}
+	}
+	for name, p := range c.OAuth.Providers {
+		if !slices.Contains(OAuthProviderNames, name) {
+			add("oauth.providers.%s is not a supported provider (%s)", name, strings.Join(OAuthProviderNames, ", "))
+			continue
+		}
+		if p.ClientID == "" {
+			add("oauth.providers.%s.client_id is required", name)
+		}
+		for field, v := range map[string]string{
+			"redirect_url": p.RedirectURL, "auth_url": p.AuthURL, "token_url": p.TokenURL,
+			"userinfo_url": p.UserInfoURL, "emails_url": p.EmailsURL,
+		} {
+			if v != "" && !isHTTPURL(v) {
+				add("oauth.providers.%s.%s %q must be an absolute http(s) URL", name, field, v)
+			}
+		}
+	}
+
+	if addr, err := mail.ParseAddress(c.Mail.From); err != nil || addr.Address != c.Mail.From {
+		add("mail.from %q must be an email address", c.Mail.From)
+	}
+	if c.Mail.SMTPHost != "" {
+		if c.Mail.SMTPPort < 1 || c.Mail.SMTPPort > 65535 {
+			add("mail.smtp_port %d must be between 1 and 65535", c.Mail.SMTPPort)
+		}
+		if c.Mail.SMTPPassword != "" && c.Mail.SMTPUsername == "" {
+			add("mail.smtp_username is required with mail.smtp_password")
+		}
+	}
+
+	if len(problems) > 0 {
+		slices.Sort(problems)
+		return errors.New("invalid configuration:\n  - " + strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+func isHTTPURL(v string) bool {
+	u, err := url.Parse(v)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
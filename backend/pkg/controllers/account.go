@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/mail"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+
+	minPasswordLength = 8
+)
+
+var ErrUserTokenInvalid = db.ErrTokenInvalid
+
+// CreateUserToken génère un token à usage unique pour l'utilisateur, seule son empreinte est stockée.
+// Les tokens précédents de même usage encore valides sont invalidés
+func CreateUserToken(tokens db.AccountTokenRepo, userID uuid.UUID, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := newRefreshToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	now := time.Now()
+	if err := tokens.Create(userID, purpose, hash, now, now.Add(ttl)); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// appLink construit un lien vers le frontend portant le token en paramètre
+func (s *MyServer) appLink(path, token string) string {
+	return strings.TrimRight(s.AppBaseURL, "/") + path + "?token=" + url.QueryEscape(token)
+}
+
+// sendVerificationEmail crée un token de vérification et l'envoie à l'utilisateur
+func (s *MyServer) sendVerificationEmail(userID uuid.UUID, email string) error {
+	token, err := CreateUserToken(s.Store.AccountTokens(), userID, db.TokenPurposeVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Confirm your email address",
+		Body: "Welcome!\n\nPlease confirm your email address by opening the link below:\n\n" +
+			s.appLink("/verify_email", token) +
+			"\n\nThis link expires in 48 hours.",
+	})
+}
+
+// VerifyEmailHandler confirme l'adresse email à partir du token reçu (GET ?token= ou POST {"token"})
+func (s *MyServer) VerifyEmailHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		switch r.Method {
+		case http.MethodGet:
+			token = r.URL.Query().Get("token")
+		case http.MethodPost:
+			var body struct {
+				Token string `json:"token"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+				return
+			}
+			token = body.Token
+		default:
+			WriteError(w, r, CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		if token == "" {
+			WriteError(w, r, CodeInvalidLink, "Missing token")
+			return
+		}
+
+		userID, err := s.Store.AccountTokens().VerifyEmail(hashToken(token), time.Now())
+		if errors.Is(err, ErrUserTokenInvalid) {
+			log.Println("Email verification rejected:", err)
+			WriteError(w, r, CodeInvalidLink, "Invalid or expired token")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to mark email as verified:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		log.Printf("Email verified for user %s", userID)
+		SendJSONResponse(w, LoginResponses{Message: "Email verified"}, http.StatusOK)
+	}
+}
+
+// ResendVerificationHandler renvoie l'email de vérification à l'utilisateur connecté
+func (s *MyServer) ResendVerificationHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		users := s.Store.Users()
+		email, err := users.Email(userID)
+		if err != nil {
+			log.Println("Failed to fetch user:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		verified, err := users.EmailVerified(userID)
+		if err != nil {
+			log.Println("Failed to fetch user:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		if verified {
+			WriteError(w, r, CodeEmailAlreadyVerified, "Email already verified")
+			return
+		}
+
+		if err := s.sendVerificationEmail(userID, email); err != nil {
+			log.Println("Failed to send verification email:", err)
+			WriteError(w, r, CodeInternal, "Failed to send verification email")
+			return
+		}
+
+		SendJSONResponse(w, LoginResponses{Message: "Verification email sent"}, http.StatusOK)
+	}
+}
+
+// sendPasswordResetEmail crée un token de réinitialisation et l'envoie à l'utilisateur
+func (s *MyServer) sendPasswordResetEmail(userID uuid.UUID, email string) error {
+	token, err := CreateUserToken(s.Store.AccountTokens(), userID, db.TokenPurposeResetPassword, resetPasswordTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.Mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Reset your password",
+		Body: "A password reset was requested for your account.\n\nOpen the link below to choose a new password:\n\n" +
+			s.appLink("/password/reset", token) +
+			"\n\nThis link expires in 1 hour. If you did not request it, you can ignore this email.",
+	})
+}
+
+// ForgotPasswordHandler envoie un lien de réinitialisation si le compte existe.
+// La réponse est identique dans tous les cas pour ne pas révéler les emails enregistrés
+func (s *MyServer) ForgotPasswordHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Email string `json:"email"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+		email := strings.TrimSpace(body.Email)
+
+		userID, err := s.Store.Users().IDByEmail(email)
+		switch {
+		case errors.Is(err, db.ErrNotFound):
+			log.Println("Password reset requested for unknown email")
+		case err != nil:
+			log.Println("Failed to fetch user:", err)
+		default:
+			if err := s.sendPasswordResetEmail(userID, email); err != nil {
+				log.Println("Failed to send reset email:", err)
+			}
+		}
+
+		SendJSONResponse(w, LoginResponses{Message: "If an account exists for this email, a reset link has been sent"}, http.StatusOK)
+	}
+}
+
+// ResetPasswordHandler change le mot de passe à partir d'un token de réinitialisation
+// puis révoque toutes les sessions ouvertes du compte
+func (s *MyServer) ResetPasswordHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Token    string `json:"token"`
+			Password string `json:"password"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+		if body.Token == "" {
+			WriteError(w, r, CodeInvalidLink, "Missing token")
+			return
+		}
+		if len(body.Password) < minPasswordLength {
+			WriteError(w, r, CodeValidation, fmt.Sprintf("Password must be at least %d characters", minPasswordLength),
+				FieldError{Field: "password", Code: "too_short", Message: fmt.Sprintf("at least %d characters", minPasswordLength)})
+			return
+		}
+
+		hashed, err := bcrypt.GenerateFromPassword([]byte(body.Password), bcrypt.DefaultCost)
+		if err != nil {
+			log.Println("Failed to hash password:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		userID, err := s.Store.AccountTokens().ResetPassword(hashToken(body.Token), string(hashed), time.Now())
+		if errors.Is(err, ErrUserTokenInvalid) {
+			log.Println("Password reset rejected:", err)
+			WriteError(w, r, CodeInvalidLink, "Invalid or expired token")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to update password:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		revoked, err := s.Store.Sessions().RevokeOthers(userID, uuid.Nil)
+		if err != nil {
+			log.Println("Failed to revoke sessions after password reset:", err)
+		}
+		for _, id := range revoked {
+			s.WebSocketChat.CloseSession(id.String())
+		}
+
+		log.Printf("Password reset for user %s, %d sessions revoked", userID, len(revoked))
+		SendJSONResponse(w, LoginResponses{Message: "Password updated"}, http.StatusOK)
+	}
+}
+
+// RequireVerifiedEmail bloque les actions de publication tant que l'email n'est pas confirmé.
+// À placer après Authenticate dans la chaîne de middlewares
+func (s *MyServer) RequireVerifiedEmail(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next(w, r)
+			return
+		}
+
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		verified, err := s.Store.Users().EmailVerified(userID)
+		if err != nil {
+			log.Println("Failed to check email verification:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		if !verified {
+			WriteError(w, r, CodeEmailNotVerified, "Please verify your email address first")
+			return
+		}
+
+		next(w, r)
+	}
+}
@@ -0,0 +1,391 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/models"
+	"backend/pkg/zwt"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	suspensionKindSuspension = "suspension"
+	suspensionKindBan        = "ban"
+
+	impersonationTTL = 15 * time.Minute
+)
+
+// ErrAccountSuspended est retourné pour un compte suspendu ou banni
+var ErrAccountSuspended = errors.New("account suspended")
+
+// ActiveSuspension retourne la restriction en cours sur le compte, nil s'il n'y en a pas
+func ActiveSuspension(admin db.AdminRepo, userID uuid.UUID) (*models.Suspension, error) {
+	sus, err := admin.ActiveSuspension(userID, time.Now())
+	if errors.Is(err, db.ErrNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suspension: %w", err)
+	}
+	return &sus, nil
+}
+
+// suspensionMessage décrit la restriction pour l'utilisateur concerné
+func suspensionMessage(sus *models.Suspension) string {
+	msg := "Your account has been suspended"
+	if sus.Kind == suspensionKindBan {
+		msg = "Your account has been banned"
+	}
+	if sus.ExpiresAt != nil {
+		msg += " until " + sus.ExpiresAt.Format(time.RFC3339)
+	}
+	if sus.Reason != "" {
+		msg += ": " + sus.Reason
+	}
+	return msg
+}
+
+// recordAdminAction ajoute une entrée au journal d'audit (table en ajout seul)
+func recordAdminAction(admin db.AdminRepo, r *http.Request, actorID uuid.UUID, action string, targetID uuid.UUID, details map[string]interface{}) {
+	entry := models.AuditEntry{
+		ID:        uuid.Must(uuid.NewV4()),
+		ActorID:   actorID,
+		Action:    action,
+		IPAddress: clientIP(r),
+		CreatedAt: time.Now(),
+	}
+	if targetID != uuid.Nil {
+		entry.TargetUserID = &targetID
+	}
+	if len(details) > 0 {
+		entry.Details, _ = json.Marshal(details)
+	}
+
+	if err := admin.RecordAction(entry); err != nil {
+		log.Printf("Failed to write audit log for %s: %v", action, err)
+	}
+}
+
+// revokeAllSessions déconnecte toutes les sessions du compte, WebSocket compris
+func (s *MyServer) revokeAllSessions(userID uuid.UUID) int {
+	revoked, err := s.Store.Sessions().RevokeOthers(userID, uuid.Nil)
+	if err != nil {
+		log.Println("Failed to revoke sessions:", err)
+	}
+	for _, id := range revoked {
+		s.WebSocketChat.CloseSession(id.String())
+	}
+	return len(revoked)
+}
+
+// adminTarget lit l'utilisateur ciblé par /admin/users/{id}/... et vérifie son existence
+func adminTarget(w http.ResponseWriter, r *http.Request, users db.UserRepo) (uuid.UUID, string, bool) {
+	targetID, err := uuid.FromString(r.PathValue("id"))
+	if err != nil {
+		WriteError(w, r, CodeInvalidID, "Invalid user ID")
+		return uuid.Nil, "", false
+	}
+
+	role, err := users.Role(targetID)
+	if errors.Is(err, db.ErrNotFound) {
+		WriteError(w, r, CodeUserNotFound, "User not found")
+		return uuid.Nil, "", false
+	}
+	if err != nil {
+		log.Println("Failed to fetch user:", err)
+		WriteError(w, r, CodeInternal, "Internal server error")
+		return uuid.Nil, "", false
+	}
+	return targetID, normalizeRole(role), true
+}
+
+// AdminListUsersHandler liste les comptes avec recherche et filtres :
+// ?q= (nom d'utilisateur ou email), ?role=, ?status=active|suspended|banned, ?limit=, ?offset=
+func (s *MyServer) AdminListUsersHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		if offset < 0 {
+			offset = 0
+		}
+
+		filter := db.AdminUserFilter{
+			Query:  strings.TrimSpace(query.Get("q")),
+			Role:   query.Get("role"),
+			Status: query.Get("status"),
+		}
+		switch filter.Status {
+		case "", "active", "suspended", "banned":
+		default:
+			WriteError(w, r, CodeValidation, "Invalid status filter", fieldInvalid("status", "must be active, suspended or banned"))
+			return
+		}
+
+		users, err := s.Store.Admin().ListUsers(filter, time.Now(), limit, offset)
+		if err != nil {
+			log.Println("Failed to list users:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		for i := range users {
+			users[i].Role = normalizeRole(users[i].Role)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(users)
+	}
+}
+
+// AdminSuspendHandler suspend (kind "suspension") ou bannit (kind "ban") un compte.
+// Corps : {"reason": "...", "expires_at": "RFC3339"} ou {"reason": "...", "duration": "72h"}, sans expiration = définitif
+func (s *MyServer) AdminSuspendHandler(kind string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+
+		var body struct {
+			Reason    string     `json:"reason"`
+			ExpiresAt *time.Time `json:"expires_at"`
+			Duration  string     `json:"duration"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+		body.Reason = strings.TrimSpace(body.Reason)
+		if body.Reason == "" {
+			WriteError(w, r, CodeValidation, "A reason is required", fieldRequired("reason"))
+			return
+		}
+
+		expiresAt := body.ExpiresAt
+		if body.Duration != "" {
+			d, err := time.ParseDuration(body.Duration)
+			if err != nil || d <= 0 {
+				WriteError(w, r, CodeValidation, "Invalid duration", fieldInvalid("duration", "must be a positive duration such as 72h"))
+				return
+			}
+			t := time.Now().Add(d)
+			expiresAt = &t
+		}
+		if expiresAt != nil && !expiresAt.After(time.Now()) {
+			WriteError(w, r, CodeValidation, "Expiry must be in the future", fieldInvalid("expires_at", "must be in the future"))
+			return
+		}
+
+		targetID, _, ok := adminTarget(w, r, s.Store.Users())
+		if !ok {
+			return
+		}
+		if targetID == actor.UserID {
+			WriteError(w, r, CodeCannotTargetSelf, "You cannot suspend your own account")
+			return
+		}
+
+		sus := models.Suspension{
+			ID:        uuid.Must(uuid.NewV4()),
+			UserID:    targetID,
+			Kind:      kind,
+			Reason:    body.Reason,
+			ExpiresAt: expiresAt,
+			CreatedBy: actor.UserID,
+			CreatedAt: time.Now(),
+		}
+		err := s.Store.Admin().Suspend(sus)
+		if err != nil {
+			log.Println("Failed to suspend user:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		revoked := s.revokeAllSessions(targetID)
+		action := "user.suspend"
+		if kind == suspensionKindBan {
+			action = "user.ban"
+		}
+		recordAdminAction(s.Store.Admin(), r, actor.UserID, action, targetID, map[string]interface{}{
+			"reason":           sus.Reason,
+			"expires_at":       sus.ExpiresAt,
+			"revoked_sessions": revoked,
+		})
+
+		log.Printf("User %s: %s by %s", targetID, kind, actor.UserID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sus)
+	}
+}
+
+// AdminUnsuspendHandler lève toutes les restrictions en cours sur le compte
+func (s *MyServer) AdminUnsuspendHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+
+		targetID, _, ok := adminTarget(w, r, s.Store.Users())
+		if !ok {
+			return
+		}
+
+		lifted, err := s.Store.Admin().LiftSuspensions(targetID, actor.UserID, time.Now())
+		if err != nil {
+			log.Println("Failed to lift suspension:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		recordAdminAction(s.Store.Admin(), r, actor.UserID, "user.unsuspend", targetID, map[string]interface{}{"lifted": lifted})
+		SendJSONResponse(w, LoginResponses{Message: fmt.Sprintf("%d restriction(s) lifted", lifted)}, http.StatusOK)
+	}
+}
+
+// AdminForcePasswordResetHandler invalide le mot de passe, déconnecte toutes les sessions et envoie un lien de réinitialisation
+func (s *MyServer) AdminForcePasswordResetHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+
+		targetID, _, ok := adminTarget(w, r, s.Store.Users())
+		if !ok {
+			return
+		}
+
+		// un hash vide ne correspond à aucun mot de passe : seule la réinitialisation permet de se reconnecter
+		if err := s.Store.Users().ClearPassword(targetID); err != nil {
+			log.Println("Failed to invalidate password:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		revoked := s.revokeAllSessions(targetID)
+
+		if email, err := s.Store.Users().Email(targetID); err == nil {
+			if err := s.sendPasswordResetEmail(targetID, email); err != nil {
+				log.Println("Failed to send reset email:", err)
+			}
+		}
+
+		recordAdminAction(s.Store.Admin(), r, actor.UserID, "user.force_password_reset", targetID, map[string]interface{}{"revoked_sessions": revoked})
+		SendJSONResponse(w, LoginResponses{Message: "Password reset required, a reset link has been sent"}, http.StatusOK)
+	}
+}
+
+// AdminChangeRoleHandler change le rôle d'un compte, pris en compte au prochain rafraîchissement du token
+func (s *MyServer) AdminChangeRoleHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+
+		var body struct {
+			Role string `json:"role"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+		if body.Role != RoleAdmin && body.Role != RoleModerator && body.Role != RoleUser {
+			WriteError(w, r, CodeValidation, "Unknown role", fieldInvalid("role", "unknown role"))
+			return
+		}
+
+		targetID, previous, ok := adminTarget(w, r, s.Store.Users())
+		if !ok {
+			return
+		}
+		if targetID == actor.UserID {
+			WriteError(w, r, CodeCannotTargetSelf, "You cannot change your own role")
+			return
+		}
+
+		if err := s.Store.Users().SetRole(targetID, body.Role); err != nil {
+			log.Println("Failed to change role:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		recordAdminAction(s.Store.Admin(), r, actor.UserID, "user.change_role", targetID, map[string]interface{}{"from": previous, "to": body.Role})
+		SendJSONResponse(w, LoginResponses{Message: "Role updated"}, http.StatusOK)
+	}
+}
+
+// AdminImpersonateHandler émet un token en lecture seule au nom de l'utilisateur, pour le support.
+// Le token est rattaché à la session de l'administrateur et ne permet que les requêtes GET
+func (s *MyServer) AdminImpersonateHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+		sessionID, _ := r.Context().Value(sessionIDKey).(string)
+
+		targetID, targetRole, ok := adminTarget(w, r, s.Store.Users())
+		if !ok {
+			return
+		}
+		username, err := s.Store.Users().UsernameByID(targetID)
+		if err != nil {
+			log.Println("Failed to get username:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		token, err := s.Tokens.Issue(zwt.Claims{
+			UserID:       targetID,
+			Username:     username,
+			Role:         targetRole,
+			SessionID:    sessionID,
+			Impersonator: actor.UserID.String(),
+			Exp:          time.Now().Add(impersonationTTL).Unix(),
+		})
+		if err != nil {
+			log.Println("Failed to generate impersonation token:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		recordAdminAction(s.Store.Admin(), r, actor.UserID, "user.impersonate", targetID, nil)
+		SendJSONResponse(w, LoginResponses{
+			Token:     token,
+			ExpiresIn: int64(impersonationTTL.Seconds()),
+			Message:   "Read-only impersonation token issued",
+		}, http.StatusOK)
+	}
+}
+
+// AdminAuditLogHandler consulte le journal d'audit (?limit=, ?offset=, ?target=)
+func (s *MyServer) AdminAuditLogHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		if limit <= 0 || limit > 200 {
+			limit = 50
+		}
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		if offset < 0 {
+			offset = 0
+		}
+
+		var target *uuid.UUID
+		if raw := r.URL.Query().Get("target"); raw != "" {
+			id, err := uuid.FromString(raw)
+			if err != nil {
+				WriteError(w, r, CodeInvalidID, "Invalid target user ID")
+				return
+			}
+			target = &id
+		}
+
+		entries, err := s.Store.Admin().AuditLog(target, limit, offset)
+		if err != nil {
+			log.Println("Failed to query audit log:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(entries)
+	}
+}
@@ -0,0 +1,158 @@
+package controllers
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// APIV1Prefix préfixe l'API REST versionnée, servie à côté des routes historiques
+const APIV1Prefix = "/api/v1"
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// apiV1Routes enregistre l'API orientée ressources. Les handlers sont ceux des routes historiques :
+// ils lisent l'identifiant dans le chemin quand il est présent, sinon dans le corps ou la query.
+// Toute route ajoutée ici doit l'être aussi dans openapi.json (vérifié par CheckOpenAPI au démarrage
+// et par TestOpenAPIMatchesRoutes)
+func (s *MyServer) apiV1Routes(router *Router) {
+	public := router.Group(APIV1Prefix)
+	auth := public.Group("", s.Authenticate)
+	verified := auth.Group("", s.RequireVerifiedEmail)
+
+	public.Handle("GET /openapi.json", s.OpenAPIHandler())
+
+	public.Handle("POST /auth/register", s.RegisterHandler())
+	public.Handle("POST /auth/login", s.LoginHandler())
+	public.Handle("POST /auth/logout", s.LogoutHandler())
+	public.Handle("POST /auth/refresh", s.RefreshTokenHandler())
+
+	auth.Handle("GET /sessions", s.SessionsHandler())
+	auth.Handle("DELETE /sessions", s.SessionsHandler())
+	auth.Handle("DELETE /sessions/{id}", s.RevokeSessionHandler())
+
+	/*-------------------------------------------------------------------------------*/
+
+	auth.Handle("GET /feed", s.FeedHandler())
+	auth.Handle("GET /posts", s.ListPostHandler())
+	verified.Handle("POST /posts", s.CreatePostHandlers())
+	auth.Handle("GET /posts/{id}", s.GetPostHandler())
+	verified.Handle("PUT /posts/{id}", s.UpdatePostHandler())
+	verified.Handle("PATCH /posts/{id}", s.UpdatePostHandler())
+	verified.Handle("DELETE /posts/{id}", s.DeletePostHandler())
+	auth.Handle("GET /posts/{id}/history", s.PostHistoryHandler())
+	auth.Handle("PUT /posts/{id}/like", s.PostLikeHandler(true))
+	auth.Handle("DELETE /posts/{id}/like", s.PostLikeHandler(false))
+	auth.Handle("GET /posts/{id}/comments", s.ListCommentHandler())
+	verified.Handle("POST /posts/{id}/comments", s.CreateCommentHandler())
+	auth.Handle("PUT /comments/{id}/like", s.CommentLikeHandler(true))
+	auth.Handle("DELETE /comments/{id}/like", s.CommentLikeHandler(false))
+	auth.Handle("POST /reports", s.ReportHandler())
+
+	/*-------------------------------------------------------------------------------*/
+
+	auth.Handle("GET /me", s.MyProfil())
+	auth.Handle("PATCH /me", s.UpdateProfileHandler())
+	auth.Handle("GET /me/friends", s.ListAmis())
+	auth.Handle("GET /users", s.ListUsers())
+	auth.Handle("GET /users/search", s.SearchUsersHandler())
+	auth.Handle("GET /users/online", s.OnlineUsersHandler())
+	auth.Handle("GET /users/{id}", s.GetUserProfilHandler())
+
+	auth.Handle("POST /follows", s.FollowUserHandler())
+	auth.Handle("DELETE /follows/{id}", s.UnfollowUserHandler())
+	auth.Handle("GET /follow_requests", s.GetFollowRequestsHandler())
+	auth.Handle("POST /follow_requests/{id}/accept", s.AcceptFollowerHandler())
+	auth.Handle("POST /follow_requests/{id}/decline", s.DeclineFollowerHandler())
+
+	auth.Handle("GET /notifications", s.GetNotificationsHandler())
+	auth.Handle("PUT /notifications/{id}/read", s.MarkNotificationAsRead())
+
+	auth.Handle("GET /messages", s.GetMessagesHandler())
+	auth.Handle("POST /messages", s.PostMessageHandler())
+	auth.Handle("GET /group_messages", s.GetMessagesGroupsHandler())
+	auth.Handle("POST /group_messages", s.PostMessageGroupHandler())
+
+	/*-------------------------------------------------------------------------------*/
+
+	auth.Handle("GET /groups", s.ListGroupsHandler())
+	verified.Handle("POST /groups", s.CreateGroupHandler())
+	auth.Handle("GET /groups/{id}", s.GetGroupDataHandler())
+	auth.Handle("POST /groups/{id}/invitations", s.InviteToGroupHandler())
+	auth.Handle("POST /groups/{id}/invitations/accept", s.AcceptGroupInviteHandler())
+	auth.Handle("POST /groups/{id}/join_requests", s.RequestToJoinGroupHandler())
+	auth.Handle("GET /groups/{id}/posts", s.ListPostGroupHandler())
+	verified.Handle("POST /groups/{id}/posts", s.CreatePostGroupHandler())
+	auth.Handle("GET /group_posts/{id}/comments", s.ListCommentsByPostGroupHandler())
+	verified.Handle("POST /group_posts/{id}/comments", s.CreateCommentPostsGroup())
+
+	auth.Handle("GET /groups/{id}/events", s.ListEvent())
+	verified.Handle("POST /groups/{id}/events", s.CreateEventHandler())
+	auth.Handle("GET /groups/{id}/votes", s.GetUserVotesHandler())
+	auth.Handle("PUT /events/{id}/response", s.RespondToEventHandler())
+	auth.Handle("POST /events/{id}/invitations", s.InviteToEventHandler())
+}
+
+// OpenAPIHandler sert la spécification OpenAPI 3 de /api/v1
+func (s *MyServer) OpenAPIHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(openAPISpec)
+	}
+}
+
+// CheckOpenAPI compare les routes /api/v1 enregistrées aux opérations de openapi.json
+// et liste les écarts dans les deux sens. Le serveur refuse de démarrer tant qu'ils divergent
+func (s *MyServer) CheckOpenAPI() error {
+	documented, err := openAPIOperations(openAPISpec)
+	if err != nil {
+		return err
+	}
+	registered := s.RouteTable.Routes(APIV1Prefix + "/")
+
+	var problems []string
+	for _, route := range registered {
+		if !slices.Contains(documented, route) {
+			problems = append(problems, "missing from openapi.json: "+route)
+		}
+	}
+	for _, op := range documented {
+		if !slices.Contains(registered, op) {
+			problems = append(problems, "documented but not routed: "+op)
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("OpenAPI specification and routes diverge:\n  - %s", strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
+
+// openAPIOperations renvoie les opérations de la spécification au format "MÉTHODE /api/v1/chemin", triées
+func openAPIOperations(spec []byte) ([]string, error) {
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(spec, &doc); err != nil {
+		return nil, fmt.Errorf("invalid openapi.json: %w", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		return nil, fmt.Errorf("openapi.json: unsupported version %q", doc.OpenAPI)
+	}
+
+	var ops []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			switch method {
+			case "get", "put", "post", "delete", "patch", "head", "options", "trace":
+				ops = append(ops, strings.ToUpper(method)+" "+APIV1Prefix+path)
+			}
+		}
+	}
+	slices.Sort(ops)
+	return ops, nil
+}
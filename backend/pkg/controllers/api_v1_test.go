@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db/dbtest"
+	"net/http"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	s := newTestServer(t, dbtest.SQLite(t), config.Default())
+
+	documented, err := openAPIOperations(openAPISpec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	registered := s.RouteTable.Routes(APIV1Prefix + "/")
+	if len(registered) == 0 {
+		t.Fatal("no /api/v1 route registered")
+	}
+
+	for _, route := range registered {
+		if !slices.Contains(documented, route) {
+			t.Errorf("route %s is missing from openapi.json", route)
+		}
+	}
+	for _, op := range documented {
+		if !slices.Contains(registered, op) {
+			t.Errorf("operation %s is documented but not routed", op)
+		}
+	}
+	if err := s.CheckOpenAPI(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckOpenAPIReportsUndocumentedRoute(t *testing.T) {
+	s := newTestServer(t, dbtest.SQLite(t), config.Default())
+	s.RouteTable.Group(APIV1Prefix).Handle("GET /undocumented", http.NotFound)
+
+	err := s.CheckOpenAPI()
+	if err == nil || !strings.Contains(err.Error(), "missing from openapi.json: GET /api/v1/undocumented") {
+		t.Fatalf("CheckOpenAPI() = %v, want the undocumented route reported", err)
+	}
+}
+
+func TestOpenAPIOperationsRejectsInvalidSpec(t *testing.T) {
+	for name, spec := range map[string]string{
+		"not json":    `{`,
+		"swagger 2.0": `{"swagger": "2.0", "paths": {}}`,
+	} {
+		if _, err := openAPIOperations([]byte(spec)); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"regexp"
+)
+
+// ErrorCode est un code d'erreur stable : le frontend traduit le code au lieu d'analyser le message
+type ErrorCode string
+
+const (
+	// erreurs génériques
+	CodeBadRequest       ErrorCode = "bad_request"
+	CodeInvalidJSON      ErrorCode = "invalid_json"
+	CodeInvalidID        ErrorCode = "invalid_id"
+	CodeInvalidCursor    ErrorCode = "invalid_cursor"
+	CodeValidation       ErrorCode = "validation_failed"
+	CodeUnauthorized     ErrorCode = "unauthorized"
+	CodeForbidden        ErrorCode = "forbidden"
+	CodeNotFound         ErrorCode = "not_found"
+	CodeMethodNotAllowed ErrorCode = "method_not_allowed"
+	CodeConflict         ErrorCode = "conflict"
+	CodeTooManyRequests  ErrorCode = "too_many_requests"
+	CodeInternal         ErrorCode = "internal_error"
+	CodeUpstream         ErrorCode = "upstream_error"
+
+	// authentification et compte
+	CodeInvalidCredentials   ErrorCode = "invalid_credentials"
+	CodeInvalidToken         ErrorCode = "invalid_token"
+	CodeInvalidLink          ErrorCode = "invalid_link"
+	CodeInvalidOAuthState    ErrorCode = "invalid_oauth_state"
+	CodeEmailNotVerified     ErrorCode = "email_not_verified"
+	CodeEmailAlreadyVerified ErrorCode = "email_already_verified"
+	CodeAccountSuspended     ErrorCode = "account_suspended"
+	CodeMFARequired          ErrorCode = "mfa_required"
+	CodeInvalidMFACode       ErrorCode = "invalid_mfa_code"
+	CodeMFAAlreadyEnabled    ErrorCode = "mfa_already_enabled"
+	CodeMFANotEnrolling      ErrorCode = "mfa_not_enrolling"
+	CodeReadOnlySession      ErrorCode = "read_only_session"
+	CodeCannotTargetSelf     ErrorCode = "cannot_target_self"
+	CodeEmailTaken           ErrorCode = "email_taken"
+	CodeUsernameTaken        ErrorCode = "username_taken"
+
+	// ressources introuvables
+	CodeUserNotFound          ErrorCode = "user_not_found"
+	CodePostNotFound          ErrorCode = "post_not_found"
+	CodeCommentNotFound       ErrorCode = "comment_not_found"
+	CodeGroupNotFound         ErrorCode = "group_not_found"
+	CodeEventNotFound         ErrorCode = "event_not_found"
+	CodeReportNotFound        ErrorCode = "report_not_found"
+	CodeSessionNotFound       ErrorCode = "session_not_found"
+	CodeFollowRequestNotFound ErrorCode = "follow_request_not_found"
+	CodeInvitationNotFound    ErrorCode = "invitation_not_found"
+	CodeContentNotFound       ErrorCode = "content_not_found"
+	CodeProviderNotFound      ErrorCode = "provider_not_found"
+
+	// règles métier
+	CodeNotGroupMember       ErrorCode = "not_group_member"
+	CodePostNotVisible       ErrorCode = "post_not_visible"
+	CodeAlreadyFollowing     ErrorCode = "already_following"
+	CodeNotFollowing         ErrorCode = "not_following"
+	CodeAlreadyInvited       ErrorCode = "already_invited"
+	CodeInvitationNotPending ErrorCode = "invitation_not_pending"
+	CodeAlreadyReported      ErrorCode = "already_reported"
+	CodeReportClosed         ErrorCode = "report_closed"
+	CodeInvalidImage         ErrorCode = "invalid_image"
+)
+
+// codeStatus associe chaque code à son statut HTTP
+var codeStatus = map[ErrorCode]int{
+	CodeBadRequest:       http.StatusBadRequest,
+	CodeInvalidJSON:      http.StatusBadRequest,
+	CodeInvalidID:        http.StatusBadRequest,
+	CodeInvalidCursor:    http.StatusBadRequest,
+	CodeValidation:       http.StatusBadRequest,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodeForbidden:        http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeMethodNotAllowed: http.StatusMethodNotAllowed,
+	CodeConflict:         http.StatusConflict,
+	CodeTooManyRequests:  http.StatusTooManyRequests,
+	CodeInternal:         http.StatusInternalServerError,
+	CodeUpstream:         http.StatusBadGateway,
+
+	CodeInvalidCredentials:   http.StatusUnauthorized,
+	CodeInvalidToken:         http.StatusUnauthorized,
+	CodeInvalidLink:          http.StatusBadRequest,
+	CodeInvalidOAuthState:    http.StatusBadRequest,
+	CodeEmailNotVerified:     http.StatusForbidden,
+	CodeEmailAlreadyVerified: http.StatusConflict,
+	CodeAccountSuspended:     http.StatusForbidden,
+	CodeMFARequired:          http.StatusForbidden,
+	CodeInvalidMFACode:       http.StatusUnauthorized,
+	CodeMFAAlreadyEnabled:    http.StatusConflict,
+	CodeMFANotEnrolling:      http.StatusConflict,
+	CodeReadOnlySession:      http.StatusForbidden,
+	CodeCannotTargetSelf:     http.StatusBadRequest,
+	CodeEmailTaken:           http.StatusConflict,
+	CodeUsernameTaken:        http.StatusConflict,
+
+	CodeUserNotFound:          http.StatusNotFound,
+	CodePostNotFound:          http.StatusNotFound,
+	CodeCommentNotFound:       http.StatusNotFound,
+	CodeGroupNotFound:         http.StatusNotFound,
+	CodeEventNotFound:         http.StatusNotFound,
+	CodeReportNotFound:        http.StatusNotFound,
+	CodeSessionNotFound:       http.StatusNotFound,
+	CodeFollowRequestNotFound: http.StatusNotFound,
+	CodeInvitationNotFound:    http.StatusNotFound,
+	CodeContentNotFound:       http.StatusNotFound,
+	CodeProviderNotFound:      http.StatusNotFound,
+
+	CodeNotGroupMember:       http.StatusForbidden,
+	CodePostNotVisible:       http.StatusForbidden,
+	CodeAlreadyFollowing:     http.StatusConflict,
+	CodeNotFollowing:         http.StatusConflict,
+	CodeAlreadyInvited:       http.StatusConflict,
+	CodeInvitationNotPending: http.StatusConflict,
+	CodeAlreadyReported:      http.StatusConflict,
+	CodeReportClosed:         http.StatusConflict,
+	CodeInvalidImage:         http.StatusBadRequest,
+}
+
+// Status renvoie le statut HTTP du code, 500 pour un code inconnu
+func (c ErrorCode) Status() int {
+	if status, ok := codeStatus[c]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// FieldError décrit le problème d'un champ précis de la requête
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"` // required, invalid, too_short, too_long...
+	Message string `json:"message"`
+}
+
+func fieldRequired(field string) FieldError {
+	return FieldError{Field: field, Code: "required", Message: "required"}
+}
+
+func fieldInvalid(field, message string) FieldError {
+	return FieldError{Field: field, Code: "invalid", Message: message}
+}
+
+// APIError est la seule forme d'erreur renvoyée par l'API :
+//
+//	{"code": "post_not_found", "error": "Post not found", "request_id": "…", "details": [...]}
+//
+// "error" garde le message lisible pour les clients qui l'affichaient déjà
+type APIError struct {
+	Code      ErrorCode    `json:"code"`
+	Message   string       `json:"error"`
+	RequestID string       `json:"request_id,omitempty"`
+	Details   []FieldError `json:"details,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	return string(e.Code) + ": " + e.Message
+}
+
+// WriteError écrit l'erreur au format APIError avec le statut associé au code
+func WriteError(w http.ResponseWriter, r *http.Request, code ErrorCode, message string, details ...FieldError) {
+	writeAPIError(w, r, &APIError{Code: code, Message: message, Details: details})
+}
+
+// WriteValidationError signale des champs invalides
+func WriteValidationError(w http.ResponseWriter, r *http.Request, details ...FieldError) {
+	WriteError(w, r, CodeValidation, "Validation failed", details...)
+}
+
+func writeAPIError(w http.ResponseWriter, r *http.Request, apiErr *APIError) {
+	if apiErr.RequestID == "" {
+		apiErr.RequestID = RequestID(r)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(apiErr.Code.Status())
+	if err := json.NewEncoder(w).Encode(apiErr); err != nil {
+		log.Printf("Failed to encode JSON error response: %v", err)
+	}
+}
+
+const requestIDKey contextKey = "requestID"
+
+// un identifiant fourni par un proxy est repris tel quel s'il reste raisonnable
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestIDMiddleware attribue un identifiant à chaque requête (en-tête X-Request-ID),
+// renvoyé dans la réponse et dans chaque erreur pour retrouver la requête dans les logs
+func RequestIDMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-ID")
+		if !validRequestID.MatchString(id) {
+			buf := make([]byte, 8)
+			rand.Read(buf)
+			id = hex.EncodeToString(buf)
+		}
+		w.Header().Set("X-Request-ID", id)
+		next(w, r.WithContext(context.WithValue(r.Context(), requestIDKey, id)))
+	}
+}
+
+// RequestID renvoie l'identifiant de la requête, vide hors RequestIDMiddleware
+func RequestID(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+	id, _ := r.Context().Value(requestIDKey).(string)
+	return id
+}
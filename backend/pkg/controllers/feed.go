@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/feed"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+)
+
+// FeedHandler renvoie le fil d'actualité personnalisé, distinct de /recent_posts :
+// mode=following (défaut) ou discover, sort=recent (défaut) ou engagement, pagination cursor/limit.
+// En mode engagement le curseur repère la dernière entrée servie dans le classement recalculé
+func (s *MyServer) FeedHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := actorFromRequest(r)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		query := r.URL.Query()
+		mode := query.Get("mode")
+		if mode == "" {
+			mode = feed.ModeFollowing
+		}
+		sortMode := query.Get("sort")
+		if sortMode == "" {
+			sortMode = feed.SortRecent
+		}
+		var invalid []FieldError
+		if mode != feed.ModeFollowing && mode != feed.ModeDiscover {
+			invalid = append(invalid, fieldInvalid("mode", "must be one of: following discover"))
+		}
+		if sortMode != feed.SortRecent && sortMode != feed.SortEngagement {
+			invalid = append(invalid, fieldInvalid("sort", "must be one of: recent engagement"))
+		}
+		if len(invalid) > 0 {
+			WriteValidationError(w, r, invalid...)
+			return
+		}
+
+		cursor, limit, ok := cursorPage(w, r)
+		if !ok {
+			return
+		}
+
+		now := time.Now()
+		q := db.FeedQuery{Discover: mode == feed.ModeDiscover}
+		if sortMode == feed.SortEngagement {
+			// le score dépend de tous les candidats : on classe la fenêtre récente entière
+			q.Since = now.Add(-feed.EngagementWindow)
+			q.Limit = feed.MaxCandidates
+		} else {
+			// chaque source fournit assez d'entrées pour remplir la page après fusion, plus une
+			// pour savoir s'il reste une page
+			q.After = cursor
+			q.Limit = limit + 1
+		}
+
+		items, err := s.Store.Feed().Candidates(actor.UserID, q)
+		if err != nil {
+			log.Println("Failed to build feed:", err)
+			WriteError(w, r, CodeInternal, "Failed to build feed")
+			return
+		}
+		feed.Rank(items, sortMode, now)
+
+		offset := 0
+		if sortMode == feed.SortEngagement && !cursor.IsZero() {
+			if offset, ok = feed.After(items, cursor.ID); !ok {
+				WriteError(w, r, CodeInvalidCursor, "Invalid cursor")
+				return
+			}
+		}
+		page := feed.Page(items, offset, limit)
+		var next db.Cursor
+		if offset+limit < len(items) {
+			last := page[len(page)-1]
+			next = db.Cursor{CreatedAt: last.CreatedAt, ID: last.ID}
+		}
+
+		setNextCursor(w, next)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"items":       page,
+			"mode":        mode,
+			"sort":        sortMode,
+			"limit":       limit,
+			"next_cursor": next.String(),
+		})
+	}
+}
@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"backend/pkg/db"
 	"encoding/json"
+	"errors"
 	"log"
 	"net/http"
 
@@ -19,12 +21,9 @@ type FollowRequest struct {
 }
 
 const (
-	ErrInvalidUUID       = "Invalid UUID format"
-	ErrInternalServer    = "Internal server error"
 	MsgFollowRequestSent = "Follow request sent"
 	MsgFollowAccepted    = "Follow request accepted"
 	MsgFollowDeclined    = "Follow request declined"
-	MsgInvalidJSONBody   = "Invalid JSON body"
 )
 
 func writeJSONResponse(w http.ResponseWriter, status int, response APIResponse) {
@@ -35,90 +34,45 @@ func writeJSONResponse(w http.ResponseWriter, status int, response APIResponse)
 
 func (s *MyServer) FollowUserHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			writeJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
-				Success: false,
-				Message: "Method not allowed",
-			})
-			return
-		}
-
 		var req FollowRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: MsgInvalidJSONBody,
-			})
+			WriteError(w, r, CodeInvalidJSON, "Invalid JSON body")
 			return
 		}
 
 		senderID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			writeJSONResponse(w, http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Message: "Unauthorized",
-			})
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
 		receiverID, err := uuid.FromString(req.ReceiverID)
 		if err != nil || senderID == receiverID {
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: ErrInvalidUUID,
-			})
+			WriteError(w, r, CodeInvalidID, "Invalid UUID format")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
+		exists, err := s.Store.Follows().RequestExists(senderID, receiverID)
 		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: ErrInternalServer,
-			})
+			WriteError(w, r, CodeInternal, "Internal server error")
 			return
 		}
-		defer DB.Close()
-
-		var exists int
-		err = DB.QueryRow(`
-			SELECT COUNT(*) FROM follow_requests WHERE sender_id = ? AND receiver_id = ?
-		`, senderID, receiverID).Scan(&exists)
 
-		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: ErrInternalServer,
-			})
+		if exists {
+			WriteError(w, r, CodeAlreadyFollowing, "Follow request already exists or user is already followed")
 			return
 		}
 
-		if exists > 0 {
-			writeJSONResponse(w, http.StatusConflict, APIResponse{
-				Success: false,
-				Message: "Follow request already exists or user is already followed",
-			})
-			return
-		}
-
-		var isPrivate bool
-		err = DB.QueryRow("SELECT is_private FROM users WHERE id = ?", receiverID).Scan(&isPrivate)
+		isPrivate, err := s.Store.Users().IsPrivate(receiverID)
 		if err != nil {
-			writeJSONResponse(w, http.StatusNotFound, APIResponse{
-				Success: false,
-				Message: "User not found",
-			})
+			WriteError(w, r, CodeUserNotFound, "User not found")
 			return
 		}
 
 		if isPrivate {
 			// 🔹 Si compte privé → Ajouter à `follow_requests`
-			_, err = DB.Exec("INSERT INTO follow_requests (sender_id, receiver_id) VALUES (?, ?)", senderID, receiverID)
-			if err != nil {
-				writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-					Success: false,
-					Message: "Failed to create follow request",
-				})
+			if err := s.Store.Follows().CreateRequest(senderID, receiverID); err != nil {
+				WriteError(w, r, CodeInternal, "Failed to create follow request")
 				return
 			}
 
@@ -133,12 +87,8 @@ func (s *MyServer) FollowUserHandler() http.HandlerFunc {
 			})
 		} else {
 			// 🔹 Si compte public → Ajouter directement à `followers`
-			_, err = DB.Exec("INSERT INTO followers (follower_id, followed_id, status) VALUES (?, ?, 'accepted')", senderID, receiverID)
-			if err != nil {
-				writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-					Success: false,
-					Message: "Failed to follow user",
-				})
+			if err := s.Store.Follows().Follow(senderID, receiverID); err != nil {
+				WriteError(w, r, CodeInternal, "Failed to follow user")
 				return
 			}
 
@@ -157,107 +107,58 @@ func (s *MyServer) FollowUserHandler() http.HandlerFunc {
 
 func (s *MyServer) UnfollowUserHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodDelete {
-			writeJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
-				Success: false,
-				Message: "Method not allowed",
-			})
-			return
-		}
-
 		var req struct {
 			FollowedID string `json:"followed_id"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Invalid JSON body",
-			})
-			return
+		if req.FollowedID = r.PathValue("id"); req.FollowedID == "" {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				WriteError(w, r, CodeInvalidJSON, "Invalid JSON body")
+				return
+			}
 		}
 
 		log.Println("FollowedID reçu:", req.FollowedID)
 
 		followerID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			writeJSONResponse(w, http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Message: "Unauthorized",
-			})
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
 		if req.FollowedID == "" {
 			log.Println("❌ Erreur: FollowedID est vide")
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "FollowedID cannot be empty",
-			})
+			WriteError(w, r, CodeValidation, "FollowedID cannot be empty", fieldRequired("followed_id"))
 			return
 		}
 
 		followedID, err := uuid.FromString(req.FollowedID)
 		if err != nil {
 			log.Println("❌ UUID invalide reçu:", req.FollowedID)
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Invalid UUID format",
-			})
-			return
-		}
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Internal server error",
-			})
+			WriteError(w, r, CodeInvalidID, "Invalid UUID format")
 			return
 		}
-		defer DB.Close()
 
 		// 🔹 Vérifier si l'utilisateur suit bien la personne avant de se désabonner
-		var exists int
-		err = DB.QueryRow(`
-			SELECT COUNT(*) FROM followers 
-			WHERE follower_id = ? AND followed_id = ?
-		`, followerID, followedID).Scan(&exists)
-
+		exists, err := s.Store.Follows().IsFollowing(followerID, followedID)
 		if err != nil {
 			log.Println("❌ Erreur lors de la vérification du follow:", err)
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Failed to verify follow status",
-			})
+			WriteError(w, r, CodeInternal, "Failed to verify follow status")
 			return
 		}
 
-		if exists == 0 {
+		if !exists {
 			log.Println("❌ Erreur: L'utilisateur ne suit pas cette personne")
-			writeJSONResponse(w, http.StatusConflict, APIResponse{
-				Success: false,
-				Message: "You are not following this user",
-			})
+			WriteError(w, r, CodeNotFollowing, "You are not following this user")
 			return
 		}
 
-		// 🔹 Supprimer l'entrée de la table `followers`
-		_, err = DB.Exec("DELETE FROM followers WHERE follower_id = ? AND followed_id = ?", followerID, followedID)
-		if err != nil {
+		// 🔹 Supprimer l'abonnement et une éventuelle demande de suivi en attente
+		if err := s.Store.Follows().Unfollow(followerID, followedID); err != nil {
 			log.Println("❌ Erreur lors de la suppression du follow:", err)
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Failed to unfollow user",
-			})
+			WriteError(w, r, CodeInternal, "Failed to unfollow user")
 			return
 		}
 
-		// 🔹 Supprimer une demande de suivi en attente (si elle existe)
-		_, err = DB.Exec("DELETE FROM follow_requests WHERE sender_id = ? AND receiver_id = ?", followerID, followedID)
-		if err != nil {
-			log.Println("⚠️ Aucune demande de suivi trouvée ou erreur:", err)
-		}
-
 		// 🔹 Envoyer une notification si nécessaire
 		err = s.AddNotification(followedID.String(), followerID.String(), "Un utilisateur s'est désabonné de vous", "unfollow")
 		if err != nil {
@@ -273,65 +174,25 @@ func (s *MyServer) UnfollowUserHandler() http.HandlerFunc {
 
 func (s *MyServer) GetFollowRequestsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			writeJSONResponse(w, http.StatusMethodNotAllowed, APIResponse{
-				Success: false,
-				Message: "Method not allowed",
-			})
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			writeJSONResponse(w, http.StatusUnauthorized, APIResponse{
-				Success: false,
-				Message: "Unauthorized",
-			})
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
+		pending, err := s.Store.Follows().PendingRequests(userID)
 		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Failed to open database",
-			})
+			WriteError(w, r, CodeInternal, "Failed to retrieve follow requests")
 			return
 		}
-		defer DB.Close()
-
-		rows, err := DB.Query(`
-			SELECT fr.id, fr.sender_id, u.username, u.avatar
-			FROM follow_requests fr
-			JOIN users u ON fr.sender_id = u.id
-			WHERE fr.receiver_id = ?`, userID)
-		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Failed to retrieve follow requests",
-			})
-			return
-		}
-		defer rows.Close()
 
 		var requests []map[string]interface{}
-		for rows.Next() {
-			var requestID, senderID uuid.UUID
-			var username, avatar string
-
-			if err := rows.Scan(&requestID, &senderID, &username, &avatar); err != nil {
-				writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-					Success: false,
-					Message: "Failed to parse follow requests",
-				})
-				return
-			}
-
+		for _, req := range pending {
 			requests = append(requests, map[string]interface{}{
-				"id":        requestID,
-				"sender_id": senderID,
-				"username":  username,
-				"avatar":    avatar,
+				"id":        req.ID,
+				"sender_id": req.SenderID,
+				"username":  req.Username,
+				"avatar":    req.Avatar,
 				"type":      "follow_request",
 				"content":   "Vous avez une nouvelle demande d'ami.",
 			})
@@ -350,81 +211,36 @@ func (s *MyServer) AcceptFollowerHandler() http.HandlerFunc {
 		var req struct {
 			RequestID string `json:"request_id"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Invalid JSON body",
-			})
-			return
+		if req.RequestID = r.PathValue("id"); req.RequestID == "" {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				WriteError(w, r, CodeInvalidJSON, "Invalid JSON body")
+				return
+			}
 		}
 
 		requestID, err := uuid.FromString(req.RequestID)
 		if err != nil {
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Invalid UUID format",
-			})
-			return
-		}
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Internal server error",
-			})
+			WriteError(w, r, CodeInvalidID, "Invalid UUID format")
 			return
 		}
-		defer DB.Close()
-
-		var senderID, receiverID uuid.UUID
-		err = DB.QueryRow(`
-			SELECT sender_id, receiver_id FROM follow_requests WHERE id = ?;
-		`, requestID).Scan(&senderID, &receiverID)
 
+		senderID, receiverID, err := s.Store.Follows().Request(requestID)
 		if err != nil {
 			log.Println("❌ Erreur: Demande de suivi non trouvée pour ID", requestID)
-			writeJSONResponse(w, http.StatusNotFound, APIResponse{
-				Success: false,
-				Message: "Follow request not found",
-			})
-			return
-		}
-
-		var alreadyFollowing int
-		err = DB.QueryRow(`
-			SELECT COUNT(*) FROM followers WHERE follower_id = ? AND followed_id = ?;
-		`, senderID, receiverID).Scan(&alreadyFollowing)
-
-		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Database error",
-			})
+			WriteError(w, r, CodeFollowRequestNotFound, "Follow request not found")
 			return
 		}
 
-		if alreadyFollowing > 0 {
+		// 🔹 Ajouter le follower et supprimer la demande
+		err = s.Store.Follows().AcceptRequest(requestID, senderID, receiverID)
+		if errors.Is(err, db.ErrAlreadyExists) {
 			log.Println("⚠️ L'utilisateur suit déjà cette personne :", senderID, "->", receiverID)
-			writeJSONResponse(w, http.StatusConflict, APIResponse{
-				Success: false,
-				Message: "Already following this user",
-			})
+			WriteError(w, r, CodeAlreadyFollowing, "Already following this user")
 			return
 		}
-
-		// 🔹 Ajouter le follower et supprimer la demande
-		_, err = DB.Exec(`
-			INSERT INTO followers (follower_id, followed_id) VALUES (?, ?);
-			DELETE FROM follow_requests WHERE id = ?;
-		`, senderID, receiverID, requestID)
-
 		if err != nil {
 			log.Println("❌ Erreur lors de l'ajout du follower :", err)
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Failed to accept follow request",
-			})
+			WriteError(w, r, CodeInternal, "Failed to accept follow request")
 			return
 		}
 
@@ -445,55 +261,30 @@ func (s *MyServer) DeclineFollowerHandler() http.HandlerFunc {
 		var req struct {
 			RequestID string `json:"request_id"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Invalid JSON body",
-			})
-			return
+		if req.RequestID = r.PathValue("id"); req.RequestID == "" {
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				WriteError(w, r, CodeInvalidJSON, "Invalid JSON body")
+				return
+			}
 		}
 
 		requestID, err := uuid.FromString(req.RequestID)
 		if err != nil {
-			writeJSONResponse(w, http.StatusBadRequest, APIResponse{
-				Success: false,
-				Message: "Invalid UUID format",
-			})
-			return
-		}
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Internal server error",
-			})
+			WriteError(w, r, CodeInvalidID, "Invalid UUID format")
 			return
 		}
-		defer DB.Close()
-
-		var senderID, receiverID uuid.UUID
-		err = DB.QueryRow(`
-			SELECT sender_id, receiver_id FROM follow_requests WHERE id = ?;
-		`, requestID).Scan(&senderID, &receiverID)
 
+		senderID, receiverID, err := s.Store.Follows().Request(requestID)
 		if err != nil {
 			log.Println("❌ Erreur: Demande de suivi non trouvée pour ID", requestID)
-			writeJSONResponse(w, http.StatusNotFound, APIResponse{
-				Success: false,
-				Message: "Follow request not found",
-			})
+			WriteError(w, r, CodeFollowRequestNotFound, "Follow request not found")
 			return
 		}
 
 		// 🔹 Supprimer la demande de suivi
-		_, err = DB.Exec("DELETE FROM follow_requests WHERE id = ?", requestID)
-		if err != nil {
+		if err := s.Store.Follows().DeleteRequest(requestID); err != nil {
 			log.Println("❌ Erreur lors de la suppression de la demande :", err)
-			writeJSONResponse(w, http.StatusInternalServerError, APIResponse{
-				Success: false,
-				Message: "Failed to decline follow request",
-			})
+			WriteError(w, r, CodeInternal, "Failed to decline follow request")
 			return
 		}
 
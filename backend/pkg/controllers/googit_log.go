@@ -1,236 +1,428 @@
 package controllers
 
-/*
 import (
-	"backend/pkg/models"
-	"context"
+	"backend/pkg/config"
+	"backend/pkg/db"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/gofrs/uuid"
 	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/github"
+	"golang.org/x/oauth2/google"
 )
 
-func (s *MyServer) GoogleRegisterHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		url := s.GoogleOAuthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+// durée de validité d'un aller-retour vers le fournisseur
+const oauthStateTTL = 10 * time.Minute
+
+var (
+	ErrOAuthStateInvalid = errors.New("invalid oauth state")
+	ErrOAuthEmailMissing = errors.New("provider did not return a verified email")
+	// le compte local n'a jamais confirmé son email : il a pu être créé par un tiers qui en connaît le mot de passe
+	ErrOAuthAccountUnverified = errors.New("existing account email is not verified")
+)
+
+// OAuthProvider regroupe la configuration OAuth d'un fournisseur et ses endpoints d'API.
+// Tous les endpoints sont configurables pour pouvoir pointer vers un faux serveur OAuth local
+type OAuthProvider struct {
+	Name        string
+	Config      *oauth2.Config
+	UserInfoURL string
+	EmailsURL   string // GitHub uniquement : liste des emails avec leur statut de vérification
+}
+
+// OAuthIdentity est l'identité normalisée renvoyée par un fournisseur
+type OAuthIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Username      string
+	FirstName     string
+	LastName      string
+}
+
+// oauthState est signé puis transmis au fournisseur dans le paramètre "state"
+type oauthState struct {
+	Provider  string `json:"p"`
+	Nonce     string `json:"n"`
+	Verifier  string `json:"v"` // empreinte du verifier PKCE gardé en cookie
+	ExpiresAt int64  `json:"exp"`
+}
+
+// newOAuthProviders construit les fournisseurs configurés (voir config.OAuthConfig).
+// Les endpoints non renseignés gardent ceux du vrai fournisseur
+func newOAuthProviders(cfg config.OAuthConfig) map[string]*OAuthProvider {
+	providers := map[string]*OAuthProvider{}
+
+	if pc, ok := cfg.Providers["google"]; ok {
+		providers["google"] = newOAuthProvider("google", pc, google.Endpoint, []string{"openid", "email", "profile"},
+			"https://www.googleapis.com/oauth2/v2/userinfo", "")
 	}
+	if pc, ok := cfg.Providers["github"]; ok {
+		providers["github"] = newOAuthProvider("github", pc, github.Endpoint, []string{"read:user", "user:email"},
+			"https://api.github.com/user", "https://api.github.com/user/emails")
+	}
+	return providers
 }
 
-func (s *MyServer) GoogleCallbackRegisterHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		token, err := s.GoogleOAuthConfig.Exchange(context.Background(), code)
-		if err != nil {
-			http.Error(w, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+func newOAuthProvider(name string, pc config.OAuthProviderConfig, endpoint oauth2.Endpoint, scopes []string, userInfoURL, emailsURL string) *OAuthProvider {
+	return &OAuthProvider{
+		Name: name,
+		Config: &oauth2.Config{
+			ClientID:     pc.ClientID,
+			ClientSecret: pc.ClientSecret,
+			RedirectURL:  pc.RedirectURL,
+			Scopes:       scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   orDefault(pc.AuthURL, endpoint.AuthURL),
+				TokenURL:  orDefault(pc.TokenURL, endpoint.TokenURL),
+				AuthStyle: endpoint.AuthStyle,
+			},
+		},
+		UserInfoURL: orDefault(pc.UserInfoURL, userInfoURL),
+		EmailsURL:   orDefault(pc.EmailsURL, emailsURL),
+	}
+}
 
-		client := s.GoogleOAuthConfig.Client(context.Background(), token)
-		resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
-		if err != nil {
-			http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
-		defer resp.Body.Close()
+// oauthStateKey décode le secret configuré (déjà validé), sinon génère une clé éphémère
+func oauthStateKey(secret string) []byte {
+	if key, err := base64.StdEncoding.DecodeString(secret); err == nil && len(key) >= 32 {
+		return key
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		log.Fatal("Failed to generate oauth state key:", err)
+	}
+	return key
+}
 
-		var userInfo struct {
-			Email     string `json:"email"`
-			FirstName string `json:"given_name"`
-			LastName  string `json:"family_name"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-			http.Error(w, "Failed to decode user info: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+func orDefault(v, fallback string) string {
+	if v != "" {
+		return v
+	}
+	return fallback
+}
 
-		user := models.User{
-			Username:  userInfo.Email,
-			FirstName: userInfo.FirstName,
-			LastName:  userInfo.LastName,
-			Email:     userInfo.Email,
-			Password:  "", //le mot de passe peut être vide
-		}
+// signOAuthState sérialise et signe (HMAC-SHA256) l'état transmis au fournisseur
+func (s *MyServer) signOAuthState(state oauthState) (string, error) {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	mac := hmac.New(sha256.New, s.OAuthStateKey)
+	mac.Write([]byte(encoded))
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
+// verifyOAuthState vérifie la signature, l'expiration et le fournisseur de l'état reçu au callback
+func (s *MyServer) verifyOAuthState(raw, provider string) (oauthState, error) {
+	var state oauthState
 
-		err = RegisterUser(w, r, DB, user)
-		if err != nil {
-			http.Error(w, "Failed to register user: "+err.Error(), http.StatusInternalServerError)
-			return
-		}
+	encoded, sig, ok := strings.Cut(raw, ".")
+	if !ok {
+		return state, ErrOAuthStateInvalid
+	}
+	presented, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return state, ErrOAuthStateInvalid
+	}
+	mac := hmac.New(sha256.New, s.OAuthStateKey)
+	mac.Write([]byte(encoded))
+	if !hmac.Equal(presented, mac.Sum(nil)) {
+		return state, ErrOAuthStateInvalid
+	}
 
-		w.WriteHeader(http.StatusCreated)
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(user); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-			return
-		}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil || json.Unmarshal(payload, &state) != nil {
+		return state, ErrOAuthStateInvalid
 	}
+	if state.Provider != provider || time.Now().Unix() > state.ExpiresAt {
+		return state, ErrOAuthStateInvalid
+	}
+	return state, nil
 }
 
-func (s *MyServer) GitHubRegisterHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		url := s.GitHubOAuthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+// oauthProvider retrouve le fournisseur désigné par le chemin /auth/{provider}/...
+func (s *MyServer) oauthProvider(w http.ResponseWriter, r *http.Request) (*OAuthProvider, bool) {
+	provider, ok := s.OAuthProviders[r.PathValue("provider")]
+	if !ok {
+		WriteError(w, r, CodeProviderNotFound, "Unknown or disabled OAuth provider")
+		return nil, false
 	}
+	return provider, true
 }
 
-func (s *MyServer) GitHubCallbackRegisterHandler() http.HandlerFunc {
+// OAuthLoginHandler redirige vers le fournisseur avec un état signé et un challenge PKCE
+func (s *MyServer) OAuthLoginHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		token, err := s.GitHubOAuthConfig.Exchange(context.Background(), code)
-		if err != nil {
-			http.Error(w, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
+		provider, ok := s.oauthProvider(w, r)
+		if !ok {
 			return
 		}
 
-		client := s.GitHubOAuthConfig.Client(context.Background(), token)
-		resp, err := client.Get("https://api.github.com/user")
-		if err != nil {
-			http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
+		verifier := oauth2.GenerateVerifier()
+		nonce := make([]byte, 16)
+		if _, err := rand.Read(nonce); err != nil {
+			log.Println("Failed to generate oauth nonce:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
 			return
 		}
-		defer resp.Body.Close()
 
-		var userInfo struct {
-			Email     string `json:"email"`
-			Login     string `json:"login"`
-			FirstName string `json:"name"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-			http.Error(w, "Failed to decode user info: "+err.Error(), http.StatusInternalServerError)
+		state, err := s.signOAuthState(oauthState{
+			Provider:  provider.Name,
+			Nonce:     base64.RawURLEncoding.EncodeToString(nonce),
+			Verifier:  hashToken(verifier),
+			ExpiresAt: time.Now().Add(oauthStateTTL).Unix(),
+		})
+		if err != nil {
+			log.Println("Failed to sign oauth state:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
 			return
 		}
 
-		user := models.User{
-			Username:  userInfo.Login,
-			FirstName: userInfo.FirstName,
-			LastName:  "",
-			Email:     userInfo.Email,
-			Password:  "",
+		// le verifier reste côté navigateur, seul son challenge part chez le fournisseur
+		http.SetCookie(w, &http.Cookie{
+			Name:     "oauth_verifier",
+			Value:    verifier,
+			Path:     "/auth/" + provider.Name,
+			Expires:  time.Now().Add(oauthStateTTL),
+			HttpOnly: true,
+			SameSite: http.SameSiteLaxMode,
+		})
+
+		url := provider.Config.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier))
+		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
+	}
+}
+
+// OAuthCallbackHandler échange le code, récupère l'identité, relie ou crée le compte puis ouvre une session,
+// ou demande la deuxième étape 2FA comme LoginHandler
+func (s *MyServer) OAuthCallbackHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		provider, ok := s.oauthProvider(w, r)
+		if !ok {
+			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		if errParam := r.URL.Query().Get("error"); errParam != "" {
+			log.Printf("OAuth %s denied: %s", provider.Name, errParam)
+			WriteError(w, r, CodeUnauthorized, "Authorization denied")
 			return
 		}
-		defer DB.Close()
 
-		err = RegisterUser(w, r, DB, user)
+		state, err := s.verifyOAuthState(r.URL.Query().Get("state"), provider.Name)
 		if err != nil {
-			http.Error(w, "Failed to register user: "+err.Error(), http.StatusInternalServerError)
+			log.Println("OAuth state rejected:", err)
+			WriteError(w, r, CodeInvalidOAuthState, "Invalid OAuth state")
 			return
 		}
 
-		w.WriteHeader(http.StatusCreated)
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(user); err != nil {
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+		cookie, err := r.Cookie("oauth_verifier")
+		if err != nil || !hmac.Equal([]byte(hashToken(cookie.Value)), []byte(state.Verifier)) {
+			log.Println("OAuth verifier missing or mismatched")
+			WriteError(w, r, CodeInvalidOAuthState, "Invalid OAuth state")
 			return
 		}
-	}
-}
-*/
-/*
-func (s *MyServer) GoogleLogin() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		log.Println("GoogleLogin handler called")
-		url := s.GoogleOAuthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOnline)
-		log.Println("Redirecting to:", url)
-		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-	}
-}
+		http.SetCookie(w, &http.Cookie{
+			Name:     "oauth_verifier",
+			Value:    "",
+			Path:     "/auth/" + provider.Name,
+			Expires:  time.Unix(0, 0),
+			HttpOnly: true,
+		})
 
-func (s *MyServer) GoogleCallBackHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		log.Println("GoogleCallBackHandler called")
 		code := r.URL.Query().Get("code")
-		log.Println("Authorization code:", code)
 		if code == "" {
-			http.Error(w, "No code in URL", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidOAuthState, "No code in URL")
 			return
 		}
-		token, err := s.GoogleOAuthConfig.Exchange(oauth2.NoContext, code)
+
+		token, err := provider.Config.Exchange(r.Context(), code, oauth2.VerifierOption(cookie.Value))
 		if err != nil {
 			log.Println("Failed to exchange token:", err)
-			http.Error(w, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
+			WriteError(w, r, CodeUpstream, "Failed to exchange token")
 			return
 		}
-		client := s.GoogleOAuthConfig.Client(oauth2.NoContext, token)
-		resp, err := client.Get("https://www.googleapis.com/oauth2/v2/userinfo")
+
+		identity, err := provider.fetchIdentity(r, token)
 		if err != nil {
 			log.Println("Failed to get user info:", err)
-			http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
+			WriteError(w, r, CodeUpstream, "Failed to get user info")
 			return
 		}
-		defer resp.Body.Close()
 
-		var userInfo struct {
-			Email string `json:"email"`
-		}
-		if err := json.NewDecoder(resp.Body).Decode(&userInfo); err != nil {
-			log.Println("Failed to decode user info:", err)
-			http.Error(w, "Failed to decode user info: "+err.Error(), http.StatusInternalServerError)
+		userID, username, err := ResolveOAuthUser(s.Store.Identities(), provider.Name, identity)
+		if err != nil {
+			log.Println("Failed to resolve oauth user:", err)
+			switch {
+			case errors.Is(err, ErrOAuthEmailMissing):
+				WriteError(w, r, CodeEmailNotVerified, "A verified email is required")
+			case errors.Is(err, ErrOAuthAccountUnverified):
+				WriteError(w, r, CodeEmailTaken, "An account already uses this email: sign in with your password and verify your email before linking "+provider.Name)
+			default:
+				WriteError(w, r, CodeInternal, "Internal server error")
+			}
 			return
 		}
-		log.Printf("User authenticated: %s", userInfo.Email)
-		fmt.Fprintf(w, "User authenticated: %s", userInfo.Email)
-	}
-}
 
-func (s *MyServer) GitHubLoginHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		url := s.GitHubOAuthConfig.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
-		http.Redirect(w, r, url, http.StatusTemporaryRedirect)
-	}
-}
-
-func (s *MyServer) GitHubCallbackHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		code := r.URL.Query().Get("code")
-		token, err := s.GitHubOAuthConfig.Exchange(oauth2.NoContext, code)
+		sus, err := ActiveSuspension(s.Store.Admin(), userID)
 		if err != nil {
-			http.Error(w, "Failed to exchange token: "+err.Error(), http.StatusInternalServerError)
+			log.Println("Failed to check suspension:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
 			return
 		}
-		client := s.GitHubOAuthConfig.Client(oauth2.NoContext, token)
-		resp, err := client.Get("https://api.github.com/user/emails")
+		if sus != nil {
+			WriteError(w, r, CodeAccountSuspended, suspensionMessage(sus))
+			return
+		}
+
+		// même deuxième étape que la connexion par mot de passe : la session n'est ouverte
+		// qu'après /mfa/verify ou /mfa/confirm
+		if challenge, pending, err := s.mfaChallenge(userID, username); err != nil {
+			log.Println("Failed to check MFA status:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		} else if pending {
+			SendJSONResponse(w, challenge, http.StatusOK)
+			return
+		}
+
+		response, err := s.startSession(w, r, userID, username, provider.Name)
 		if err != nil {
-			http.Error(w, "Failed to get user info: "+err.Error(), http.StatusInternalServerError)
+			log.Println("Failed to start session:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
 			return
 		}
-		defer resp.Body.Close()
 
+		log.Printf("User logged in with %s, userID: %s", provider.Name, userID)
+
+		SendJSONResponse(w, response, http.StatusOK)
+	}
+}
+
+// fetchIdentity interroge l'API du fournisseur avec le token obtenu
+func (p *OAuthProvider) fetchIdentity(r *http.Request, token *oauth2.Token) (OAuthIdentity, error) {
+	client := p.Config.Client(r.Context(), token)
+
+	switch p.Name {
+	case "google":
+		var info struct {
+			ID            string `json:"id"`
+			Email         string `json:"email"`
+			VerifiedEmail bool   `json:"verified_email"`
+			FirstName     string `json:"given_name"`
+			LastName      string `json:"family_name"`
+		}
+		if err := getJSON(client, p.UserInfoURL, &info); err != nil {
+			return OAuthIdentity{}, err
+		}
+		return OAuthIdentity{
+			Subject:       info.ID,
+			Email:         info.Email,
+			EmailVerified: info.VerifiedEmail,
+			Username:      strings.Split(info.Email, "@")[0],
+			FirstName:     info.FirstName,
+			LastName:      info.LastName,
+		}, nil
+
+	case "github":
+		var info struct {
+			ID    int64  `json:"id"`
+			Login string `json:"login"`
+			Name  string `json:"name"`
+		}
+		if err := getJSON(client, p.UserInfoURL, &info); err != nil {
+			return OAuthIdentity{}, err
+		}
+
+		// l'email du profil GitHub n'indique pas s'il est vérifié, on passe par /user/emails
 		var emails []struct {
 			Email    string `json:"email"`
 			Primary  bool   `json:"primary"`
 			Verified bool   `json:"verified"`
 		}
-		if err := json.NewDecoder(resp.Body).Decode(&emails); err != nil {
-			http.Error(w, "Failed to decode user info: "+err.Error(), http.StatusInternalServerError)
-			return
+		if err := getJSON(client, p.EmailsURL, &emails); err != nil {
+			return OAuthIdentity{}, err
 		}
 
-		var primaryEmail string
-		for _, email := range emails {
-			if email.Primary {
-				primaryEmail = email.Email
+		identity := OAuthIdentity{Subject: fmt.Sprint(info.ID), Username: info.Login}
+		identity.FirstName, identity.LastName, _ = strings.Cut(info.Name, " ")
+		for _, e := range emails {
+			if e.Primary {
+				identity.Email = e.Email
+				identity.EmailVerified = e.Verified
 				break
 			}
 		}
-		fmt.Printf("User authenticated %s \n", primaryEmail)
-		fmt.Fprintf(w, "User authenticated: %s", primaryEmail)
+		return identity, nil
+	}
+
+	return OAuthIdentity{}, fmt.Errorf("unsupported provider %q", p.Name)
+}
+
+func getJSON(client *http.Client, url string, v interface{}) error {
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// ResolveOAuthUser retrouve le compte lié à l'identité du fournisseur.
+// À défaut, l'identité est rattachée au compte ayant le même email si le fournisseur l'a vérifié
+// et que le compte local l'a lui aussi confirmé (ErrOAuthAccountUnverified sinon) ;
+// sans compte correspondant, un nouveau compte sans mot de passe est créé
+func ResolveOAuthUser(identities db.IdentityRepo, provider string, identity OAuthIdentity) (uuid.UUID, string, error) {
+	if identity.Subject == "" {
+		return uuid.Nil, "", fmt.Errorf("provider returned an empty subject")
+	}
+
+	userID, username, err := identities.User(provider, identity.Subject, time.Now())
+	if err == nil {
+		return userID, username, nil
+	}
+	if !errors.Is(err, db.ErrNotFound) {
+		return uuid.Nil, "", fmt.Errorf("failed to query identity: %w", err)
+	}
+
+	if identity.Email == "" || !identity.EmailVerified {
+		return uuid.Nil, "", ErrOAuthEmailMissing
+	}
+
+	userID, username, created, err := identities.Link(db.Identity{
+		Provider:  provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		Username:  identity.Username,
+		FirstName: identity.FirstName,
+		LastName:  identity.LastName,
+	}, time.Now())
+	if errors.Is(err, db.ErrEmailNotVerified) {
+		return uuid.Nil, "", ErrOAuthAccountUnverified
+	}
+	if err != nil {
+		return uuid.Nil, "", err
+	}
+
+	if created {
+		log.Printf("User created from %s identity: %s", provider, userID)
+	} else {
+		log.Printf("Linked %s identity to existing user %s", provider, userID)
 	}
+	return userID, username, nil
 }
-*/
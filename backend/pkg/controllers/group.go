@@ -5,89 +5,41 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gofrs/uuid"
 )
 
 type GroupRequest struct {
-	GroupID    string `json:"group_id"`
-	ReceiverID string `json:"receiver_id"`
+	GroupID    string `json:"group_id" validate:"required,uuid"`
+	ReceiverID string `json:"receiver_id" validate:"required,uuid"`
 }
 
 func (s *MyServer) ListGroupsHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			http.Error(w, "User not logged in", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User not logged in")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			http.Error(w, "Failed to open database", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
-		var count int
-		query := `SELECT COUNT(*) FROM group_members WHERE user_id = ? AND status = 'accepted'`
-		err = DB.QueryRow(query, userID).Scan(&count)
+		count, err := s.Store.Groups().MembershipCount(userID)
 		if err != nil || count == 0 {
-			http.Error(w, "User is not a member of any group", http.StatusForbidden)
+			WriteError(w, r, CodeNotGroupMember, "User is not a member of any group")
 			return
 		}
 
-		page := 1
-		limit := 10
-
-		queryParams := r.URL.Query()
-		if p := queryParams.Get("page"); p != "" {
-			page, err = strconv.Atoi(p)
-			if err != nil || page < 1 {
-				page = 1
-			}
-		}
-
-		if l := queryParams.Get("limit"); l != "" {
-			limit, err = strconv.Atoi(l)
-			if err != nil || limit < 1 {
-				limit = 10
-			}
+		cursor, limit, ok := cursorPage(w, r)
+		if !ok {
+			return
 		}
 
-		offset := (page - 1) * limit
-
-		query = `
-			SELECT g.id, g.name, g.description
-			FROM groups g
-			JOIN group_members gm ON g.id = gm.group_id
-			WHERE gm.user_id = ? AND gm.status = 'accepted'
-			LIMIT ? OFFSET ?
-			`
-		rows, err := DB.Query(query, userID, limit, offset)
+		groups, next, err := s.Store.Groups().ListForMember(userID, cursor, limit)
 		if err != nil {
-			http.Error(w, "Failed to retrieve groups", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to retrieve groups")
 			return
 		}
-		defer rows.Close()
-
-		var groups []models.Group
-
-		for rows.Next() {
-			var group models.Group
-			if err := rows.Scan(&group.ID, &group.Name, &group.Description); err != nil {
-				http.Error(w, "Failed to scan group", http.StatusInternalServerError)
-				return
-			}
-			groups = append(groups, group)
-		}
 
+		setNextCursor(w, next)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(groups)
 	}
@@ -95,58 +47,23 @@ func (s *MyServer) ListGroupsHandler() http.HandlerFunc {
 
 func (s *MyServer) CreateGroupHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			http.Error(w, "User not logged in", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User not logged in")
 			return
 		}
 
 		var group models.Group
-		if err := json.NewDecoder(r.Body).Decode(&group); err != nil {
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		if !decodeValid(w, r, &group) {
 			return
 		}
 
 		group.ID = uuid.Must(uuid.NewV4())
 		group.CreatorID = userID
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			http.Error(w, "Failed to open database", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
-		tx, err := DB.Begin()
-		if err != nil {
-			http.Error(w, "Failed to begin transaction", http.StatusInternalServerError)
-			return
-		}
-		defer func() {
-			if err != nil {
-				tx.Rollback()
-			} else {
-				tx.Commit()
-			}
-		}()
-
-		query := `INSERT INTO groups (id, name, description, creator_id) VALUES (?, ?, ?, ?)`
-		_, err = tx.Exec(query, group.ID, group.Name, group.Description, group.CreatorID)
-		if err != nil {
-			http.Error(w, "Failed to create group", http.StatusInternalServerError)
-			return
-		}
-
-		// ajouter le créateur comme membre du groupe avec le rôle de "creator"
-		query = `INSERT INTO group_members (id, group_id, user_id, status, role) VALUES (?, ?, ?, 'accepted', 'creator')`
-		_, err = tx.Exec(query, uuid.Must(uuid.NewV4()), group.ID, group.CreatorID)
-		if err != nil {
-			http.Error(w, "Failed to add group creator as member", http.StatusInternalServerError)
+		if err := s.Store.Groups().Create(group); err != nil {
+			log.Println("Failed to create group:", err)
+			WriteError(w, r, CodeInternal, "Failed to create group")
 			return
 		}
 		log.Printf("Creating group: %v", group.Name)
@@ -157,76 +74,59 @@ func (s *MyServer) CreateGroupHandler() http.HandlerFunc {
 }
 func (s *MyServer) InviteToGroupHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-
-		if r.Method != http.MethodPost {
-			http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
-			return
-		}
 		var req GroupRequest
 		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, `{"error": "Invalid request payload"}`, http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid request payload")
 			return
 		}
+		if id := r.PathValue("id"); id != "" {
+			req.GroupID = id
+		}
 
 		log.Printf(" demande d'invitation reçue : GroupID=%s, InviteeID=%s\n", req.GroupID, req.ReceiverID)
 
-		if req.ReceiverID == "" || req.GroupID == "" {
-			http.Error(w, `{"error": "Invalid Receiver ID or Group ID"}`, http.StatusBadRequest)
+		if !checkValid(w, r, &req) {
 			return
 		}
 
 		inviterID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			http.Error(w, `{"error": "User not logged in"}`, http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User not logged in")
 			return
 		}
 
 		receiverID, err := uuid.FromString(req.ReceiverID)
 		if err != nil || inviterID == receiverID {
-			http.Error(w, `{"error": "Invalid Receiver ID"}`, http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidID, "Invalid Receiver ID")
 			return
 		}
 
 		groupID, err := uuid.FromString(req.GroupID)
 		if err != nil {
-			http.Error(w, `{"error": "Invalid Group ID"}`, http.StatusBadRequest)
-			return
-		}
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("echec d'ouverture de la base de données", err)
-			http.Error(w, `{"error": "Failed to open database"}`, http.StatusInternalServerError)
+			WriteError(w, r, CodeInvalidID, "Invalid Group ID")
 			return
 		}
-		defer DB.Close()
 
-		var inviterRole string
-		query := `SELECT role FROM group_members WHERE group_id = ? AND user_id = ? AND status = 'accepted'`
-		err = DB.QueryRow(query, groupID, inviterID).Scan(&inviterRole)
-		if err != nil {
-			http.Error(w, `{"error": "User not authorized to invite to group"}`, http.StatusUnauthorized)
+		actor, _ := actorFromRequest(r)
+		if err := AuthorizeGroupAdmin(s.Store.Groups(), actor, groupID); err != nil {
+			writePolicyError(w, r, err)
 			return
 		}
 
-		var status string
-		query = `SELECT status FROM group_members WHERE group_id = ? AND user_id = ?`
-		err = DB.QueryRow(query, groupID, receiverID).Scan(&status)
+		status, err := s.Store.Groups().MemberStatus(groupID, receiverID)
 		if err == nil && status == "pending" {
-			http.Error(w, `{"error": "User already invited to the group"}`, http.StatusConflict)
+			WriteError(w, r, CodeAlreadyInvited, "User already invited to the group")
 			return
 		}
 
-		insertQuery := `INSERT INTO group_members (group_id, user_id, role, status) VALUES (?, ?, 'member', 'pending')`
-		_, err = DB.Exec(insertQuery, groupID, receiverID)
-		if err != nil {
-			http.Error(w, `{"error": "Failed to invite user"}`, http.StatusInternalServerError)
+		if err := s.Store.Groups().InviteMember(groupID, receiverID, inviterID); err != nil {
+			WriteError(w, r, CodeInternal, "Failed to invite user")
 			return
 		}
 
 		err = s.AddNotification(receiverID.String(), inviterID.String(), "Un utilisateur vous a invité à rejoindre un groupe", "group_invite")
 		if err != nil {
-			http.Error(w, `{"error": "Failed to add notification"}`, http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to add notification")
 			return
 		}
 
@@ -241,73 +141,82 @@ func (s *MyServer) AcceptGroupInviteHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		log.Println("📩 Demande d'acceptation de l'invitation reçue")
 
-		// Vérifier la méthode HTTP
-		if r.Method != http.MethodPost {
-			http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
-			return
-		}
-
 		// Décoder le corps de la requête
 		var request struct {
-			GroupID        string `json:"group_id"`
-			NotificationID string `json:"notification_id"`
+			GroupID        string `json:"group_id" validate:"required,uuid"`
+			UserID         string `json:"user_id" validate:"omitempty,uuid"` // auteur de la demande d'adhésion approuvée
+			NotificationID string `json:"notification_id" validate:"omitempty,uuid"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			log.Println("❌ Requête invalide", err)
-			http.Error(w, `{"error": "Invalid request body"}`, http.StatusBadRequest)
+		if !decodeJSON(w, r, &request) {
 			return
 		}
-
-		// Vérifier que les données ne sont pas vides
-		if request.GroupID == "" || request.NotificationID == "" {
-			http.Error(w, `{"error": "GroupID and NotificationID are required"}`, http.StatusBadRequest)
+		if id := r.PathValue("id"); id != "" {
+			request.GroupID = id
+		}
+		if !checkValid(w, r, &request) {
 			return
 		}
 
 		// Récupérer l'utilisateur authentifié
-		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		actor, ok := actorFromRequest(r)
 		if !ok {
-			http.Error(w, `{"error": "User not logged in"}`, http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User not logged in")
 			return
 		}
 
-		// Ouvrir la base de données
-		DB, err := s.Store.OpenDatabase()
+		groupID, err := uuid.FromString(request.GroupID)
 		if err != nil {
-			log.Println("❌ Erreur de connexion à la base de données", err)
-			http.Error(w, `{"error": "Failed to open database"}`, http.StatusInternalServerError)
+			WriteError(w, r, CodeInvalidID, "Invalid Group ID")
 			return
 		}
-		defer DB.Close()
+		memberID := actor.UserID
+		if request.UserID != "" {
+			memberID = uuid.FromStringOrNil(request.UserID)
+		}
 
 		// Vérifier que l'invitation existe et est en attente
-		var status string
-		err = DB.QueryRow("SELECT status FROM group_members WHERE group_id = ? AND user_id = ?", request.GroupID, userID).Scan(&status)
+		status, err := s.Store.Groups().MemberStatus(groupID, memberID)
 		if err != nil {
 			log.Println("❌ L'invitation n'existe pas", err)
-			http.Error(w, `{"error": "Invitation not found"}`, http.StatusNotFound)
+			WriteError(w, r, CodeInvitationNotFound, "Invitation not found")
 			return
 		}
 
 		if status != "pending" {
-			http.Error(w, `{"error": "Invitation is not pending"}`, http.StatusBadRequest)
+			WriteError(w, r, CodeInvitationNotPending, "Invitation is not pending")
 			return
 		}
 
-		// Accepter l'invitation (passer "pending" → "accepted")
-		_, err = DB.Exec("UPDATE group_members SET status = 'accepted' WHERE group_id = ? AND user_id = ?", request.GroupID, userID)
+		// Une invitation est acceptée par l'invité, une demande d'adhésion par le créateur du groupe
+		inviterID, err := s.Store.Groups().InvitedBy(groupID, memberID)
 		if err != nil {
+			WriteError(w, r, CodeInternal, "Failed to accept invitation")
+			return
+		}
+		if inviterID == uuid.Nil {
+			err = AuthorizeGroupAdmin(s.Store.Groups(), actor, groupID)
+		} else if memberID != actor.UserID {
+			err = ErrForbidden
+		}
+		if err != nil {
+			writePolicyError(w, r, err)
+			return
+		}
+
+		// Accepter l'invitation (passer "pending" → "accepted")
+		if err := s.Store.Groups().AcceptMember(groupID, memberID); err != nil {
 			log.Println("❌ Échec de l'acceptation de l'invitation", err)
-			http.Error(w, `{"error": "Failed to accept invitation"}`, http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to accept invitation")
 			return
 		}
 
 		// Marquer la notification comme lue
-		_, err = DB.Exec("UPDATE notifications SET read = 1 WHERE id = ?", request.NotificationID)
-		if err != nil {
-			log.Println("❌ Échec de mise à jour de la notification", err)
-			http.Error(w, `{"error": "Failed to update notification"}`, http.StatusInternalServerError)
-			return
+		if request.NotificationID != "" {
+			if err := s.Store.Notifications().MarkRead(request.NotificationID); err != nil {
+				log.Println("❌ Échec de mise à jour de la notification", err)
+				WriteError(w, r, CodeInternal, "Failed to update notification")
+				return
+			}
 		}
 
 		log.Println("✅ Invitation acceptée avec succès")
@@ -319,31 +228,33 @@ func (s *MyServer) AcceptGroupInviteHandler() http.HandlerFunc {
 
 func (s *MyServer) RequestToJoinGroupHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		var request struct {
 			GroupID uuid.UUID `json:"group_id"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-			http.Error(w, "Invalid input", http.StatusBadRequest)
+		if id := r.PathValue("id"); id != "" {
+			groupID, err := uuid.FromString(id)
+			if err != nil {
+				WriteError(w, r, CodeInvalidID, "Invalid Group ID")
+				return
+			}
+			request.GroupID = groupID
+		} else if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid input")
+			return
+		}
+		if request.GroupID == uuid.Nil {
+			WriteError(w, r, CodeInvalidID, "Invalid Group ID")
 			return
 		}
 
-		userID := r.Context().Value("userID").(uuid.UUID)
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			http.Error(w, "Failed to open database", http.StatusInternalServerError)
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "User not logged in")
 			return
 		}
 
-		query := `INSERT INTO group_members (group_id, user_id, role, status) VALUES (?, ?, 'member', 'pending')`
-		_, err = DB.Exec(query, request.GroupID, userID)
-		if err != nil {
-			http.Error(w, "Failed to request to join group", http.StatusInternalServerError)
+		if err := s.Store.Groups().AddPendingMember(request.GroupID, userID); err != nil {
+			WriteError(w, r, CodeInternal, "Failed to request to join group")
 			return
 		}
 
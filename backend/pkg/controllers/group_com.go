@@ -12,26 +12,26 @@ import (
 
 func (s *MyServer) CreateCommentPostsGroup() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			http.Error(w, "User not logged in", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User not logged in")
 			return
 		}
 
-		username, err := s.getUsernameByUserID(userID)
+		username, err := s.Store.Users().UsernameByID(userID)
 		if err != nil {
-			http.Error(w, "Failed to get username", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to get username")
 			return
 		}
 
 		var comment models.CommentPostGroup
-		if err := json.NewDecoder(r.Body).Decode(&comment); err != nil {
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		if !decodeJSON(w, r, &comment) {
+			return
+		}
+		if id := r.PathValue("id"); id != "" {
+			comment.PostID, _ = uuid.FromString(id)
+		}
+		if !checkValid(w, r, &comment) {
 			return
 		}
 
@@ -40,17 +40,19 @@ func (s *MyServer) CreateCommentPostsGroup() http.HandlerFunc {
 		comment.Username = username
 		comment.CreatedAt = time.Now()
 
-		DB, err := s.Store.OpenDatabase()
+		groupID, err := s.Store.Groups().PostGroupID(comment.PostID)
 		if err != nil {
-			http.Error(w, "Failed to open database", http.StatusInternalServerError)
+			WriteError(w, r, CodePostNotFound, "Post not found")
+			return
+		}
+		actor, _ := actorFromRequest(r)
+		if err := AuthorizeGroupMember(s.Store.Groups(), actor, groupID); err != nil {
+			writePolicyError(w, r, err)
 			return
 		}
-		defer DB.Close()
 
-		query := `INSERT INTO group_posts_comments (id, post_id, content, user_id, username, created_at) VALUES (?, ?, ?, ?, ?, ?)`
-		_, err = DB.Exec(query, comment.ID, comment.PostID, comment.Content, comment.UserID, comment.Username, comment.CreatedAt)
-		if err != nil {
-			http.Error(w, "Failed to create comment", http.StatusInternalServerError)
+		if err := s.Store.Groups().CreateComment(comment); err != nil {
+			WriteError(w, r, CodeInternal, "Failed to create comment")
 			return
 		}
 
@@ -64,30 +66,18 @@ func (s *MyServer) CreateCommentPostsGroup() http.HandlerFunc {
 
 func (s *MyServer) ListCommentsByPostGroupHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		postIDStr := r.URL.Query().Get("post_id")
+		postIDStr := pathOrQuery(r, "id", "post_id")
 		if postIDStr == "" {
-			http.Error(w, "Post ID not provided", http.StatusBadRequest)
+			WriteError(w, r, CodeValidation, "Post ID not provided", fieldRequired("post_id"))
 			return
 		}
 
 		postID, err := uuid.FromString(postIDStr)
 		if err != nil {
-			http.Error(w, "Invalid Post ID", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidID, "Invalid Post ID")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			http.Error(w, "Failed to open database", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
 		page := 1
 		limit := 10
 
@@ -108,52 +98,15 @@ func (s *MyServer) ListCommentsByPostGroupHandler() http.HandlerFunc {
 
 		offset := (page - 1) * limit
 
-		// ✅ Jointure pour récupérer le nom d'utilisateur
-		query := `
-			SELECT c.id, c.post_id, c.content, c.user_id, u.username, c.created_at
-			FROM group_posts_comments AS c
-			INNER JOIN users AS u ON c.user_id = u.id
-			WHERE c.post_id = ?
-			ORDER BY c.created_at DESC
-			LIMIT ? OFFSET ?
-		`
-
-		rows, err := DB.Query(query, postID, limit, offset)
+		comments, err := s.Store.Groups().ListComments(postID, limit, offset)
 		if err != nil {
-			http.Error(w, "Failed to retrieve comments", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to retrieve comments")
 			return
 		}
-		defer rows.Close()
-
-		var comments []models.CommentPostGroup
-		for rows.Next() {
-			var comment models.CommentPostGroup
-			if err := rows.Scan(&comment.ID, &comment.PostID, &comment.Content, &comment.UserID, &comment.Username, &comment.CreatedAt); err != nil {
-				http.Error(w, "Failed to scan comment", http.StatusInternalServerError)
-				return
-			}
-			comments = append(comments, comment)
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(comments); err != nil {
-			http.Error(w, "Failed to encode comments", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode comments")
 		}
 	}
 }
-
-func (s *MyServer) getUsernameByUserID(userID uuid.UUID) (string, error) {
-	DB, err := s.Store.OpenDatabase()
-	if err != nil {
-		return "", err
-	}
-	defer DB.Close()
-
-	var username string
-	query := `SELECT username FROM users WHERE id = ?`
-	err = DB.QueryRow(query, userID).Scan(&username)
-	if err != nil {
-		return "", err
-	}
-	return username, nil
-}
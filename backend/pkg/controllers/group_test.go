@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+// TestGroupMembership vérifie que seul le créateur invite et approuve les demandes d'adhésion,
+// et qu'une invitation n'est acceptée que par l'invité
+func TestGroupMembership(t *testing.T) {
+	store := dbtest.SQLite(t)
+	s := newTestServer(t, store, config.Default())
+	groups := store.Groups()
+
+	creator := dbtest.User(t, store, "creator")
+	member := dbtest.User(t, store, "member")
+	invitee := dbtest.User(t, store, "invitee")
+	requester := dbtest.User(t, store, "requester")
+	tokens := map[uuid.UUID]string{}
+	for id, name := range map[uuid.UUID]string{creator: "creator", member: "member", invitee: "invitee", requester: "requester"} {
+		tokens[id] = accessToken(t, s, id, name, RoleUser)
+	}
+
+	groupID := uuid.Must(uuid.NewV4())
+	if err := groups.Create(models.Group{ID: groupID, Name: "group", Description: "group", CreatorID: creator}); err != nil {
+		t.Fatal(err)
+	}
+	if err := groups.InviteMember(groupID, member, creator); err != nil {
+		t.Fatal(err)
+	}
+	if err := groups.AcceptMember(groupID, member); err != nil {
+		t.Fatal(err)
+	}
+	base := "/api/v1/groups/" + groupID.String()
+
+	status := func(t *testing.T, userID uuid.UUID, want string) {
+		t.Helper()
+		if got, _ := groups.MemberStatus(groupID, userID); got != want {
+			t.Errorf("member status = %q; want %q", got, want)
+		}
+	}
+	expect := func(t *testing.T, actor uuid.UUID, path, body string, want int) {
+		t.Helper()
+		if rec := serve(s, tokens[actor], http.MethodPost, base+path, body); rec.Code != want {
+			t.Errorf("POST %s = %d %s; want %d", path, rec.Code, rec.Body, want)
+		}
+	}
+
+	t.Run("invitation", func(t *testing.T) {
+		body := `{"receiver_id": "` + invitee.String() + `"}`
+		expect(t, member, "/invitations", body, http.StatusForbidden)
+		status(t, invitee, "")
+		expect(t, creator, "/invitations", body, http.StatusCreated)
+
+		// le créateur ne peut pas accepter à la place de l'invité
+		expect(t, creator, "/invitations/accept", `{"user_id": "`+invitee.String()+`"}`, http.StatusForbidden)
+		status(t, invitee, "pending")
+		expect(t, invitee, "/invitations/accept", `{}`, http.StatusOK)
+		status(t, invitee, "accepted")
+	})
+
+	t.Run("join request", func(t *testing.T) {
+		expect(t, requester, "/join_requests", ``, http.StatusCreated)
+
+		approve := `{"user_id": "` + requester.String() + `"}`
+		expect(t, requester, "/invitations/accept", `{}`, http.StatusForbidden)
+		expect(t, member, "/invitations/accept", approve, http.StatusForbidden)
+		status(t, requester, "pending")
+		expect(t, creator, "/invitations/accept", approve, http.StatusOK)
+		status(t, requester, "accepted")
+	})
+
+	t.Run("invalid group id", func(t *testing.T) {
+		rec := serve(s, tokens[requester], http.MethodPost, "/api/v1/groups/not-a-uuid/join_requests", ``)
+		var apiErr APIError
+		json.NewDecoder(rec.Body).Decode(&apiErr)
+		if rec.Code != http.StatusBadRequest || apiErr.Code != CodeInvalidID {
+			t.Errorf("join request = %d %s; want 400 %s", rec.Code, apiErr.Code, CodeInvalidID)
+		}
+	})
+}
@@ -13,26 +13,26 @@ import (
 
 func (s *MyServer) CreatePostGroupHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			http.Error(w, "User not logged in", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User not logged in")
 			return
 		}
 
 		var postGroup models.PostGroup
-		if err := json.NewDecoder(r.Body).Decode(&postGroup); err != nil {
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+		if !decodeJSON(w, r, &postGroup) {
+			return
+		}
+		if id := r.PathValue("id"); id != "" {
+			postGroup.GroupID, _ = uuid.FromString(id)
+		}
+		if !checkValid(w, r, &postGroup) {
 			return
 		}
 
-		username, err := s.getUsernameByUserID(userID)
+		username, err := s.Store.Users().UsernameByID(userID)
 		if err != nil {
-			http.Error(w, "Failed to get username", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to get username")
 			return
 		}
 		postGroup.Username = username
@@ -42,17 +42,14 @@ func (s *MyServer) CreatePostGroupHandler() http.HandlerFunc {
 		postGroup.CreatedAt = time.Now()
 		postGroup.UpdatedAt = time.Now()
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			http.Error(w, "Failed to open database", http.StatusInternalServerError)
+		actor, _ := actorFromRequest(r)
+		if err := AuthorizeGroupMember(s.Store.Groups(), actor, postGroup.GroupID); err != nil {
+			writePolicyError(w, r, err)
 			return
 		}
-		defer DB.Close()
 
-		query := `INSERT INTO group_posts (id, group_id, user_id, title, content, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
-		_, err = DB.Exec(query, postGroup.ID, postGroup.GroupID, postGroup.UserID, postGroup.Title, postGroup.Content, postGroup.CreatedAt, postGroup.UpdatedAt)
-		if err != nil {
-			http.Error(w, "Failed to create post", http.StatusInternalServerError)
+		if err := s.Store.Groups().CreatePost(postGroup); err != nil {
+			WriteError(w, r, CodeInternal, "Failed to create post")
 			return
 		}
 
@@ -63,29 +60,18 @@ func (s *MyServer) CreatePostGroupHandler() http.HandlerFunc {
 
 func (s *MyServer) ListPostGroupHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
-		groupIDStr := r.URL.Query().Get("group_id")
+		groupIDStr := pathOrQuery(r, "id", "group_id")
 		if groupIDStr == "" {
-			http.Error(w, "Group ID not provided", http.StatusBadRequest)
+			WriteError(w, r, CodeValidation, "Group ID not provided", fieldRequired("group_id"))
 			return
 		}
 
 		groupID, err := uuid.FromString(groupIDStr)
 		if err != nil {
-			http.Error(w, "Invalid Group ID", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidID, "Invalid Group ID")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			http.Error(w, "Failed to open database", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
 		page := 1
 		limit := 10
 
@@ -107,33 +93,15 @@ func (s *MyServer) ListPostGroupHandler() http.HandlerFunc {
 		offset := (page - 1) * limit
 		log.Printf("Fetching posts from database (page: %d, limit: %d)\n", page, limit)
 
-		query := `
-		SELECT gp.id, gp.group_id, gp.user_id, u.username, gp.title, gp.content, gp.created_at, gp.updated_at
-		FROM group_posts gp
-		JOIN users u ON gp.user_id = u.id
-		WHERE gp.group_id = ?
-		LIMIT ? OFFSET ?
-	`
-		rows, err := DB.Query(query, groupID, limit, offset)
+		postsGroup, err := s.Store.Groups().ListPosts(groupID, limit, offset)
 		if err != nil {
-			http.Error(w, "Failed to retrieve posts", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to retrieve posts")
 			return
 		}
-		defer rows.Close()
-
-		var postsGroup []models.PostGroup
-		for rows.Next() {
-			var postgroup models.PostGroup
-			if err := rows.Scan(&postgroup.ID, &postgroup.GroupID, &postgroup.UserID, &postgroup.Username, &postgroup.Title, &postgroup.Content, &postgroup.CreatedAt, &postgroup.UpdatedAt); err != nil {
-				http.Error(w, "Failed to scan post", http.StatusInternalServerError)
-				return
-			}
-			postsGroup = append(postsGroup, postgroup)
-		}
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(postsGroup); err != nil {
-			http.Error(w, "Failed to encode posts", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode posts")
 		}
 	}
 }
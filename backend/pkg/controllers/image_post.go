@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -12,49 +13,47 @@ import (
 
 func (s *MyServer) UploadGroupImageHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Vérifiez la méthode HTTP
-		if r.Method != http.MethodPost {
-			http.Error(w, `{"error": "Method not allowed"}`, http.StatusMethodNotAllowed)
-			return
-		}
-
 		// Parsez le formulaire
 		err := r.ParseMultipartForm(10 << 20) // Limite de 10 MB
 		if err != nil {
-			http.Error(w, `{"error": "Failed to parse form"}`, http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Failed to parse form")
 			return
 		}
 
 		// Récupérez le fichier
 		file, handler, err := r.FormFile("image")
 		if err != nil {
-			http.Error(w, `{"error": "Failed to read file"}`, http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidImage, "Failed to read file")
 			return
 		}
 		defer file.Close()
 
-		// Sauvegardez le fichier sur le serveur (ou téléchargez-le sur un service cloud)
-		filePath := "./uploads/" + handler.Filename
-		dst, err := os.Create(filePath)
+		// Sauvegardez le fichier dans le dossier servi sous uploads.url_path
+		if err := os.MkdirAll(s.Config.Uploads.Dir, os.ModePerm); err != nil {
+			WriteError(w, r, CodeInternal, "Failed to save file")
+			return
+		}
+		dst, err := os.Create(filepath.Join(s.Config.Uploads.Dir, handler.Filename))
 		if err != nil {
-			http.Error(w, `{"error": "Failed to save file"}`, http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to save file")
 			return
 		}
 		defer dst.Close()
 
 		if _, err := io.Copy(dst, file); err != nil {
-			http.Error(w, `{"error": "Failed to copy file"}`, http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to copy file")
 			return
 		}
 
 		// Retournez l'URL de l'image
-		imageURL := "http://127.0.0.1:8079/image_path/" + handler.Filename
+		imageURL := s.Config.ImageURL(handler.Filename)
 		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(map[string]string{"url": imageURL})
 	}
 }
 
-func UploadImages(w http.ResponseWriter, r *http.Request, filePath string) (string, error) {
+// UploadImages enregistre l'image du formulaire dans uploads.dir et renvoie son URL publique
+func (s *MyServer) UploadImages(w http.ResponseWriter, r *http.Request) (string, error) {
 	err := r.ParseMultipartForm(20 << 20) // Limite de 20MB
 	if err != nil {
 		return "", fmt.Errorf("error parsing form: %v", err)
@@ -69,6 +68,7 @@ func UploadImages(w http.ResponseWriter, r *http.Request, filePath string) (stri
 	}
 	defer file.Close()
 
+	filePath := s.Config.Uploads.Dir
 	err = os.MkdirAll(filePath, os.ModePerm)
 	if err != nil {
 		return "", fmt.Errorf("error creating directory: %v", err)
@@ -87,10 +87,30 @@ func UploadImages(w http.ResponseWriter, r *http.Request, filePath string) (stri
 	}
 
 	// l'URL image
-	imageURL := fmt.Sprintf("http://127.0.0.1:8079/image_path/%s", handler.Filename)
+	imageURL := s.Config.ImageURL(handler.Filename)
 	return imageURL, nil
 }
 
+// discardUpload supprime l'image téléversée pour une écriture en base qui a échoué,
+// sauf si un autre contenu référence déjà ce fichier (même nom téléversé deux fois)
+func (s *MyServer) discardUpload(imageURL string) {
+	file, ok := s.Config.ImageFile(imageURL)
+	if !ok {
+		return
+	}
+	used, err := s.Store.Posts().ImageInUse(imageURL)
+	if err != nil {
+		log.Printf("Failed to check image %s: %v", file, err)
+		return
+	}
+	if used {
+		return
+	}
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		log.Printf("Failed to remove image %s: %v", file, err)
+	}
+}
+
 func IsValidImageExtension(filename string) bool {
 	validExtensions := []string{".jpg", ".jpeg", ".png", ".gif"}
 	fileExt := strings.ToLower(filepath.Ext(filename))
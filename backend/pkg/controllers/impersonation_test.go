@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+// impersonationFixture : un administrateur connecté et le token d'assistance qu'il a obtenu pour target
+type impersonationFixture struct {
+	s          *MyServer
+	store      *db.DBStore
+	admin      uuid.UUID
+	adminToken string
+	target     uuid.UUID
+	token      string // token d'assistance
+}
+
+func newImpersonationFixture(t *testing.T) *impersonationFixture {
+	store := dbtest.SQLite(t)
+	f := &impersonationFixture{
+		s:      newTestServer(t, store, config.Default()),
+		store:  store,
+		admin:  dbtest.User(t, store, "admin"),
+		target: dbtest.User(t, store, "target"),
+	}
+	if err := store.Users().SetRole(f.admin, RoleAdmin); err != nil {
+		t.Fatal(err)
+	}
+	f.adminToken = accessToken(t, f.s, f.admin, "admin", RoleAdmin)
+
+	rec := serve(f.s, f.adminToken, http.MethodPost, "/admin/users/"+f.target.String()+"/impersonate", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("impersonate = %d %s", rec.Code, rec.Body)
+	}
+	var resp LoginResponses
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Token == "" {
+		t.Fatalf("impersonate response: %v", err)
+	}
+	f.token = resp.Token
+	return f
+}
+
+func TestImpersonationCannotEnrollMFA(t *testing.T) {
+	f := newImpersonationFixture(t)
+
+	if rec := serve(f.s, f.token, http.MethodGet, "/myprofil", ""); rec.Code != http.StatusOK {
+		t.Fatalf("GET /myprofil with the impersonation token = %d %s; want 200", rec.Code, rec.Body)
+	}
+
+	for _, path := range []string{"/mfa/enroll", "/mfa/confirm"} {
+		rec := serve(f.s, f.token, http.MethodPost, path, `{"code": "123456"}`)
+		var apiErr APIError
+		json.NewDecoder(rec.Body).Decode(&apiErr)
+		if rec.Code != http.StatusForbidden || apiErr.Code != CodeReadOnlySession {
+			t.Errorf("POST %s = %d %s; want 403 %s", path, rec.Code, apiErr.Code, CodeReadOnlySession)
+		}
+	}
+	if n := dbtest.Scalar[int](t, f.store.DB(), `SELECT COUNT(*) FROM user_mfa WHERE user_id = ?`, f.target); n != 0 {
+		t.Error("an MFA secret was stored for the impersonated user")
+	}
+}
+
+func TestImpersonationLogoutKeepsAdminSession(t *testing.T) {
+	f := newImpersonationFixture(t)
+
+	if rec := serve(f.s, f.token, http.MethodPost, "/logout", ""); rec.Code != http.StatusOK {
+		t.Fatalf("logout = %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(f.s, f.adminToken, http.MethodGet, "/admin/users", ""); rec.Code != http.StatusOK {
+		t.Errorf("admin request after the impersonation logout = %d %s; want 200", rec.Code, rec.Body)
+	}
+
+	// la déconnexion de l'administrateur révoque bien sa session
+	if rec := serve(f.s, f.adminToken, http.MethodPost, "/logout", ""); rec.Code != http.StatusOK {
+		t.Fatalf("logout = %d %s", rec.Code, rec.Body)
+	}
+	if rec := serve(f.s, f.adminToken, http.MethodGet, "/admin/users", ""); rec.Code != http.StatusUnauthorized {
+		t.Errorf("admin request after logout = %d; want 401", rec.Code)
+	}
+}
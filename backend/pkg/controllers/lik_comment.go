@@ -2,9 +2,7 @@ package controllers
 
 import (
 	"backend/pkg/models"
-	"database/sql"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
 
@@ -13,35 +11,36 @@ import (
 
 func (s *MyServer) LikeComment() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
-			return
-		}
-
 		var requestData models.CommentLike
 
 		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
 			log.Println("Error decoding JSON:", err)
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid JSON format")
 			return
 		}
 
-		commentID, err := uuid.FromString(requestData.CommentID.String())
-		if err != nil {
-			http.Error(w, `{"error": "Invalid comment ID"}`, http.StatusBadRequest)
+		commentID := requestData.CommentID
+		if commentID == uuid.Nil {
+			WriteError(w, r, CodeInvalidID, "Invalid comment ID")
 			return
 		}
 
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
 			log.Println("User ID not found in context or is invalid")
-			http.Error(w, `{"error": "Unauthorized"}`, http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
-		if err := s.ToggleLikeComment(userID, commentID, "like"); err != nil {
+		actor, _ := actorFromRequest(r)
+		if err := AuthorizeCommentView(s.Store, actor, commentID); err != nil {
+			writePolicyError(w, r, err)
+			return
+		}
+
+		if err := s.Store.Comments().ToggleReaction(commentID, userID, "like"); err != nil {
 			log.Println("Error toggling like:", err)
-			http.Error(w, "Failed to like post", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to like post")
 			return
 		}
 
@@ -51,22 +50,17 @@ func (s *MyServer) LikeComment() http.HandlerFunc {
 
 func (s *MyServer) UnlikeComment() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Invalid request method", http.StatusMethodNotAllowed)
-			return
-		}
-
 		var requestData models.CommentLike
 
 		if err := json.NewDecoder(r.Body).Decode(&requestData); err != nil {
 			log.Println("Error decoding JSON:", err)
-			http.Error(w, "Invalid JSON format", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid JSON format")
 			return
 		}
 
-		commentID, err := uuid.FromString(requestData.CommentID.String())
-		if err != nil {
-			log.Println("Invalid comment ID", err)
+		commentID := requestData.CommentID
+		if commentID == uuid.Nil {
+			log.Println("Invalid comment ID")
 			return
 		}
 
@@ -76,141 +70,47 @@ func (s *MyServer) UnlikeComment() http.HandlerFunc {
 			return
 		}
 
-		if err := s.ToggleLikeComment(userID, commentID, "unlike"); err != nil {
-			log.Println("Failed to toggle like:", err)
+		actor, _ := actorFromRequest(r)
+		if err := AuthorizeCommentView(s.Store, actor, commentID); err != nil {
+			writePolicyError(w, r, err)
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
-	}
-}
-
-func (s *MyServer) ToggleLikeComment(userID, commentID uuid.UUID, interactionType string) error {
-	DB, err := s.Store.OpenDatabase()
-	if err != nil {
-		log.Println("Failed to open database:", err)
-		return err
-	}
-	defer DB.Close()
-
-	tx, err := DB.Begin()
-	if err != nil {
-		log.Println("Failed to begin transaction:", err)
-		return err
-	}
-
-	switch interactionType {
-	case "like":
-		if err := ToggleLikeComment(userID, commentID, tx); err != nil {
-			tx.Rollback()
+		if err := s.Store.Comments().ToggleReaction(commentID, userID, "unlike"); err != nil {
 			log.Println("Failed to toggle like:", err)
-			return err
-		}
-	case "unlike":
-		if err := ToggleUnlikeComment(userID, commentID, tx); err != nil {
-			tx.Rollback()
-			log.Println("Failed to toggle unlike:", err)
-			return err
+			return
 		}
-	default:
-		tx.Rollback()
-		return fmt.Errorf("invalid interaction type: %s", interactionType)
-	}
-
-	return tx.Commit()
-
-}
-
-func ToggleLikeComment(userID, commentID uuid.UUID, tx *sql.Tx) error {
-	liked, err := UserLikedComment(userID, commentID, tx)
-	if err != nil {
-		return err
-	}
-
-	if liked {
-		return DeleteLikeComment(userID, commentID, tx)
-	}
-
-	return CreateLikeComment(userID, commentID, tx)
-}
-
-func ToggleUnlikeComment(userID, commentID uuid.UUID, tx *sql.Tx) error {
-	liked, err := UserLikedComment(userID, commentID, tx)
-	if err != nil {
-		return err
-	}
 
-	unliked, err := UserUnlikedComment(userID, commentID, tx)
-	if err != nil {
-		return err
-	}
-
-	if liked {
-		return DeleteLikeComment(userID, commentID, tx)
-	}
-
-	if unliked {
-		return DeleteUnlikeComment(userID, commentID, tx)
+		w.WriteHeader(http.StatusOK)
 	}
-
-	return CreateUnlikeComment(userID, commentID, tx)
 }
 
-/*--------------------------------------------------------------------*/
-
-func CreateLikeComment(userID, commentID uuid.UUID, tx *sql.Tx) error {
-	_, err := tx.Exec("INSERT INTO comment_interactions (user_id, comment_id, interaction_type) VALUES (?, ?, 'like')", userID, commentID)
-	if err != nil {
-		return err
-	}
-	return UpdateCommentLikeCount(tx, commentID, "likes", true)
-}
+// CommentLikeHandler pose (liked) ou retire le like du commentaire {id} pour /api/v1, de façon idempotente
+func (s *MyServer) CommentLikeHandler(liked bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := actorFromRequest(r)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
 
-func DeleteLikeComment(userID, commentID uuid.UUID, tx *sql.Tx) error {
-	_, err := tx.Exec("DELETE FROM comment_interactions WHERE user_id = ? AND comment_id = ? AND interaction_type = 'like'", userID, commentID)
-	if err != nil {
-		return err
-	}
-	return UpdateCommentLikeCount(tx, commentID, "likes", false)
-}
+		commentID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid comment ID")
+			return
+		}
 
-func CreateUnlikeComment(userID, commentID uuid.UUID, tx *sql.Tx) error {
-	_, err := tx.Exec("INSERT INTO comment_interactions (user_id, comment_id, interaction_type) VALUES (?, ?, 'unlike')", userID, commentID)
-	if err != nil {
-		return err
-	}
-	return UpdateCommentLikeCount(tx, commentID, "unlikes", true)
-}
+		if err := AuthorizeCommentView(s.Store, actor, commentID); err != nil {
+			writePolicyError(w, r, err)
+			return
+		}
 
-func DeleteUnlikeComment(userID, commentID uuid.UUID, tx *sql.Tx) error {
-	_, err := tx.Exec("DELETE FROM comment_interactions WHERE user_id = ? AND comment_id = ? AND interaction_type = 'unlike'", userID, commentID)
-	if err != nil {
-		return err
-	}
-	return UpdateCommentLikeCount(tx, commentID, "unlikes", false)
-}
+		if err := s.Store.Comments().SetLike(commentID, actor.UserID, liked); err != nil {
+			log.Println("Failed to update comment like:", err)
+			WriteError(w, r, CodeInternal, "Failed to update like")
+			return
+		}
 
-func UpdateCommentLikeCount(tx *sql.Tx, commentID uuid.UUID, likeType string, increment bool) error {
-	operation := "+"
-	if !increment {
-		operation = "-"
+		w.WriteHeader(http.StatusOK)
 	}
-
-	query := fmt.Sprintf("UPDATE comments SET total_%s = total_%s %s 1 WHERE id = ?", likeType, likeType, operation)
-	_, err := tx.Exec(query, commentID)
-	return err
-}
-
-/*--------------------------------------------------------------------*/
-
-func UserLikedComment(userID, commentID uuid.UUID, tx *sql.Tx) (bool, error) {
-	var count int
-	err := tx.QueryRow("SELECT COUNT(*) FROM comment_interactions WHERE user_id = ? AND comment_id = ? AND interaction_type = 'like'", userID, commentID).Scan(&count)
-	return count > 0, err
-}
-
-func UserUnlikedComment(userID, commentID uuid.UUID, tx *sql.Tx) (bool, error) {
-	var count int
-	err := tx.QueryRow("SELECT COUNT(*) FROM comment_interactions WHERE user_id = ? AND comment_id = ? AND interaction_type = 'unlike'", userID, commentID).Scan(&count)
-	return count > 0, err
 }
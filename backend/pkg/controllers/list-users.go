@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -15,20 +14,12 @@ func (s *MyServer) ListUsers() http.HandlerFunc {
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
 			log.Println(" User ID not found in context")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
 		log.Println("🔍 User ID found:", userID)
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Printf(" Failed to open database: %v\n", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
 		page, limit := 1, 10
 		queryParams := r.URL.Query()
 
@@ -46,50 +37,21 @@ func (s *MyServer) ListUsers() http.HandlerFunc {
 		offset := (page - 1) * limit
 		log.Printf("📦 Fetching users (page: %d, limit: %d, offset: %d)\n", page, limit, offset)
 
-		query := `
-			SELECT 
-				u.id, 
-				u.username, 
-				u.avatar, 
-				EXISTS (
-					SELECT 1 FROM follow_requests fr 
-					WHERE fr.sender_id = ? AND fr.receiver_id = u.id
-				) AS is_request_pending,
-				EXISTS (
-					SELECT 1 FROM followers f 
-					WHERE f.follower_id = ? AND f.followed_id = u.id
-				) AS is_following
-			FROM users u
-			WHERE u.id != ?
-			LIMIT ? OFFSET ?;
-		`
-
-		rows, err := DB.Query(query, userID, userID, userID, limit, offset)
+		summaries, err := s.Store.Users().List(userID, limit, offset)
 		if err != nil {
 			log.Printf(" Error fetching users: %v\n", err)
-			http.Error(w, "Failed to fetch users", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to fetch users")
 			return
 		}
-		defer rows.Close()
 
 		var users []map[string]interface{}
-		for rows.Next() {
-			var id, username string
-			var avatar sql.NullString
-			var isRequestPending, isFollowing bool
-
-			if err := rows.Scan(&id, &username, &avatar, &isRequestPending, &isFollowing); err != nil {
-				log.Printf(" Error scanning user data: %v\n", err)
-				http.Error(w, "Failed to read user data", http.StatusInternalServerError)
-				return
-			}
-
+		for _, u := range summaries {
 			users = append(users, map[string]interface{}{
-				"id":               id,
-				"username":         username,
-				"avatar":           avatar.String,
-				"isRequestPending": isRequestPending,
-				"isFollowing":      isFollowing,
+				"id":               u.ID,
+				"username":         u.Username,
+				"avatar":           u.Avatar,
+				"isRequestPending": u.IsRequestPending,
+				"isFollowing":      u.IsFollowing,
 			})
 		}
 
@@ -102,7 +64,7 @@ func (s *MyServer) ListUsers() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			log.Printf(" Error encoding response: %v\n", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode response")
 		}
 	}
 }
@@ -114,19 +76,12 @@ func (s *MyServer) ListAmis() http.HandlerFunc {
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
 			log.Println("User ID not found in context")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 		log.Println("User ID found:", userID)
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Printf("Failed to open database: %v\n", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
+		var err error
 		page := 1
 		limit := 10
 		queryParams := r.URL.Query()
@@ -147,40 +102,19 @@ func (s *MyServer) ListAmis() http.HandlerFunc {
 
 		log.Printf("Fetching friends (page: %d, limit: %d, offset: %d)\n", page, limit, offset)
 
-		query := `
-            SELECT 
-                u.id, 
-                u.username, 
-                u.avatar  
-            FROM users u
-            INNER JOIN followers f ON f.followed_id = u.id
-            WHERE f.follower_id = ?
-            LIMIT ? OFFSET ?;
-        `
-
-		rows, err := DB.Query(query, userID, limit, offset)
+		friends, err := s.Store.Follows().Friends(userID, limit, offset)
 		if err != nil {
 			log.Printf("Error fetching friends: %v\n", err)
-			http.Error(w, "Failed to fetch friends", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to fetch friends")
 			return
 		}
-		defer rows.Close()
 
 		var result []map[string]interface{}
-		for rows.Next() {
-			var id, username string
-			var avatar sql.NullString
-
-			if err := rows.Scan(&id, &username, &avatar); err != nil {
-				log.Printf("Error scanning friend data: %v\n", err)
-				http.Error(w, "Failed to read friend data", http.StatusInternalServerError)
-				return
-			}
-
+		for _, f := range friends {
 			result = append(result, map[string]interface{}{
-				"id":       id,
-				"username": username,
-				"avatar":   avatar.String,
+				"id":       f.ID,
+				"username": f.Username,
+				"avatar":   f.Avatar,
 			})
 		}
 
@@ -195,7 +129,7 @@ func (s *MyServer) ListAmis() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			log.Printf("Error encoding response: %v\n", err)
-			http.Error(w, "Failed to encode response", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode response")
 		}
 	}
 }
@@ -1,9 +1,9 @@
 package controllers
 
 import (
-	"backend/pkg/zwt"
-	"database/sql"
+	"backend/pkg/db"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -15,26 +15,27 @@ import (
 )
 
 type LoginResponses struct {
-	Token   string `json:"token,omitempty"`
-	Message string `json:"message"`
+	Token                 string `json:"token,omitempty"`
+	RefreshToken          string `json:"refresh_token,omitempty"`
+	ExpiresIn             int64  `json:"expires_in,omitempty"`
+	MFAToken              string `json:"mfa_token,omitempty"`
+	MFARequired           bool   `json:"mfa_required,omitempty"`
+	MFAEnrollmentRequired bool   `json:"mfa_enrollment_required,omitempty"`
+	Message               string `json:"message"`
 }
 
 func (s MyServer) LoginHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			SendJSONErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		var loginData struct {
 			Identifier string `json:"email"`
 			Password   string `json:"password"`
+			Device     string `json:"device"`
 		}
 
 		err := json.NewDecoder(r.Body).Decode(&loginData)
 		if err != nil {
 			log.Println("Failed to decode JSON body:", err)
-			SendJSONErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
 			return
 		}
 
@@ -43,80 +44,148 @@ func (s MyServer) LoginHandler() http.HandlerFunc {
 
 		if identifier == "" || password == "" {
 			log.Println("Identifier or password is empty")
-			SendJSONErrorResponse(w, "Incorrect username or password", http.StatusUnauthorized)
+			WriteError(w, r, CodeInvalidCredentials, "Incorrect username or password")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			SendJSONErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+		userID, storedPassword, username, credErr := getUserCredentials(s.Store.Users(), identifier)
+
+		// limitation des tentatives par compte et par adresse, vérifiée avant le mot de passe
+		accountKey := accountThrottleKey(userID, identifier)
+		if wait := s.loginRetryAfter(accountKey, addressThrottleKey(r)); wait > 0 {
+			log.Printf("Login throttled for %s, retry in %s", accountKey, wait)
+			s.recordLoginAttempt(identifier, userID, r, false, "throttled")
+			sendTooManyAttempts(w, r, wait)
 			return
 		}
-		defer DB.Close()
 
-		userID, storedPassword, username, err := getUserCredentials(DB, identifier)
-		if err != nil {
-			log.Println("User credential error:", err)
-			SendJSONErrorResponse(w, "Incorrect username or password", http.StatusUnauthorized)
+		if credErr != nil {
+			log.Println("User credential error:", credErr)
+			s.recordLoginFailure(accountKey, uuid.Nil, r)
+			s.recordLoginAttempt(identifier, uuid.Nil, r, false, "unknown_user")
+			WriteError(w, r, CodeInvalidCredentials, "Incorrect username or password")
 			return
 		}
 
 		err = bcrypt.CompareHashAndPassword([]byte(storedPassword), []byte(password))
 		if err != nil {
 			log.Println("Incorrect password")
-			SendJSONErrorResponse(w, "Incorrect username or password", http.StatusUnauthorized)
+			s.recordLoginFailure(accountKey, userID, r)
+			s.recordLoginAttempt(identifier, userID, r, false, "bad_password")
+			WriteError(w, r, CodeInvalidCredentials, "Incorrect username or password")
 			return
 		}
 
-		token, err := zwt.GenerateJWT(userID, username)
+		s.resetLoginFailures(accountKey)
+
+		sus, err := ActiveSuspension(s.Store.Admin(), userID)
 		if err != nil {
-			log.Println("Failed to generate token:", err)
-			SendJSONErrorResponse(w, "Internal server error", http.StatusInternalServerError)
+			log.Println("Failed to check suspension:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		if sus != nil {
+			s.recordLoginAttempt(identifier, userID, r, false, sus.Kind)
+			WriteError(w, r, CodeAccountSuspended, suspensionMessage(sus))
+			return
+		}
+
+		s.recordLoginAttempt(identifier, userID, r, true, "")
+
+		// 2FA : le vrai token n'est émis qu'après vérification du code (/mfa/verify)
+		if challenge, pending, err := s.mfaChallenge(userID, username); err != nil {
+			log.Println("Failed to check MFA status:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		} else if pending {
+			SendJSONResponse(w, challenge, http.StatusOK)
+			return
+		}
+
+		response, err := s.startSession(w, r, userID, username, loginData.Device)
+		if err != nil {
+			log.Println("Failed to start session:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
 			return
 		}
 
 		log.Printf("User logged in successfully, userID: %s", userID)
 
-		http.SetCookie(w, &http.Cookie{
-			Name:     "token",
-			Value:    token,
-			Expires:  time.Now().Add(24 * time.Hour),
-			HttpOnly: true,
-			Secure:   false,
-			SameSite: http.SameSiteStrictMode,
-		})
+		SendJSONResponse(w, response, http.StatusOK)
+	}
+}
 
-		http.SetCookie(w, &http.Cookie{
-			Name:    "username",
-			Value:   username,
-			Expires: time.Now().Add(30 * time.Minute),
-		})
+// mfaChallenge renvoie la réponse de la deuxième étape quand la 2FA est active ou exigée par le rôle :
+// un token "mfa_pending" à présenter à /mfa/verify, ou "mfa_enroll" pour configurer la 2FA.
+// pending est faux quand la session peut être ouverte directement. Partagé par le mot de passe et OAuth
+func (s *MyServer) mfaChallenge(userID uuid.UUID, username string) (LoginResponses, bool, error) {
+	mfaEnabled, mfaRequired, err := s.Store.MFA().Status(userID)
+	if err != nil {
+		return LoginResponses{}, false, err
+	}
+	if !mfaEnabled && !mfaRequired {
+		return LoginResponses{}, false, nil
+	}
 
-		SendJSONResponse(w, LoginResponses{Token: token, Message: "Login successful"}, http.StatusOK)
+	purpose, message := mfaPurposePending, "Two-factor code required"
+	if !mfaEnabled {
+		purpose, message = mfaPurposeEnroll, "Two-factor authentication must be set up for your role"
+	}
+	mfaToken, err := s.issueMFAToken(userID, username, purpose)
+	if err != nil {
+		return LoginResponses{}, false, fmt.Errorf("failed to generate MFA token: %w", err)
 	}
+	return LoginResponses{
+		MFAToken:              mfaToken,
+		MFARequired:           mfaEnabled,
+		MFAEnrollmentRequired: !mfaEnabled,
+		ExpiresIn:             int64(mfaTokenTTL.Seconds()),
+		Message:               message,
+	}, true, nil
 }
 
-// getUserCredentials vérifie l'existence de l'utilisateur et renvoie une erreur générique si l'utilisateur n'existe pas ou si une erreur survient
-func getUserCredentials(DB *sql.DB, identifier string) (uuid.UUID, string, string, error) {
-	var userID uuid.UUID
-	var storedPassword, username string
-	var query string
-
-	// si l'identifiant est un email ou un nom d'utilisateur
-	if strings.Contains(identifier, "@") {
-		query = "SELECT id, password_hash, username FROM users WHERE email = ?"
-	} else {
-		query = "SELECT id, password_hash, username FROM users WHERE username = ?"
+// startSession ouvre une session pour l'utilisateur authentifié, émet la paire de tokens et pose les cookies.
+// Partagé par la connexion par mot de passe et la connexion OAuth
+func (s *MyServer) startSession(w http.ResponseWriter, r *http.Request, userID uuid.UUID, username, device string) (LoginResponses, error) {
+	session, refreshToken, err := CreateSession(s.Store.Sessions(), userID, device, r)
+	if err != nil {
+		return LoginResponses{}, fmt.Errorf("failed to create session: %w", err)
+	}
+
+	role, err := s.Store.Users().Role(userID)
+	if err != nil {
+		return LoginResponses{}, fmt.Errorf("failed to get role: %w", err)
 	}
 
-	err := DB.QueryRow(query, identifier).Scan(&userID, &storedPassword, &username)
+	token, err := s.Tokens.GenerateJWT(userID, username, normalizeRole(role), session.ID.String())
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return uuid.Nil, "", "", fmt.Errorf("incorrect  or password")
+		return LoginResponses{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	s.setAuthCookies(w, token, refreshToken)
+
+	http.SetCookie(w, &http.Cookie{
+		Name:    "username",
+		Value:   username,
+		Expires: time.Now().Add(30 * time.Minute),
+	})
+
+	return LoginResponses{
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(s.Tokens.TTL().Seconds()),
+		Message:      "Login successful",
+	}, nil
+}
+
+// getUserCredentials vérifie l'existence de l'utilisateur et renvoie une erreur générique si l'utilisateur n'existe pas ou si une erreur survient
+func getUserCredentials(users db.UserRepo, identifier string) (uuid.UUID, string, string, error) {
+	userID, storedPassword, username, err := users.Credentials(identifier)
+	if err != nil {
+		if !errors.Is(err, db.ErrNotFound) {
+			// masque les erreurs SQL internes
+			log.Printf("Database error: %v", err)
 		}
-		// masque les erreurs SQL internes
-		log.Printf("Database error: %v", err)
 		return uuid.Nil, "", "", fmt.Errorf("incorrect username or password")
 	}
 
@@ -128,29 +197,53 @@ func SendJSONResponse(w http.ResponseWriter, response LoginResponses, statusCode
 	w.WriteHeader(statusCode)
 	if err := json.NewEncoder(w).Encode(response); err != nil {
 		log.Printf("Failed to encode response: %v", err)
-		SendJSONErrorResponse(w, "Internal server error", http.StatusInternalServerError)
 	}
 }
 
-func SendJSONErrorResponse(w http.ResponseWriter, message string, statusCode int) {
+// gère les requêtes de déconnexion : révoque la session du token présenté puis efface les cookies
+func (s *MyServer) LogoutHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		sessionID := uuid.Nil
 
-	if w.Header().Get("Content-Type") == "" {
-		w.Header().Set("Content-Type", "application/json")
-	}
-	if statusCode != http.StatusOK {
-		w.WriteHeader(statusCode)
-	}
-	err := json.NewEncoder(w).Encode(map[string]string{"error": message})
-	if err != nil {
-		log.Printf("Failed to encode JSON error response: %v", err)
-	}
+		// le token d'accès peut être expiré, on se rabat alors sur le refresh token
+		if token, err := bearerToken(r); err == nil {
+			if claims, err := s.Tokens.VerifyJWT(token); err == nil {
+				// un token d'assistance porte la session de l'administrateur : il expire seul,
+				// sans révoquer cette session ni effacer ses cookies
+				if claims.Impersonator != "" {
+					SendJSONResponse(w, LoginResponses{Message: "Impersonation token discarded"}, http.StatusOK)
+					return
+				}
+				sessionID, _ = uuid.FromString(claims.SessionID)
+			}
+		}
 
-}
+		if sessionID == uuid.Nil {
+			if presented := refreshTokenFromRequest(r); presented != "" {
+				sessionID, _ = sessionFromRefreshToken(s.Store.Sessions(), presented)
+			}
+		}
 
-// gère les requêtes de déconnexion
-func (s *MyServer) LogoutHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// Supprime le cookie de token
+		if sessionID != uuid.Nil {
+			if err := s.Store.Sessions().Revoke(sessionID); err != nil {
+				log.Println("Failed to revoke session:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+			s.WebSocketChat.CloseSession(sessionID.String())
+			log.Println("Session revoked:", sessionID)
+		}
+
+		// Supprime les cookies de token
+		http.SetCookie(w, &http.Cookie{
+			Name:     "refresh_token",
+			Value:    "",
+			Expires:  time.Unix(0, 0),
+			Secure:   false,
+			Path:     "/token",
+			HttpOnly: true,
+			SameSite: http.SameSiteStrictMode,
+		})
 		http.SetCookie(w, &http.Cookie{
 			Name:     "token",
 			Value:    "",
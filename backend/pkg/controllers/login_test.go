@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/mail"
+	"backend/pkg/throttle"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestLoginThrottle(t *testing.T) {
+	store := dbtest.SQLite(t)
+	s := newTestServer(t, store, config.Default())
+	userID := dbtest.User(t, store, "alice")
+
+	login := func(t *testing.T, password string) *httptest.ResponseRecorder {
+		t.Helper()
+		body := `{"email": "alice@example.com", "password": "` + password + `"}`
+		return serve(s, "", http.MethodPost, "/login", body)
+	}
+	wantThrottled := func(t *testing.T, rec *httptest.ResponseRecorder, maxWait time.Duration) {
+		t.Helper()
+		var apiErr APIError
+		json.NewDecoder(rec.Body).Decode(&apiErr)
+		if rec.Code != http.StatusTooManyRequests || apiErr.Code != CodeTooManyRequests {
+			t.Fatalf("login = %d %s; want 429 %s", rec.Code, apiErr.Code, CodeTooManyRequests)
+		}
+		retry, err := strconv.Atoi(rec.Header().Get("Retry-After"))
+		if err != nil || retry < 1 || time.Duration(retry)*time.Second > maxWait {
+			t.Errorf("Retry-After = %q; want between 1 and %d seconds", rec.Header().Get("Retry-After"), int(maxWait.Seconds()))
+		}
+	}
+	attempts := func(reason string) int {
+		return dbtest.Scalar[int](t, store.DB(), `SELECT COUNT(*) FROM login_attempts WHERE user_id = ? AND reason = ?`, userID, reason)
+	}
+	accountKey := accountThrottleKey(userID, "")
+
+	t.Run("backoff after the free attempts", func(t *testing.T) {
+		for i := range throttle.AccountPolicy.FreeAttempts + 1 {
+			if rec := login(t, "wrong"); rec.Code != http.StatusUnauthorized {
+				t.Fatalf("attempt %d = %d %s; want 401", i+1, rec.Code, rec.Body)
+			}
+		}
+		// même le bon mot de passe est refusé tant que le délai court, sans être vérifié
+		wantThrottled(t, login(t, "password"), throttle.AccountPolicy.BaseDelay)
+		if n := attempts("throttled"); n != 1 {
+			t.Errorf("%d throttled attempts logged; want 1", n)
+		}
+		if n := attempts("bad_password"); n != throttle.AccountPolicy.FreeAttempts+1 {
+			t.Errorf("%d bad_password attempts logged; want %d", n, throttle.AccountPolicy.FreeAttempts+1)
+		}
+	})
+
+	t.Run("lockout", func(t *testing.T) {
+		// échecs anciens, hors délai mais dans la fenêtre : le prochain atteint le seuil
+		s.LoginLimiter.Reset(accountKey)
+		past := time.Now().Add(-time.Hour)
+		for range throttle.AccountPolicy.LockoutThreshold - 1 {
+			if _, err := s.LoginLimiter.Fail(accountKey, throttle.AccountPolicy, past); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if rec := login(t, "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("login = %d %s; want 401", rec.Code, rec.Body)
+		}
+		wantThrottled(t, login(t, "password"), throttle.AccountPolicy.LockoutDuration)
+
+		if _, ok := s.Mailer.(*mail.MemoryMailer).Last("alice@example.com"); !ok {
+			t.Error("no lockout notice sent")
+		}
+	})
+
+	t.Run("success resets the account counter", func(t *testing.T) {
+		s.LoginLimiter.Reset(accountKey)
+		if rec := login(t, "wrong"); rec.Code != http.StatusUnauthorized {
+			t.Fatalf("login = %d %s; want 401", rec.Code, rec.Body)
+		}
+		if rec := login(t, "password"); rec.Code != http.StatusOK {
+			t.Fatalf("login = %d %s; want 200", rec.Code, rec.Body)
+		}
+		if res, _ := s.LoginLimiter.Fail(accountKey, throttle.AccountPolicy, time.Now()); res.Failures != 1 {
+			t.Errorf("account counter = %d after a successful login; want 1", res.Failures)
+		}
+	})
+}
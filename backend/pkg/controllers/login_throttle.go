@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"backend/pkg/mail"
+	"backend/pkg/models"
+	"backend/pkg/throttle"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// clés du limiteur : par compte (ou identifiant inconnu) et par adresse du client
+func accountThrottleKey(userID uuid.UUID, identifier string) string {
+	if userID != uuid.Nil {
+		return "account:" + userID.String()
+	}
+	return "account:" + strings.ToLower(identifier)
+}
+
+func addressThrottleKey(r *http.Request) string {
+	return "ip:" + clientIP(r)
+}
+
+// loginRetryAfter retourne le délai d'attente imposé au compte ou à l'adresse (0 si la tentative est permise)
+func (s *MyServer) loginRetryAfter(keys ...string) time.Duration {
+	var wait time.Duration
+	now := time.Now()
+	for _, key := range keys {
+		d, err := s.LoginLimiter.Allow(key, now)
+		if err != nil {
+			log.Println("Failed to check login throttle:", err)
+			continue
+		}
+		if d > wait {
+			wait = d
+		}
+	}
+	return wait
+}
+
+// sendTooManyAttempts répond 429 avec l'en-tête Retry-After
+func sendTooManyAttempts(w http.ResponseWriter, r *http.Request, wait time.Duration) {
+	w.Header().Set("Retry-After", fmt.Sprint(int(math.Ceil(wait.Seconds()))))
+	WriteError(w, r, CodeTooManyRequests, "Too many failed attempts, try again later")
+}
+
+// recordLoginFailure incrémente les compteurs et prévient le propriétaire si le compte vient d'être verrouillé
+func (s *MyServer) recordLoginFailure(accountKey string, userID uuid.UUID, r *http.Request) {
+	now := time.Now()
+
+	res, err := s.LoginLimiter.Fail(accountKey, throttle.AccountPolicy, now)
+	if err != nil {
+		log.Println("Failed to record login failure:", err)
+	} else if res.LockedOut {
+		log.Printf("🔒 Account %s locked until %s after %d failed attempts", accountKey, res.BlockedUntil.Format(time.RFC3339), res.Failures)
+		if userID != uuid.Nil {
+			s.notifyLockout(userID, res.BlockedUntil, r)
+		}
+	}
+
+	if _, err := s.LoginLimiter.Fail(addressThrottleKey(r), throttle.AddressPolicy, now); err != nil {
+		log.Println("Failed to record login failure:", err)
+	}
+}
+
+// resetLoginFailures efface le compteur du compte après une connexion réussie (celui de l'adresse est conservé)
+func (s *MyServer) resetLoginFailures(accountKey string) {
+	if err := s.LoginLimiter.Reset(accountKey); err != nil {
+		log.Println("Failed to reset login throttle:", err)
+	}
+}
+
+// notifyLockout prévient le propriétaire du compte par email
+func (s *MyServer) notifyLockout(userID uuid.UUID, until time.Time, r *http.Request) {
+	email, err := s.Store.Users().Email(userID)
+	if err != nil {
+		log.Println("Failed to fetch user email for lockout notice:", err)
+		return
+	}
+
+	err = s.Mailer.Send(mail.Message{
+		To:      email,
+		Subject: "Your account has been temporarily locked",
+		Body: "We detected too many failed sign-in attempts on your account (last one from " + clientIP(r) + ").\n\n" +
+			"Sign-in is blocked until " + until.Format("2006-01-02 15:04 MST") + ".\n\n" +
+			"If this was not you, we recommend resetting your password.",
+	})
+	if err != nil {
+		log.Println("Failed to send lockout notice:", err)
+	}
+}
+
+// recordLoginAttempt ajoute une ligne au journal login_attempts
+func (s *MyServer) recordLoginAttempt(identifier string, userID uuid.UUID, r *http.Request, success bool, reason string) {
+	err := s.Store.Users().RecordLoginAttempt(models.LoginAttempt{
+		ID:         uuid.Must(uuid.NewV4()),
+		Identifier: identifier,
+		UserID:     userID,
+		IPAddress:  clientIP(r),
+		UserAgent:  r.UserAgent(),
+		Success:    success,
+		Reason:     reason,
+		CreatedAt:  time.Now(),
+	})
+	if err != nil {
+		log.Println("Failed to record login attempt:", err)
+	}
+}
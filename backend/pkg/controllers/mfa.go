@@ -0,0 +1,415 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/totp"
+	"backend/pkg/zwt"
+	"crypto/rand"
+	"encoding/base32"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	mfaPurposePending = "mfa_pending" // mot de passe validé, code TOTP attendu
+	mfaPurposeEnroll  = "mfa_enroll"  // 2FA obligatoire pour le rôle mais pas encore configurée
+
+	mfaTokenTTL       = 5 * time.Minute
+	mfaIssuer         = "Social Network"
+	mfaSkew           = 1 // fenêtres de 30 s tolérées de part et d'autre
+	recoveryCodeCount = 10
+)
+
+var ErrMFAInvalidCode = errors.New("invalid two-factor code")
+
+// issueMFAToken émet un token de courte durée qui ne donne accès qu'aux routes 2FA
+func (s *MyServer) issueMFAToken(userID uuid.UUID, username, purpose string) (string, error) {
+	return s.Tokens.Issue(zwt.Claims{
+		UserID:   userID,
+		Username: username,
+		Purpose:  purpose,
+		Exp:      time.Now().Add(mfaTokenTTL).Unix(),
+	})
+}
+
+// mfaSubject authentifie les routes d'enrôlement : token d'accès classique ou token "mfa_enroll"
+func (s *MyServer) mfaSubject(r *http.Request) (*zwt.Claims, error) {
+	token, err := bearerToken(r)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := s.verifyAccessToken(token, r.Method)
+	if err == nil {
+		return claims, nil
+	}
+	if errors.Is(err, ErrReadOnlySession) {
+		return nil, err
+	}
+
+	claims, err = s.Tokens.VerifyJWT(token)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Purpose != mfaPurposeEnroll {
+		return nil, fmt.Errorf("unexpected token purpose %q", claims.Purpose)
+	}
+	return claims, nil
+}
+
+// verifyTOTP valide un code TOTP pour une 2FA active, un code déjà utilisé est refusé (anti-rejeu)
+func verifyTOTP(mfa db.MFARepo, userID uuid.UUID, code string) error {
+	secret, lastStep, err := mfa.Secret(userID)
+	if errors.Is(err, db.ErrNotFound) {
+		return ErrMFAInvalidCode
+	}
+	if err != nil {
+		return err
+	}
+
+	step, ok := totp.Validate(secret, code, time.Now(), mfaSkew)
+	if !ok || step <= lastStep {
+		return ErrMFAInvalidCode
+	}
+
+	used, err := mfa.UseStep(userID, step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+// useRecoveryCode consomme un code de secours, chaque code n'est valable qu'une fois
+func useRecoveryCode(mfa db.MFARepo, userID uuid.UUID, code string) error {
+	used, err := mfa.UseRecoveryCode(userID, hashToken(normalizeRecoveryCode(code)), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrMFAInvalidCode
+	}
+	return nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// generateRecoveryCodes tire de nouveaux codes de secours et renvoie aussi leurs empreintes, seules stockées
+func generateRecoveryCodes() (codes, hashes []string, err error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	for i := 0; i < recoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(buf))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, hashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// MFAEnrollHandler génère un nouveau secret (non actif tant qu'il n'est pas confirmé) et l'URI otpauth://
+func (s *MyServer) MFAEnrollHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := s.mfaSubject(r)
+		if err != nil {
+			log.Println("MFA enrollment unauthorized:", err)
+			if errors.Is(err, ErrReadOnlySession) {
+				WriteError(w, r, CodeReadOnlySession, "Impersonation tokens are read-only")
+				return
+			}
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		mfa := s.Store.MFA()
+
+		enabled, _, err := mfa.Status(claims.UserID)
+		if err != nil {
+			log.Println("Failed to check MFA status:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		if enabled {
+			WriteError(w, r, CodeMFAAlreadyEnabled, "Two-factor authentication is already enabled")
+			return
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			log.Println("Failed to generate TOTP secret:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		if err := mfa.Enroll(claims.UserID, secret, time.Now()); err != nil {
+			log.Println("Failed to store TOTP secret:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{
+			"secret":      secret,
+			"otpauth_uri": totp.URI(mfaIssuer, claims.Username, secret),
+		})
+	}
+}
+
+// MFAConfirmHandler active la 2FA avec un premier code valide et retourne les codes de secours.
+// Lors d'un enrôlement imposé à la connexion, la session est ouverte dans la foulée
+func (s *MyServer) MFAConfirmHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		claims, err := s.mfaSubject(r)
+		if err != nil {
+			log.Println("MFA confirmation unauthorized:", err)
+			if errors.Is(err, ErrReadOnlySession) {
+				WriteError(w, r, CodeReadOnlySession, "Impersonation tokens are read-only")
+				return
+			}
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		var body struct {
+			Code   string `json:"code"`
+			Device string `json:"device"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+
+		mfa := s.Store.MFA()
+
+		secret, err := mfa.PendingSecret(claims.UserID)
+		if errors.Is(err, db.ErrNotFound) {
+			WriteError(w, r, CodeMFANotEnrolling, "No pending two-factor enrollment")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to fetch TOTP secret:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		step, ok := totp.Validate(secret, body.Code, time.Now(), mfaSkew)
+		if !ok {
+			WriteError(w, r, CodeInvalidMFACode, "Invalid two-factor code")
+			return
+		}
+
+		codes, hashes, err := generateRecoveryCodes()
+		if err != nil {
+			log.Println("Failed to generate recovery codes:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		if err := mfa.Enable(claims.UserID, step, hashes, time.Now()); err != nil {
+			log.Println("Failed to enable MFA:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		log.Printf("Two-factor authentication enabled for user %s", claims.UserID)
+
+		response := map[string]interface{}{
+			"message":        "Two-factor authentication enabled",
+			"recovery_codes": codes,
+		}
+
+		if claims.Purpose == mfaPurposeEnroll {
+			login, err := s.startSession(w, r, claims.UserID, claims.Username, body.Device)
+			if err != nil {
+				log.Println("Failed to start session:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+			response["token"] = login.Token
+			response["refresh_token"] = login.RefreshToken
+			response["expires_in"] = login.ExpiresIn
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// MFAVerifyHandler termine une connexion en deux étapes : token "mfa_pending" + code TOTP ou code de secours
+func (s *MyServer) MFAVerifyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			MFAToken     string `json:"mfa_token"`
+			Code         string `json:"code"`
+			RecoveryCode string `json:"recovery_code"`
+			Device       string `json:"device"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+
+		claims, err := s.Tokens.VerifyJWT(body.MFAToken)
+		if err != nil || claims.Purpose != mfaPurposePending {
+			log.Println("MFA token rejected:", err)
+			WriteError(w, r, CodeInvalidToken, "Invalid or expired MFA token")
+			return
+		}
+
+		// les codes 2FA partagent le compteur du compte pour empêcher leur force brute
+		accountKey := accountThrottleKey(claims.UserID, "")
+		if wait := s.loginRetryAfter(accountKey, addressThrottleKey(r)); wait > 0 {
+			s.recordLoginAttempt(claims.Username, claims.UserID, r, false, "throttled")
+			sendTooManyAttempts(w, r, wait)
+			return
+		}
+
+		if body.RecoveryCode != "" {
+			err = useRecoveryCode(s.Store.MFA(), claims.UserID, body.RecoveryCode)
+		} else {
+			err = verifyTOTP(s.Store.MFA(), claims.UserID, body.Code)
+		}
+		if err != nil {
+			log.Printf("Two-factor verification failed for user %s: %v", claims.UserID, err)
+			s.recordLoginFailure(accountKey, claims.UserID, r)
+			s.recordLoginAttempt(claims.Username, claims.UserID, r, false, "bad_mfa_code")
+			WriteError(w, r, CodeInvalidMFACode, "Invalid two-factor code")
+			return
+		}
+		s.resetLoginFailures(accountKey)
+		s.recordLoginAttempt(claims.Username, claims.UserID, r, true, "mfa")
+
+		response, err := s.startSession(w, r, claims.UserID, claims.Username, body.Device)
+		if err != nil {
+			log.Println("Failed to start session:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		log.Printf("User logged in with two-factor authentication, userID: %s", claims.UserID)
+		SendJSONResponse(w, response, http.StatusOK)
+	}
+}
+
+// MFADisableHandler désactive la 2FA après vérification d'un code, sauf si le rôle l'impose
+func (s *MyServer) MFADisableHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		var body struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+
+		mfa := s.Store.MFA()
+
+		_, required, err := mfa.Status(userID)
+		if err != nil {
+			log.Println("Failed to check MFA status:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		if required {
+			WriteError(w, r, CodeMFARequired, "Two-factor authentication is required for your role")
+			return
+		}
+
+		// même compteur que /mfa/verify : un token volé ne doit pas permettre de forcer le code
+		accountKey := accountThrottleKey(userID, "")
+		if wait := s.loginRetryAfter(accountKey, addressThrottleKey(r)); wait > 0 {
+			sendTooManyAttempts(w, r, wait)
+			return
+		}
+
+		if err := verifyTOTP(mfa, userID, body.Code); err != nil {
+			log.Printf("Two-factor verification failed for user %s: %v", userID, err)
+			s.recordLoginFailure(accountKey, userID, r)
+			WriteError(w, r, CodeInvalidMFACode, "Invalid two-factor code")
+			return
+		}
+		s.resetLoginFailures(accountKey)
+
+		if err := mfa.Disable(userID); err != nil {
+			log.Println("Failed to disable MFA:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		log.Printf("Two-factor authentication disabled for user %s", userID)
+		SendJSONResponse(w, LoginResponses{Message: "Two-factor authentication disabled"}, http.StatusOK)
+	}
+}
+
+// MFAPolicyHandler permet aux administrateurs de lire (GET) ou définir (PUT) les rôles soumis à la 2FA obligatoire.
+// L'accès est restreint par RequireRole(RoleAdmin) dans les routes
+func (s *MyServer) MFAPolicyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		mfa := s.Store.MFA()
+
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var body struct {
+				Roles []string `json:"roles"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+				return
+			}
+
+			roles := make([]string, 0, len(body.Roles))
+			for _, role := range body.Roles {
+				role = strings.TrimSpace(role)
+				if role != RoleAdmin && role != RoleModerator && role != RoleUser {
+					WriteError(w, r, CodeValidation, "Unknown role: "+role, fieldInvalid("roles", "unknown role "+role))
+					return
+				}
+				roles = append(roles, role)
+			}
+			if err := mfa.SetRequiredRoles(roles); err != nil {
+				log.Println("Failed to update MFA policy:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+			log.Printf("MFA policy updated by %s: %v", userID, body.Roles)
+			recordAdminAction(s.Store.Admin(), r, userID, "mfa_policy.update", uuid.Nil, map[string]interface{}{"roles": body.Roles})
+		default:
+			WriteError(w, r, CodeMethodNotAllowed, "Method not allowed")
+			return
+		}
+
+		roles, err := mfa.RequiredRoles()
+		if err != nil {
+			log.Println("Failed to query MFA policy:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string][]string{"roles": roles})
+	}
+}
@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/throttle"
+	"backend/pkg/totp"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// enableMFA active la 2FA de userID et renvoie son secret
+func enableMFA(t *testing.T, store *db.DBStore, userID uuid.UUID) string {
+	t.Helper()
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MFA().Enroll(userID, secret, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.MFA().Enable(userID, 0, nil, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+func currentCode(t *testing.T, secret string) string {
+	t.Helper()
+	code, err := totp.CodeAt(secret, totp.Step(time.Now()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+func TestVerifyTOTPReplay(t *testing.T) {
+	store := dbtest.SQLite(t)
+	userID := dbtest.User(t, store, "alice")
+	code := currentCode(t, enableMFA(t, store, userID))
+
+	if err := verifyTOTP(store.MFA(), userID, code); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := verifyTOTP(store.MFA(), userID, code); !errors.Is(err, ErrMFAInvalidCode) {
+		t.Errorf("replayed code: err = %v; want ErrMFAInvalidCode", err)
+	}
+}
+
+// TestMFADisableThrottle vérifie que /mfa/disable partage le compteur d'échecs de la connexion :
+// un token d'accès volé ne suffit pas à forcer le code
+func TestMFADisableThrottle(t *testing.T) {
+	store := dbtest.SQLite(t)
+	s := newTestServer(t, store, config.Default())
+	userID := dbtest.User(t, store, "alice")
+	secret := enableMFA(t, store, userID)
+	token := accessToken(t, s, userID, "alice", RoleUser)
+	accountKey := accountThrottleKey(userID, "")
+
+	disable := func(t *testing.T, code string) (int, ErrorCode) {
+		t.Helper()
+		rec := serve(s, token, http.MethodPost, "/mfa/disable", `{"code": "`+code+`"}`)
+		var apiErr APIError
+		json.NewDecoder(rec.Body).Decode(&apiErr)
+		return rec.Code, apiErr.Code
+	}
+
+	t.Run("backoff after the free attempts", func(t *testing.T) {
+		for i := range throttle.AccountPolicy.FreeAttempts + 1 {
+			if _, code := disable(t, "000000"); code != CodeInvalidMFACode {
+				t.Fatalf("attempt %d = %s; want %s", i+1, code, CodeInvalidMFACode)
+			}
+		}
+		if status, code := disable(t, currentCode(t, secret)); status != http.StatusTooManyRequests || code != CodeTooManyRequests {
+			t.Errorf("disable = %d %s; want 429 %s", status, code, CodeTooManyRequests)
+		}
+		if enabled, _, _ := store.MFA().Status(userID); !enabled {
+			t.Error("MFA disabled while throttled")
+		}
+	})
+
+	t.Run("success resets the account counter", func(t *testing.T) {
+		s.LoginLimiter.Reset(accountKey)
+		if _, code := disable(t, "000000"); code != CodeInvalidMFACode {
+			t.Fatalf("disable = %s; want %s", code, CodeInvalidMFACode)
+		}
+		if status, code := disable(t, currentCode(t, secret)); status != http.StatusOK {
+			t.Fatalf("disable = %d %s; want 200", status, code)
+		}
+		if enabled, _, _ := store.MFA().Status(userID); enabled {
+			t.Error("MFA still enabled")
+		}
+		if res, _ := s.LoginLimiter.Fail(accountKey, throttle.AccountPolicy, time.Now()); res.Failures != 1 {
+			t.Errorf("account counter = %d after a successful disable; want 1", res.Failures)
+		}
+	})
+}
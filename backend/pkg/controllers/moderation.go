@@ -0,0 +1,407 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	maxReportReasonLength  = 200
+	maxReportDetailsLength = 2000
+)
+
+// ReportHandler permet à un utilisateur de signaler un contenu :
+// {"target_type": "post", "target_id": "...", "reason": "spam", "details": "..."}
+func (s *MyServer) ReportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+		username, _ := r.Context().Value(usernameIDKey).(string)
+
+		var body struct {
+			TargetType string `json:"target_type"`
+			TargetID   string `json:"target_id"`
+			Reason     string `json:"reason"`
+			Details    string `json:"details"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+		body.Reason = strings.TrimSpace(body.Reason)
+		body.Details = strings.TrimSpace(body.Details)
+
+		if !db.IsReportTarget(body.TargetType) {
+			WriteError(w, r, CodeValidation, "Invalid target type", fieldInvalid("target_type", "unknown target type"))
+			return
+		}
+		if body.TargetID == "" {
+			WriteError(w, r, CodeValidation, "Target ID is required", fieldRequired("target_id"))
+			return
+		}
+		if body.Reason == "" || len(body.Reason) > maxReportReasonLength {
+			WriteError(w, r, CodeValidation, "A reason of at most 200 characters is required", fieldInvalid("reason", fmt.Sprintf("required, at most %d characters", maxReportReasonLength)))
+			return
+		}
+		if len(body.Details) > maxReportDetailsLength {
+			WriteError(w, r, CodeValidation, "Details are too long", FieldError{Field: "details", Code: "too_long", Message: "too long"})
+			return
+		}
+
+		moderation := s.Store.Moderation()
+
+		if _, err := moderation.TargetContent(body.TargetType, body.TargetID); errors.Is(err, db.ErrNotFound) {
+			WriteError(w, r, CodeContentNotFound, "Content not found")
+			return
+		} else if err != nil {
+			log.Println("Failed to fetch reported content:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		// un message privé ne peut être signalé que par l'un des participants
+		if body.TargetType == "private_message" {
+			participant, err := moderation.IsMessageParticipant(body.TargetID, username)
+			if err != nil {
+				log.Println("Failed to check message participants:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+			if !participant {
+				WriteError(w, r, CodeContentNotFound, "Content not found")
+				return
+			}
+		}
+
+		now := time.Now()
+		report := models.Report{
+			ID:         uuid.Must(uuid.NewV4()),
+			ReporterID: userID,
+			TargetType: body.TargetType,
+			TargetID:   body.TargetID,
+			Reason:     body.Reason,
+			Details:    body.Details,
+			Status:     models.ReportStatusOpen,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+		}
+		if err := moderation.CreateReport(report); errors.Is(err, db.ErrAlreadyExists) {
+			WriteError(w, r, CodeAlreadyReported, "You have already reported this content")
+			return
+		} else if err != nil {
+			log.Println("Failed to store report:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		log.Printf("Report %s created by %s on %s %s", report.ID, userID, report.TargetType, report.TargetID)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(report)
+	}
+}
+
+// ModerationReportsHandler liste la file des signalements :
+// ?status=open|in_review|resolved|dismissed|all (open par défaut), ?target_type=, ?assigned_to=me|<id>, ?limit=, ?offset=
+func (s *MyServer) ModerationReportsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+		query := r.URL.Query()
+
+		limit, _ := strconv.Atoi(query.Get("limit"))
+		if limit <= 0 || limit > 100 {
+			limit = 20
+		}
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		if offset < 0 {
+			offset = 0
+		}
+
+		var filter db.ReportFilter
+
+		switch status := query.Get("status"); status {
+		case "":
+			filter.Status = models.ReportStatusOpen
+		case "all":
+		case models.ReportStatusOpen, models.ReportStatusInReview, models.ReportStatusResolved, models.ReportStatusDismissed:
+			filter.Status = status
+		default:
+			WriteError(w, r, CodeValidation, "Invalid status filter", fieldInvalid("status", "unknown report status"))
+			return
+		}
+		if targetType := query.Get("target_type"); targetType != "" {
+			if !db.IsReportTarget(targetType) {
+				WriteError(w, r, CodeValidation, "Invalid target type", fieldInvalid("target_type", "unknown target type"))
+				return
+			}
+			filter.TargetType = targetType
+		}
+		switch assigned := query.Get("assigned_to"); assigned {
+		case "":
+		case "me":
+			filter.AssignedTo = &actor.UserID
+		case "none":
+			filter.AssignedTo = &uuid.Nil
+		default:
+			assigneeID, err := uuid.FromString(assigned)
+			if err != nil {
+				WriteError(w, r, CodeInvalidID, "Invalid assignee")
+				return
+			}
+			filter.AssignedTo = &assigneeID
+		}
+
+		reports, err := s.Store.Moderation().ListReports(filter, limit, offset)
+		if err != nil {
+			log.Println("Failed to list reports:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(reports)
+	}
+}
+
+// ModerationReportHandler renvoie le détail d'un signalement
+func (s *MyServer) ModerationReportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reportID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid report ID")
+			return
+		}
+
+		rep, err := s.Store.Moderation().Report(reportID)
+		if errors.Is(err, db.ErrNotFound) {
+			WriteError(w, r, CodeReportNotFound, "Report not found")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to fetch report:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rep)
+	}
+}
+
+// AssignReportHandler attribue un signalement à un modérateur (soi-même par défaut) et le passe en cours de traitement :
+// {"assignee_id": "..."}
+func (s *MyServer) AssignReportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+		reportID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid report ID")
+			return
+		}
+
+		var body struct {
+			AssigneeID *uuid.UUID `json:"assignee_id"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+				return
+			}
+		}
+		assignee := actor.UserID
+		if body.AssigneeID != nil {
+			assignee = *body.AssigneeID
+		}
+
+		if assignee != actor.UserID {
+			role, err := s.Store.Users().Role(assignee)
+			if err != nil || !(Actor{UserID: assignee, Role: normalizeRole(role)}).IsStaff() {
+				WriteError(w, r, CodeValidation, "Assignee must be a moderator", fieldInvalid("assignee_id", "must be a moderator"))
+				return
+			}
+		}
+
+		if err := s.Store.Moderation().AssignReport(reportID, assignee, time.Now()); errors.Is(err, db.ErrNotFound) {
+			WriteError(w, r, CodeReportNotFound, "Report not found or already closed")
+			return
+		} else if err != nil {
+			log.Println("Failed to assign report:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		recordAdminAction(s.Store.Admin(), r, actor.UserID, "report.assign", uuid.Nil, map[string]interface{}{
+			"report_id": reportID,
+			"assignee":  assignee,
+		})
+
+		rep, err := s.Store.Moderation().Report(reportID)
+		if err != nil {
+			log.Println("Failed to fetch report:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rep)
+	}
+}
+
+// ResolveReportHandler clôt un signalement :
+// {"action": "hide"|"no_action"|"dismiss", "resolution": "..."}.
+// "hide" masque le contenu et clôt tous les signalements ouverts qui le visent
+func (s *MyServer) ResolveReportHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+		reportID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid report ID")
+			return
+		}
+
+		var body struct {
+			Action     string `json:"action"`
+			Resolution string `json:"resolution"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+			return
+		}
+		body.Resolution = strings.TrimSpace(body.Resolution)
+
+		status := models.ReportStatusResolved
+		switch body.Action {
+		case "hide", "no_action":
+		case "dismiss":
+			status = models.ReportStatusDismissed
+		default:
+			WriteError(w, r, CodeValidation, "Action must be hide, no_action or dismiss", fieldInvalid("action", "must be hide, no_action or dismiss"))
+			return
+		}
+
+		moderation := s.Store.Moderation()
+
+		rep, err := moderation.Report(reportID)
+		if errors.Is(err, db.ErrNotFound) {
+			WriteError(w, r, CodeReportNotFound, "Report not found")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to fetch report:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		if rep.Status == models.ReportStatusResolved || rep.Status == models.ReportStatusDismissed {
+			WriteError(w, r, CodeReportClosed, "Report is already closed")
+			return
+		}
+
+		resolution := body.Resolution
+		if resolution == "" {
+			resolution = body.Action
+		}
+		err = moderation.ResolveReport(rep, status, resolution, body.Action == "hide", actor.UserID, time.Now())
+		if err != nil {
+			log.Println("Failed to resolve report:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		recordAdminAction(s.Store.Admin(), r, actor.UserID, "report."+body.Action, uuid.Nil, map[string]interface{}{
+			"report_id":   reportID,
+			"target_type": rep.TargetType,
+			"target_id":   rep.TargetID,
+			"resolution":  resolution,
+		})
+
+		rep, err = moderation.Report(reportID)
+		if err != nil {
+			log.Println("Failed to fetch report:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(rep)
+	}
+}
+
+// ModerateContentHandler masque (POST, {"reason": "..."}) ou rétablit (DELETE) un contenu
+// sans passer par un signalement : /moderation/content/{type}/{id}
+func (s *MyServer) ModerateContentHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, _ := actorFromRequest(r)
+		targetType, targetID := r.PathValue("type"), r.PathValue("id")
+		if !db.IsReportTarget(targetType) {
+			WriteError(w, r, CodeValidation, "Invalid target type", fieldInvalid("target_type", "unknown target type"))
+			return
+		}
+
+		moderation := s.Store.Moderation()
+
+		switch r.Method {
+		case http.MethodPost:
+			var body struct {
+				Reason string `json:"reason"`
+			}
+			if r.ContentLength != 0 {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					WriteError(w, r, CodeInvalidJSON, "Invalid request format")
+					return
+				}
+			}
+
+			if _, err := moderation.TargetContent(targetType, targetID); errors.Is(err, db.ErrNotFound) {
+				WriteError(w, r, CodeContentNotFound, "Content not found")
+				return
+			} else if err != nil {
+				log.Println("Failed to fetch content:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+
+			if err := moderation.Hide(targetType, targetID, strings.TrimSpace(body.Reason), actor.UserID); err != nil {
+				log.Println("Failed to hide content:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+			recordAdminAction(s.Store.Admin(), r, actor.UserID, "content.hide", uuid.Nil, map[string]interface{}{
+				"target_type": targetType,
+				"target_id":   targetID,
+				"reason":      body.Reason,
+			})
+			SendJSONResponse(w, LoginResponses{Message: "Content hidden"}, http.StatusOK)
+
+		case http.MethodDelete:
+			restored, err := moderation.Unhide(targetType, targetID)
+			if err != nil {
+				log.Println("Failed to unhide content:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+			if !restored {
+				WriteError(w, r, CodeContentNotFound, "Content is not hidden")
+				return
+			}
+			recordAdminAction(s.Store.Admin(), r, actor.UserID, "content.unhide", uuid.Nil, map[string]interface{}{
+				"target_type": targetType,
+				"target_id":   targetID,
+			})
+			SendJSONResponse(w, LoginResponses{Message: "Content restored"}, http.StatusOK)
+
+		default:
+			WriteError(w, r, CodeMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
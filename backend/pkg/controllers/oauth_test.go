@@ -0,0 +1,426 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db/dbtest"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestResolveOAuthUser(t *testing.T) {
+	store := dbtest.SQLite(t)
+	DB := store.DB()
+
+	verified := dbtest.User(t, store, "verified")
+	unverified := dbtest.User(t, store, "unverified")
+	if _, err := DB.Exec(`UPDATE users SET email_verified_at = NULL WHERE id = ?`, unverified); err != nil {
+		t.Fatal(err)
+	}
+
+	identities := func(userID uuid.UUID) int {
+		return dbtest.Scalar[int](t, DB, `SELECT COUNT(*) FROM user_identities WHERE user_id = ?`, userID)
+	}
+
+	t.Run("links a verified account", func(t *testing.T) {
+		id, _, err := ResolveOAuthUser(store.Identities(), "github", OAuthIdentity{Subject: "1", Email: "VERIFIED@example.com", EmailVerified: true})
+		if err != nil || id != verified {
+			t.Fatalf("ResolveOAuthUser = %s, %v; want %s", id, err, verified)
+		}
+		if n := identities(verified); n != 1 {
+			t.Errorf("%d identities linked, want 1", n)
+		}
+	})
+
+	t.Run("known identity", func(t *testing.T) {
+		id, _, err := ResolveOAuthUser(store.Identities(), "github", OAuthIdentity{Subject: "1", Email: "changed@example.com", EmailVerified: true})
+		if err != nil || id != verified {
+			t.Fatalf("ResolveOAuthUser = %s, %v; want %s", id, err, verified)
+		}
+	})
+
+	t.Run("refuses an unverified account", func(t *testing.T) {
+		_, _, err := ResolveOAuthUser(store.Identities(), "google", OAuthIdentity{Subject: "2", Email: "unverified@example.com", EmailVerified: true})
+		if !errors.Is(err, ErrOAuthAccountUnverified) {
+			t.Fatalf("err = %v, want ErrOAuthAccountUnverified", err)
+		}
+		if n := identities(unverified); n != 0 {
+			t.Errorf("%d identities linked, want 0", n)
+		}
+		if v := dbtest.Scalar[bool](t, DB, `SELECT email_verified_at IS NOT NULL FROM users WHERE id = ?`, unverified); v {
+			t.Error("unverified account was marked as verified")
+		}
+	})
+
+	t.Run("refuses an email the provider did not verify", func(t *testing.T) {
+		_, _, err := ResolveOAuthUser(store.Identities(), "google", OAuthIdentity{Subject: "3", Email: "verified@example.com"})
+		if !errors.Is(err, ErrOAuthEmailMissing) {
+			t.Fatalf("err = %v, want ErrOAuthEmailMissing", err)
+		}
+	})
+
+	t.Run("creates a new account", func(t *testing.T) {
+		id, username, err := ResolveOAuthUser(store.Identities(), "google", OAuthIdentity{Subject: "4", Email: "new@example.com", EmailVerified: true, Username: "new user"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id == verified || id == unverified || username != "newuser" {
+			t.Errorf("ResolveOAuthUser = %s, %q; want a new account named newuser", id, username)
+		}
+		if v := dbtest.Scalar[bool](t, DB, `SELECT email_verified_at IS NOT NULL AND password_hash = '' FROM users WHERE id = ?`, id); !v {
+			t.Error("new account should be verified and have no password")
+		}
+	})
+}
+
+// fakeGitHub imite les endpoints OAuth et API de GitHub utilisés par le serveur.
+// /authorize délivre un code lié au challenge PKCE reçu pour l'identité next ;
+// /token refuse un code_verifier qui ne correspond pas à ce challenge
+type fakeGitHub struct {
+	*httptest.Server
+	mu        sync.Mutex
+	next      fakeGitHubUser
+	codes     map[string]fakeGrant // code -> autorisation
+	tokens    map[string]fakeGitHubUser
+	exchanges int
+}
+
+type fakeGitHubUser struct {
+	ID       int64
+	Login    string
+	Email    string
+	Verified bool
+}
+
+type fakeGrant struct {
+	challenge string
+	user      fakeGitHubUser
+}
+
+func newFakeGitHub(t *testing.T) *fakeGitHub {
+	f := &fakeGitHub{codes: map[string]fakeGrant{}, tokens: map[string]fakeGitHubUser{}}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /authorize", func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+			http.Error(w, "PKCE required", http.StatusBadRequest)
+			return
+		}
+		f.mu.Lock()
+		code := uuid.Must(uuid.NewV4()).String()
+		f.codes[code] = fakeGrant{challenge: q.Get("code_challenge"), user: f.next}
+		f.mu.Unlock()
+
+		callback, _ := url.Parse(q.Get("redirect_uri"))
+		callback.RawQuery = url.Values{"code": {code}, "state": {q.Get("state")}}.Encode()
+		http.Redirect(w, r, callback.String(), http.StatusFound)
+	})
+
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.exchanges++
+
+		grant, ok := f.codes[r.FormValue("code")]
+		sum := sha256.Sum256([]byte(r.FormValue("code_verifier")))
+		if !ok || base64.RawURLEncoding.EncodeToString(sum[:]) != grant.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error": "invalid_grant"}`))
+			return
+		}
+		delete(f.codes, r.FormValue("code"))
+
+		token := uuid.Must(uuid.NewV4()).String()
+		f.tokens[token] = grant.user
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"access_token": token, "token_type": "bearer"})
+	})
+
+	user := func(r *http.Request) (fakeGitHubUser, bool) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		u, ok := f.tokens[strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")]
+		return u, ok
+	}
+	mux.HandleFunc("GET /user", func(w http.ResponseWriter, r *http.Request) {
+		u, ok := user(r)
+		if !ok {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]any{"id": u.ID, "login": u.Login, "name": "Test User"})
+	})
+	mux.HandleFunc("GET /user/emails", func(w http.ResponseWriter, r *http.Request) {
+		u, ok := user(r)
+		if !ok {
+			http.Error(w, "bad token", http.StatusUnauthorized)
+			return
+		}
+		json.NewEncoder(w).Encode([]map[string]any{
+			{"email": "other@example.com", "primary": false, "verified": true},
+			{"email": u.Email, "primary": true, "verified": u.Verified},
+		})
+	})
+
+	f.Server = httptest.NewServer(mux)
+	t.Cleanup(f.Close)
+	return f
+}
+
+// oauthConfig renvoie la configuration par défaut avec GitHub pointant vers fake
+func oauthConfig(fake *fakeGitHub) config.Config {
+	cfg := config.Default()
+	cfg.OAuth.Providers = map[string]config.OAuthProviderConfig{
+		"github": {
+			ClientID:     "client",
+			ClientSecret: "secret",
+			RedirectURL:  "http://backend.test/auth/github/callback",
+			AuthURL:      fake.URL + "/authorize",
+			TokenURL:     fake.URL + "/token",
+			UserInfoURL:  fake.URL + "/user",
+			EmailsURL:    fake.URL + "/user/emails",
+		},
+	}
+	return cfg
+}
+
+// oauthAttempt est un aller-retour vers le fournisseur arrêté avant le callback
+type oauthAttempt struct {
+	verifier *http.Cookie
+	callback url.Values // code et state renvoyés par le fournisseur
+}
+
+// startOAuth suit /auth/github/login puis l'autorisation du faux fournisseur pour l'identité user
+func startOAuth(t *testing.T, s *MyServer, fake *fakeGitHub, user fakeGitHubUser) oauthAttempt {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/auth/github/login", nil))
+	if rec.Code != http.StatusTemporaryRedirect {
+		t.Fatalf("login = %d %s", rec.Code, rec.Body)
+	}
+	var attempt oauthAttempt
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == "oauth_verifier" {
+			attempt.verifier = c
+		}
+	}
+	if attempt.verifier == nil {
+		t.Fatal("login did not set the oauth_verifier cookie")
+	}
+
+	fake.mu.Lock()
+	fake.next = user
+	fake.mu.Unlock()
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	resp, err := client.Get(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	location, err := resp.Location()
+	if err != nil {
+		t.Fatalf("authorize did not redirect: %d", resp.StatusCode)
+	}
+	attempt.callback = location.Query()
+	return attempt
+}
+
+// finish présente le callback au serveur avec le cookie verifier
+func (a oauthAttempt) finish(s *MyServer) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, "/auth/github/callback?"+a.callback.Encode(), nil)
+	req.AddCookie(a.verifier)
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestOAuthFlow(t *testing.T) {
+	store := dbtest.SQLite(t)
+	DB := store.DB()
+	fake := newFakeGitHub(t)
+	s := newTestServer(t, store, oauthConfig(fake))
+
+	verified := dbtest.User(t, store, "verified")
+	unverified := dbtest.User(t, store, "unverified")
+	if _, err := DB.Exec(`UPDATE users SET email_verified_at = NULL WHERE id = ?`, unverified); err != nil {
+		t.Fatal(err)
+	}
+	withMFA := dbtest.User(t, store, "withmfa")
+	if _, err := DB.Exec(`INSERT INTO user_mfa (user_id, secret, enabled_at) VALUES (?, 'secret', CURRENT_TIMESTAMP)`, withMFA); err != nil {
+		t.Fatal(err)
+	}
+
+	identity := func(subject int64) (userID uuid.UUID, ok bool) {
+		err := DB.QueryRow(`SELECT user_id FROM user_identities WHERE provider = 'github' AND subject = ?`, fmt.Sprint(subject)).Scan(&userID)
+		return userID, err == nil
+	}
+	sessions := func(userID uuid.UUID) int {
+		return dbtest.Scalar[int](t, DB, `SELECT COUNT(*) FROM sessions WHERE user_id = ?`, userID)
+	}
+	decode := func(t *testing.T, rec *httptest.ResponseRecorder, v any) {
+		t.Helper()
+		if err := json.NewDecoder(rec.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wantError := func(t *testing.T, rec *httptest.ResponseRecorder, code ErrorCode) {
+		t.Helper()
+		var apiErr APIError
+		decode(t, rec, &apiErr)
+		if rec.Code != code.Status() || apiErr.Code != code {
+			t.Fatalf("callback = %d %s; want %d %s", rec.Code, apiErr.Code, code.Status(), code)
+		}
+	}
+	exchanges := func() int {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+		return fake.exchanges
+	}
+
+	t.Run("state signature mismatch", func(t *testing.T) {
+		attempt := startOAuth(t, s, fake, fakeGitHubUser{ID: 1, Login: "nobody", Email: "nobody@example.com", Verified: true})
+		state := attempt.callback.Get("state")
+		payload, _, _ := strings.Cut(state, ".")
+		for name, forged := range map[string]string{
+			"tampered signature": payload + ".AAAA",
+			"unsigned":           payload,
+			"other key":          signWithKey(t, payload, []byte(strings.Repeat("k", 32))),
+		} {
+			t.Run(name, func(t *testing.T) {
+				before := exchanges()
+				forgedAttempt := attempt
+				forgedAttempt.callback = url.Values{"code": {attempt.callback.Get("code")}, "state": {forged}}
+				wantError(t, forgedAttempt.finish(s), CodeInvalidOAuthState)
+				if exchanges() != before {
+					t.Error("code was exchanged despite an invalid state")
+				}
+			})
+		}
+		if _, ok := identity(1); ok {
+			t.Error("identity linked after an invalid state")
+		}
+	})
+
+	t.Run("PKCE verifier mismatch", func(t *testing.T) {
+		user := fakeGitHubUser{ID: 2, Login: "nobody", Email: "nobody@example.com", Verified: true}
+
+		// cookie d'une autre tentative : l'empreinte ne correspond pas à celle signée dans le state
+		attempt := startOAuth(t, s, fake, user)
+		other := startOAuth(t, s, fake, user)
+		attempt.verifier = other.verifier
+		before := exchanges()
+		wantError(t, attempt.finish(s), CodeInvalidOAuthState)
+		if exchanges() != before {
+			t.Error("code was exchanged with a mismatched verifier cookie")
+		}
+
+		// code injecté : state et cookie cohérents, mais le code a été délivré pour le challenge d'une autre tentative
+		victim := startOAuth(t, s, fake, user)
+		injected := startOAuth(t, s, fake, user)
+		victim.callback.Set("code", injected.callback.Get("code"))
+		wantError(t, victim.finish(s), CodeUpstream)
+
+		if _, ok := identity(2); ok {
+			t.Error("identity linked after a PKCE mismatch")
+		}
+	})
+
+	t.Run("new identity", func(t *testing.T) {
+		rec := startOAuth(t, s, fake, fakeGitHubUser{ID: 3, Login: "octocat", Email: "octocat@example.com", Verified: true}).finish(s)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("callback = %d %s", rec.Code, rec.Body)
+		}
+		var resp LoginResponses
+		decode(t, rec, &resp)
+		if resp.Token == "" || resp.RefreshToken == "" {
+			t.Errorf("response = %+v; want a session", resp)
+		}
+		userID, ok := identity(3)
+		if !ok {
+			t.Fatal("identity not linked")
+		}
+		if username := dbtest.Scalar[string](t, DB, `SELECT username FROM users WHERE id = ?`, userID); username != "octocat" {
+			t.Errorf("username = %q; want octocat", username)
+		}
+		if sessions(userID) != 1 {
+			t.Errorf("%d sessions; want 1", sessions(userID))
+		}
+	})
+
+	t.Run("existing verified account", func(t *testing.T) {
+		rec := startOAuth(t, s, fake, fakeGitHubUser{ID: 4, Login: "gh-verified", Email: "Verified@Example.com", Verified: true}).finish(s)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("callback = %d %s", rec.Code, rec.Body)
+		}
+		if userID, ok := identity(4); !ok || userID != verified {
+			t.Errorf("identity linked to %s, %v; want %s", userID, ok, verified)
+		}
+		if sessions(verified) != 1 {
+			t.Errorf("%d sessions; want 1", sessions(verified))
+		}
+	})
+
+	t.Run("existing unverified account", func(t *testing.T) {
+		rec := startOAuth(t, s, fake, fakeGitHubUser{ID: 5, Login: "gh-unverified", Email: "unverified@example.com", Verified: true}).finish(s)
+		wantError(t, rec, CodeEmailTaken)
+		if _, ok := identity(5); ok {
+			t.Error("identity linked to an unverified account")
+		}
+		if sessions(unverified) != 0 {
+			t.Error("session opened for an unverified account")
+		}
+	})
+
+	t.Run("email not verified by the provider", func(t *testing.T) {
+		rec := startOAuth(t, s, fake, fakeGitHubUser{ID: 6, Login: "gh-verified", Email: "verified@example.com"}).finish(s)
+		wantError(t, rec, CodeEmailNotVerified)
+		if _, ok := identity(6); ok {
+			t.Error("identity linked with an email the provider did not verify")
+		}
+	})
+
+	t.Run("existing account with MFA", func(t *testing.T) {
+		rec := startOAuth(t, s, fake, fakeGitHubUser{ID: 7, Login: "gh-mfa", Email: "withmfa@example.com", Verified: true}).finish(s)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("callback = %d %s", rec.Code, rec.Body)
+		}
+		var resp LoginResponses
+		decode(t, rec, &resp)
+		if !resp.MFARequired || resp.MFAToken == "" || resp.Token != "" || resp.RefreshToken != "" {
+			t.Errorf("response = %+v; want an MFA challenge without session tokens", resp)
+		}
+		if userID, ok := identity(7); !ok || userID != withMFA {
+			t.Errorf("identity linked to %s, %v; want %s", userID, ok, withMFA)
+		}
+		if sessions(withMFA) != 0 {
+			t.Error("session opened before the second factor")
+		}
+	})
+}
+
+// signWithKey resigne le contenu d'un state avec une autre clé que celle du serveur
+func signWithKey(t *testing.T, payload string, key []byte) string {
+	t.Helper()
+	var state oauthState
+	raw, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil || json.Unmarshal(raw, &state) != nil {
+		t.Fatalf("invalid state payload %q", payload)
+	}
+	forged, err := (&MyServer{OAuthStateKey: key}).signOAuthState(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return forged
+}
@@ -0,0 +1,61 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// taille des pages des listes : limit vaut defaultPageSize par défaut et ne dépasse jamais maxPageSize
+const (
+	defaultPageSize = 10
+	maxPageSize     = 50
+)
+
+// nextCursorHeader porte le curseur de la page suivante, seul moyen de le connaître pour les listes
+// que les routes historiques renvoient sous forme de tableau JSON ; il est absent sur la dernière page
+const nextCursorHeader = "X-Next-Cursor"
+
+// cursorPage lit les paramètres cursor et limit d'une liste paginée par clé. Les anciens
+// paramètres page et offset sont ignorés. Un curseur invalide est refusé avec invalid_cursor
+func cursorPage(w http.ResponseWriter, r *http.Request) (db.Cursor, int, bool) {
+	query := r.URL.Query()
+	cursor, err := db.ParseCursor(query.Get("cursor"))
+	if err != nil {
+		WriteError(w, r, CodeInvalidCursor, "Invalid cursor")
+		return db.Cursor{}, 0, false
+	}
+	return cursor, pageSize(query.Get("limit"), defaultPageSize), true
+}
+
+// pageSize convertit un paramètre limit, fallback s'il est absent ou invalide, borné à maxPageSize
+func pageSize(value string, fallback int) int {
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit < 1 {
+		limit = fallback
+	}
+	return min(limit, maxPageSize)
+}
+
+// setNextCursor expose le curseur de la page suivante dans l'en-tête X-Next-Cursor
+func setNextCursor(w http.ResponseWriter, next db.Cursor) {
+	if !next.IsZero() {
+		w.Header().Set(nextCursorHeader, next.String())
+	}
+}
+
+// writeList envoie une page de liste. Sous /api/v1 la réponse est l'enveloppe {"items", "next_cursor"} ;
+// les routes historiques gardent le tableau JSON attendu par le frontend. X-Next-Cursor est posé dans les deux cas
+func writeList[T any](w http.ResponseWriter, r *http.Request, items []T, next db.Cursor) error {
+	setNextCursor(w, next)
+	w.Header().Set("Content-Type", "application/json")
+	if !strings.HasPrefix(r.URL.Path, APIV1Prefix+"/") {
+		return json.NewEncoder(w).Encode(items)
+	}
+	return json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":       items,
+		"next_cursor": next.String(),
+	})
+}
@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gofrs/uuid"
+)
+
+// rôles de la colonne users.role
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+var (
+	ErrForbidden        = errors.New("forbidden")
+	ErrResourceNotFound = errors.New("resource not found")
+)
+
+// policyError précise ErrForbidden ou ErrResourceNotFound avec le code renvoyé au client
+type policyError struct {
+	err  error
+	code ErrorCode
+}
+
+func (e policyError) Error() string { return e.err.Error() + " (" + string(e.code) + ")" }
+func (e policyError) Unwrap() error { return e.err }
+
+// normalizeRole ramène les valeurs inconnues ou vides au rôle "user"
+func normalizeRole(role string) string {
+	switch role {
+	case RoleAdmin, RoleModerator:
+		return role
+	default:
+		return RoleUser
+	}
+}
+
+// Actor est l'utilisateur authentifié qui effectue la requête
+type Actor struct {
+	UserID uuid.UUID
+	Role   string
+}
+
+// IsStaff indique un administrateur ou un modérateur
+func (a Actor) IsStaff() bool {
+	return a.Role == RoleAdmin || a.Role == RoleModerator
+}
+
+// actorFromRequest lit l'utilisateur et son rôle injectés par Authenticate
+func actorFromRequest(r *http.Request) (Actor, bool) {
+	userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+	if !ok {
+		return Actor{}, false
+	}
+	role, _ := r.Context().Value(roleKey).(string)
+	return Actor{UserID: userID, Role: normalizeRole(role)}, true
+}
+
+// RequireRole n'autorise que les rôles listés, à placer après Authenticate :
+//
+//	admin := router.Group("/admin", s.Authenticate, RequireRole(RoleAdmin))
+func RequireRole(roles ...string) Middleware {
+	return func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method == http.MethodOptions {
+				next(w, r)
+				return
+			}
+
+			actor, ok := actorFromRequest(r)
+			if !ok {
+				WriteError(w, r, CodeUnauthorized, "Unauthorized")
+				return
+			}
+			for _, role := range roles {
+				if actor.Role == role {
+					next(w, r)
+					return
+				}
+			}
+
+			log.Printf("Access denied to %s for user %s (role %s)", r.URL.Path, actor.UserID, actor.Role)
+			WriteError(w, r, CodeForbidden, "Forbidden")
+		}
+	}
+}
+
+// AuthorizePostAuthor : édition et suppression d'un post réservées à son auteur, la modération passe par le masquage
+func AuthorizePostAuthor(posts db.PostRepo, actor Actor, postID uuid.UUID) error {
+	ownerID, err := posts.Author(postID)
+	if errors.Is(err, db.ErrNotFound) {
+		return policyError{ErrResourceNotFound, CodePostNotFound}
+	}
+	if err != nil {
+		return err
+	}
+	if ownerID != actor.UserID {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizePostView : lire un post et ses commentaires ou y réagir demande de pouvoir le voir (voir PostRepo.Visible).
+// Un post inexistant ou masqué par la modération donne post_not_found, un post existant mais non visible post_not_visible
+func AuthorizePostView(posts db.PostRepo, actor Actor, postID uuid.UUID) error {
+	visible, err := posts.Visible(postID, actor.UserID)
+	if errors.Is(err, db.ErrNotFound) {
+		return policyError{ErrResourceNotFound, CodePostNotFound}
+	}
+	if err != nil {
+		return err
+	}
+	if !visible {
+		return policyError{ErrForbidden, CodePostNotVisible}
+	}
+	return nil
+}
+
+// AuthorizeCommentView : réagir à un commentaire demande de pouvoir voir son post
+func AuthorizeCommentView(store db.Store, actor Actor, commentID uuid.UUID) error {
+	postID, err := store.Comments().PostID(commentID)
+	if errors.Is(err, db.ErrNotFound) {
+		return policyError{ErrResourceNotFound, CodeCommentNotFound}
+	}
+	if err != nil {
+		return err
+	}
+	err = AuthorizePostView(store.Posts(), actor, postID)
+	if errors.Is(err, ErrResourceNotFound) {
+		return policyError{ErrResourceNotFound, CodeCommentNotFound}
+	}
+	return err
+}
+
+// AuthorizeGroupAdmin : inviter et approuver les demandes d'adhésion est réservé au créateur du groupe et aux administrateurs
+func AuthorizeGroupAdmin(groups db.GroupRepo, actor Actor, groupID uuid.UUID) error {
+	creatorID, err := groups.Creator(groupID)
+	if errors.Is(err, db.ErrNotFound) {
+		return policyError{ErrResourceNotFound, CodeGroupNotFound}
+	}
+	if err != nil {
+		return err
+	}
+	if creatorID == actor.UserID || actor.Role == RoleAdmin {
+		return nil
+	}
+	return ErrForbidden
+}
+
+// AuthorizeGroupMember : publier ou créer un événement demande d'être membre accepté du groupe
+func AuthorizeGroupMember(groups db.GroupRepo, actor Actor, groupID uuid.UUID) error {
+	creatorID, err := groups.Creator(groupID)
+	if errors.Is(err, db.ErrNotFound) {
+		return policyError{ErrResourceNotFound, CodeGroupNotFound}
+	}
+	if err != nil {
+		return err
+	}
+	if creatorID == actor.UserID || actor.Role == RoleAdmin {
+		return nil
+	}
+
+	status, err := groups.MemberStatus(groupID, actor.UserID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return err
+	}
+	if status != "accepted" {
+		return policyError{ErrForbidden, CodeNotGroupMember}
+	}
+	return nil
+}
+
+// writePolicyError traduit une erreur de la couche d'autorisation en réponse HTTP
+func writePolicyError(w http.ResponseWriter, r *http.Request, err error) {
+	var pe policyError
+	switch {
+	case errors.As(err, &pe):
+		WriteError(w, r, pe.code, policyMessages[pe.code])
+	case errors.Is(err, ErrResourceNotFound):
+		WriteError(w, r, CodeNotFound, "Not found")
+	case errors.Is(err, ErrForbidden):
+		WriteError(w, r, CodeForbidden, "Forbidden")
+	default:
+		log.Println("Authorization check failed:", err)
+		WriteError(w, r, CodeInternal, "Internal server error")
+	}
+}
+
+var policyMessages = map[ErrorCode]string{
+	CodePostNotFound:    "Post not found",
+	CodeCommentNotFound: "Comment not found",
+	CodeGroupNotFound:   "Group not found",
+	CodeNotGroupMember:  "You are not a member of this group",
+	CodePostNotVisible:  "You are not allowed to see this post",
+}
@@ -0,0 +1,57 @@
+package controllers
+
+import (
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"errors"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestAuthorizeOwnership(t *testing.T) {
+	store := dbtest.SQLite(t)
+	author := dbtest.User(t, store, "author")
+	other := dbtest.User(t, store, "other")
+	postID := dbtest.Post(t, store, author, "public")
+	groupID := uuid.Must(uuid.NewV4())
+	if err := store.Groups().Create(models.Group{ID: groupID, Name: "group", Description: "group", CreatorID: author}); err != nil {
+		t.Fatal(err)
+	}
+	missing := uuid.Must(uuid.NewV4())
+
+	for _, tc := range []struct {
+		name  string
+		check func(Actor, uuid.UUID) error
+		id    uuid.UUID
+	}{
+		{"post author", func(a Actor, id uuid.UUID) error { return AuthorizePostAuthor(store.Posts(), a, id) }, postID},
+		{"group admin", func(a Actor, id uuid.UUID) error { return AuthorizeGroupAdmin(store.Groups(), a, id) }, groupID},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for _, c := range []struct {
+				actor Actor
+				id    uuid.UUID
+				want  error
+			}{
+				{Actor{author, RoleUser}, tc.id, nil},
+				{Actor{other, RoleUser}, tc.id, ErrForbidden},
+				{Actor{other, RoleModerator}, tc.id, ErrForbidden},
+				{Actor{author, RoleUser}, missing, ErrResourceNotFound},
+			} {
+				if err := tc.check(c.actor, c.id); !errors.Is(err, c.want) {
+					t.Errorf("%s %s on %s: err = %v; want %v", c.actor.Role, c.actor.UserID, c.id, err, c.want)
+				}
+			}
+		})
+	}
+
+	// l'administrateur gère tous les groupes, mais ne modifie pas les posts des autres
+	admin := Actor{other, RoleAdmin}
+	if err := AuthorizeGroupAdmin(store.Groups(), admin, groupID); err != nil {
+		t.Errorf("admin on the group: %v", err)
+	}
+	if err := AuthorizePostAuthor(store.Posts(), admin, postID); !errors.Is(err, ErrForbidden) {
+		t.Errorf("admin on the post: err = %v; want %v", err, ErrForbidden)
+	}
+}
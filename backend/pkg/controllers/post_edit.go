@@ -0,0 +1,291 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"encoding/json"
+	"errors"
+	"log"
+	"mime"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// postChanges regroupe les champs envoyés pour modifier un post, nil quand le champ est absent
+type postChanges struct {
+	Title        *string      `json:"title"`
+	Content      *string      `json:"content"`
+	Visibility   *string      `json:"visibility"`
+	AllowedUsers *[]uuid.UUID `json:"allowed_users"`
+	RemoveImage  bool         `json:"remove_image"`
+}
+
+// UpdatePostHandler modifie un post (PUT remplace titre, contenu et visibilité, PATCH seulement les champs envoyés).
+// Le corps est en JSON ou en multipart comme à la création ; l'image est remplacée par le champ "image"
+// et retirée par remove_image. L'état précédent est conservé dans l'historique du post
+func (s *MyServer) UpdatePostHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := actorFromRequest(r)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		postID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid post ID")
+			return
+		}
+
+		if err := AuthorizePostAuthor(s.Store.Posts(), actor, postID); err != nil {
+			writePolicyError(w, r, err)
+			return
+		}
+
+		var changes postChanges
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		multipart := mediaType == "multipart/form-data"
+		if multipart {
+			if err := r.ParseMultipartForm(20 << 20); err != nil {
+				log.Println("Failed to parse multipart form:", err)
+				WriteError(w, r, CodeInvalidJSON, "Failed to parse form")
+				return
+			}
+			if changes, err = postChangesFromForm(r); err != nil {
+				WriteError(w, r, CodeInvalidID, "Invalid allowed user ID")
+				return
+			}
+		} else if !decodeJSON(w, r, &changes) {
+			return
+		}
+
+		if r.Method == http.MethodPut {
+			var missing []FieldError
+			if changes.Title == nil {
+				missing = append(missing, fieldRequired("title"))
+			}
+			if changes.Content == nil {
+				missing = append(missing, fieldRequired("content"))
+			}
+			if changes.Visibility == nil {
+				missing = append(missing, fieldRequired("visibility"))
+			}
+			if len(missing) > 0 {
+				WriteValidationError(w, r, missing...)
+				return
+			}
+			if changes.AllowedUsers == nil {
+				changes.AllowedUsers = &[]uuid.UUID{}
+			}
+		}
+
+		post, err := s.Store.Posts().Get(postID, actor.UserID)
+		if errors.Is(err, db.ErrNotFound) {
+			WriteError(w, r, CodePostNotFound, "Post not found")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to load post:", err)
+			WriteError(w, r, CodeInternal, "Failed to load post")
+			return
+		}
+
+		if changes.Title != nil {
+			post.Title = *changes.Title
+		}
+		if changes.Content != nil {
+			post.Content = *changes.Content
+		}
+		if changes.Visibility != nil {
+			post.Visibility = *changes.Visibility
+		}
+		if changes.AllowedUsers != nil {
+			post.AllowedUsers = *changes.AllowedUsers
+		}
+		// la liste des utilisateurs autorisés n'a de sens que pour un post "almost_private"
+		if post.Visibility != "almost_private" {
+			post.AllowedUsers = nil
+		}
+		if !checkValid(w, r, &post) {
+			return
+		}
+
+		if changes.RemoveImage {
+			post.ImagePath = ""
+		}
+		var uploaded string
+		if multipart {
+			if file, handler, err := r.FormFile("image"); err == nil {
+				file.Close()
+				if !IsValidImageExtension(handler.Filename) {
+					WriteError(w, r, CodeInvalidImage, "Invalid image file extension")
+					return
+				}
+				imagePath, err := s.UploadImages(w, r)
+				if err != nil {
+					log.Printf("Erreur lors du téléversement de l'image : %v\n", err)
+					WriteError(w, r, CodeInternal, "Failed to upload image")
+					return
+				}
+				post.ImagePath, uploaded = imagePath, imagePath
+			}
+		}
+
+		// l'ancienne image reste sur le disque : elle est référencée par la révision
+		if err := s.Store.Posts().Update(post, actor.UserID, time.Now()); err != nil {
+			log.Println("Failed to update post:", err)
+			if uploaded != "" {
+				s.discardUpload(uploaded)
+			}
+			WriteError(w, r, CodeInternal, "Failed to update post")
+			return
+		}
+
+		updated, err := s.Store.Posts().Get(postID, actor.UserID)
+		if err != nil {
+			log.Println("Failed to reload post:", err)
+			WriteError(w, r, CodeInternal, "Failed to load post")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(updated)
+	}
+}
+
+// postChangesFromForm lit les champs d'un formulaire multipart ; seuls les champs présents sont pris en compte
+func postChangesFromForm(r *http.Request) (postChanges, error) {
+	var changes postChanges
+	form := r.MultipartForm.Value
+	if v, ok := form["title"]; ok {
+		changes.Title = &v[0]
+	}
+	if v, ok := form["content"]; ok {
+		changes.Content = &v[0]
+	}
+	if v, ok := form["visibility"]; ok {
+		changes.Visibility = &v[0]
+	}
+	if v, ok := form["allowed_users"]; ok {
+		users, err := parseAllowedUsers(v[0])
+		if err != nil {
+			return changes, err
+		}
+		changes.AllowedUsers = &users
+	}
+	changes.RemoveImage = r.FormValue("remove_image") == "true"
+	return changes, nil
+}
+
+// parseAllowedUsers lit la liste d'identifiants séparés par des virgules envoyée par le formulaire
+func parseAllowedUsers(value string) ([]uuid.UUID, error) {
+	users := []uuid.UUID{}
+	if value == "" {
+		return users, nil
+	}
+	for _, userIDStr := range strings.Split(value, ",") {
+		userID, err := uuid.FromString(strings.TrimSpace(userIDStr))
+		if err != nil {
+			log.Println("Invalid allowed user ID:", userIDStr)
+			return nil, err
+		}
+		users = append(users, userID)
+	}
+	return users, nil
+}
+
+// DeletePostHandler supprime un post avec ses commentaires, ses likes et ses images devenues inutilisées
+func (s *MyServer) DeletePostHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := actorFromRequest(r)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		postID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid post ID")
+			return
+		}
+
+		if err := AuthorizePostAuthor(s.Store.Posts(), actor, postID); err != nil {
+			writePolicyError(w, r, err)
+			return
+		}
+
+		orphans, err := s.Store.Posts().Delete(postID)
+		if errors.Is(err, db.ErrNotFound) {
+			WriteError(w, r, CodePostNotFound, "Post not found")
+			return
+		}
+		if err != nil {
+			log.Println("Failed to delete post:", err)
+			WriteError(w, r, CodeInternal, "Failed to delete post")
+			return
+		}
+
+		// le post est supprimé : un fichier qui ne peut pas être retiré est seulement journalisé
+		for _, image := range orphans {
+			file, ok := s.Config.ImageFile(image)
+			if !ok {
+				continue
+			}
+			if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+				log.Printf("Failed to remove image %s: %v", file, err)
+			}
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// PostHistoryHandler renvoie les versions précédentes d'un post à ceux qui peuvent le voir
+func (s *MyServer) PostHistoryHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := actorFromRequest(r)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		postID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid post ID")
+			return
+		}
+
+		if err := AuthorizePostView(s.Store.Posts(), actor, postID); err != nil {
+			writePolicyError(w, r, err)
+			return
+		}
+
+		post, err := s.Store.Posts().Get(postID, actor.UserID)
+		if err != nil {
+			log.Println("Failed to load post:", err)
+			WriteError(w, r, CodeInternal, "Failed to load post")
+			return
+		}
+		revisions, err := s.Store.Posts().Revisions(postID)
+		if err != nil {
+			log.Println("Failed to retrieve post history:", err)
+			WriteError(w, r, CodeInternal, "Failed to retrieve post history")
+			return
+		}
+		// la liste des utilisateurs autorisés ne concerne que l'auteur
+		if post.UserID != actor.UserID {
+			for i := range revisions {
+				revisions[i].AllowedUsers = nil
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"post_id":   postID,
+			"edited_at": post.EditedAt,
+			"revisions": revisions,
+		})
+	}
+}
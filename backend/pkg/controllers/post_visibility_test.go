@@ -0,0 +1,71 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db/dbtest"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestPostVisibilityPolicy(t *testing.T) {
+	f := dbtest.Visibility(t)
+
+	f.EachCase(t, func(t *testing.T, viewer, postID uuid.UUID, visibility string, want bool) {
+		actor := Actor{UserID: viewer, Role: RoleUser}
+		if err := AuthorizePostView(f.Store.Posts(), actor, postID); (err == nil) != want {
+			t.Errorf("AuthorizePostView = %v; want visible=%v", err, want)
+		}
+		if err := AuthorizeCommentView(f.Store, actor, f.Comments[visibility]); (err == nil) != want {
+			t.Errorf("AuthorizeCommentView = %v; want visible=%v", err, want)
+		}
+	})
+}
+
+func TestPostVisibilityHTTP(t *testing.T) {
+	f := dbtest.Visibility(t)
+	s := newTestServer(t, f.Store, config.Default())
+	token := map[uuid.UUID]string{}
+	for id, name := range f.Names {
+		token[id] = accessToken(t, s, id, name, RoleUser)
+	}
+
+	f.EachCase(t, func(t *testing.T, viewer, postID uuid.UUID, visibility string, want bool) {
+		wantStatus := http.StatusForbidden
+		if want {
+			wantStatus = http.StatusOK
+		}
+		if viewer == uuid.Nil {
+			// les routes /api/v1 concernées exigent une session
+			wantStatus = http.StatusUnauthorized
+		}
+		id := postID.String()
+
+		for _, route := range []struct{ method, path string }{
+			{http.MethodGet, "/api/v1/posts/" + id},
+			{http.MethodGet, "/api/v1/posts/" + id + "/comments"},
+			{http.MethodPut, "/api/v1/posts/" + id + "/like"},
+			{http.MethodDelete, "/api/v1/posts/" + id + "/like"},
+			{http.MethodPut, "/api/v1/comments/" + f.Comments[visibility].String() + "/like"},
+		} {
+			if rec := serve(s, token[viewer], route.method, route.path, ""); rec.Code != wantStatus {
+				t.Errorf("%s %s = %d %s; want %d", route.method, route.path, rec.Code, rec.Body, wantStatus)
+			}
+		}
+
+		if viewer == uuid.Nil {
+			return
+		}
+		for _, path := range []string{"/api/v1/users/" + f.Author.String(), "/api/v1/feed?mode=discover&limit=50"} {
+			rec := serve(s, token[viewer], http.MethodGet, path, "")
+			if rec.Code != http.StatusOK {
+				t.Fatalf("GET %s = %d %s", path, rec.Code, rec.Body)
+			}
+			if got := strings.Contains(rec.Body.String(), id); got != want {
+				t.Errorf("GET %s contains post = %v; want %v", path, got, want)
+			}
+		}
+	})
+}
@@ -1,13 +1,11 @@
 package controllers
 
 import (
+	"backend/pkg/db"
 	"backend/pkg/models"
-	"database/sql"
 	"encoding/json"
-	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -16,16 +14,11 @@ import (
 
 func (s *MyServer) CreatePostHandlers() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		// Parse multipart form with a 20MB limit
 		err := r.ParseMultipartForm(20 << 20)
 		if err != nil {
 			log.Println("Failed to parse multipart form:", err)
-			http.Error(w, "Failed to parse form", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Failed to parse form")
 			return
 		}
 
@@ -38,27 +31,25 @@ func (s *MyServer) CreatePostHandlers() http.HandlerFunc {
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
 			log.Println("User ID not found in context")
-			http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User ID not found in context")
 			return
 		}
 		post.UserID = userID
 		post.CreatedAt = time.Now()
+		if post.Visibility == "" {
+			post.Visibility = "public"
+		}
 
 		if post.Visibility == "almost_private" {
-			allowedUsersStr := r.FormValue("allowed_users")
-			if allowedUsersStr != "" {
-				allowedUsers := strings.Split(allowedUsersStr, ",")
-				for _, userIDStr := range allowedUsers {
-					allowedUserID, err := uuid.FromString(userIDStr)
-					if err != nil {
-						log.Println("Invalid allowed user ID:", userIDStr)
-						http.Error(w, "Invalid allowed user ID", http.StatusBadRequest)
-						return
-					}
-					post.AllowedUsers = append(post.AllowedUsers, allowedUserID)
-				}
+			post.AllowedUsers, err = parseAllowedUsers(r.FormValue("allowed_users"))
+			if err != nil {
+				WriteError(w, r, CodeInvalidID, "Invalid allowed user ID")
+				return
 			}
 		}
+		if !checkValid(w, r, &post) {
+			return
+		}
 
 		file, handler, err := r.FormFile("image")
 		if err == nil {
@@ -66,15 +57,15 @@ func (s *MyServer) CreatePostHandlers() http.HandlerFunc {
 
 			if !IsValidImageExtension(handler.Filename) {
 				log.Println("Invalid image file extension")
-				http.Error(w, "Invalid image file extension", http.StatusBadRequest)
+				WriteError(w, r, CodeInvalidImage, "Invalid image file extension")
 				return
 			}
 
 			log.Println("Tentative de création d'une image...")
-			imagesPath, err := UploadImages(w, r, "./image_path/")
+			imagesPath, err := s.UploadImages(w, r)
 			if err != nil {
 				log.Printf("Erreur lors du téléversement de l'image : %v\n", err)
-				http.Error(w, "Failed to upload image", http.StatusInternalServerError)
+				WriteError(w, r, CodeInternal, "Failed to upload image")
 				return
 			}
 			log.Println("Image téléversée avec succès :", imagesPath)
@@ -82,10 +73,13 @@ func (s *MyServer) CreatePostHandlers() http.HandlerFunc {
 			post.ImagePath = imagesPath
 		}
 
-		postID, err := s.StorePost(post)
+		postID, err := s.Store.Posts().Create(post)
 		if err != nil {
 			log.Println("Failed to save post:", err)
-			http.Error(w, "Failed to save post", http.StatusInternalServerError)
+			if post.ImagePath != "" {
+				s.discardUpload(post.ImagePath)
+			}
+			WriteError(w, r, CodeInternal, "Failed to save post")
 			return
 		}
 		post.ID = postID
@@ -98,99 +92,116 @@ func (s *MyServer) CreatePostHandlers() http.HandlerFunc {
 
 func (s *MyServer) ListPostHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
 			log.Println("User ID not found in context")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
 		log.Println("User ID found:", userID)
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database for ListPost:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		cursor, limit, ok := cursorPage(w, r)
+		if !ok {
 			return
 		}
-		defer DB.Close()
 
-		tx, err := DB.Begin()
+		log.Printf("Fetching visible posts from database (limit: %d)\n", limit)
+
+		posts, next, err := s.Store.Posts().ListVisible(userID, cursor, limit)
 		if err != nil {
-			http.Error(w, "Failed to begin transaction", http.StatusInternalServerError)
+			log.Println("Failed to retrieve posts:", err)
+			WriteError(w, r, CodeInternal, "Failed to retrieve posts from the database")
 			return
 		}
 
-		commitErr := func() error {
-			if err != nil {
-				return tx.Rollback()
-			}
-			return tx.Commit()
+		if err := writeList(w, r, posts, next); err != nil {
+			log.Println("Failed to encode posts to JSON:", err)
+			WriteError(w, r, CodeInternal, "Failed to encode posts to JSON")
 		}
 
-		defer func() {
-			if commitErr() != nil {
-				log.Println("Transaction failed to commit/rollback")
-			}
-		}()
+	}
+}
 
-		log.Println("Database and table ready")
+/*--------------------------------------------------------------------------------------------------------------------------*/
 
-		page := 1
-		limit := 10
+// previewLength est la longueur maximale (en caractères) de la description d'aperçu
+const previewLength = 200
 
-		queryParams := r.URL.Query()
-		if p := queryParams.Get("page"); p != "" {
-			page, err = strconv.Atoi(p)
-			if err != nil || page < 1 {
-				page = 1
-			}
+// GetPostHandler renvoie un post (permalien) avec la première page de ses commentaires,
+// le curseur de la page suivante et les métadonnées d'aperçu du lien
+func (s *MyServer) GetPostHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		actor, ok := actorFromRequest(r)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
 		}
 
-		if l := queryParams.Get("limit"); l != "" {
-			limit, err = strconv.Atoi(l)
-			if err != nil || limit < 1 {
-				limit = 10
-			}
+		postID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid post ID")
+			return
 		}
 
-		offset := (page - 1) * limit
+		limit := pageSize(r.URL.Query().Get("comments_limit"), defaultPageSize)
 
-		log.Printf("Fetching visible posts from database (page: %d, limit: %d)\n", page, limit)
+		if err := AuthorizePostView(s.Store.Posts(), actor, postID); err != nil {
+			writePolicyError(w, r, err)
+			return
+		}
 
-		posts, err := GetVisiblePostsWithPagination(DB, userID, limit, offset)
+		post, err := s.Store.Posts().Get(postID, actor.UserID)
 		if err != nil {
-			log.Println("Failed to retrieve posts:", err)
-			http.Error(w, "Failed to retrieve posts from the database", http.StatusInternalServerError)
+			log.Println("Failed to load post:", err)
+			WriteError(w, r, CodeInternal, "Failed to load post")
 			return
 		}
+		// la liste des utilisateurs autorisés ne concerne que l'auteur
+		if post.UserID != actor.UserID {
+			post.AllowedUsers = nil
+		}
 
-		fmt.Println("posts", posts)
-
-		w.Header().Set("Content-Type", "application/json")
-		w.WriteHeader(http.StatusOK)
-		err = json.NewEncoder(w).Encode(posts)
+		comments, next, err := s.Store.Comments().List(postID, actor.UserID, db.Cursor{}, limit)
 		if err != nil {
-			log.Println("Failed to encode posts to JSON:", err)
-			http.Error(w, "Failed to encode posts to JSON", http.StatusInternalServerError)
+			log.Println("Failed to retrieve comments:", err)
+			WriteError(w, r, CodeInternal, "Failed to retrieve comments")
+			return
 		}
 
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"post":        post,
+			"comments":    comments,
+			"next_cursor": next.String(),
+			"open_graph":  s.postPreview(post),
+		})
 	}
 }
 
-func GetAvatar(db *sql.DB, userID uuid.UUID) (sql.NullString, error) {
-	var avatar sql.NullString
-	query := `SELECT avatar FROM users WHERE id = ?`
-	err := db.QueryRow(query, userID).Scan(&avatar)
-	if err != nil {
-		return avatar, fmt.Errorf("failed to query avatar: %w", err)
+// postPreview construit l'aperçu Open Graph du post ; l'image est celle du post, à défaut l'avatar de l'auteur
+func (s *MyServer) postPreview(post models.Post) models.PostPreview {
+	preview := models.PostPreview{
+		Title:         post.Title,
+		Description:   summarize(post.Content, previewLength),
+		Image:         post.ImagePath,
+		URL:           strings.TrimRight(s.AppBaseURL, "/") + "/posts/" + post.ID.String(),
+		Type:          "article",
+		Author:        post.Username,
+		PublishedTime: post.CreatedAt,
+		ModifiedTime:  post.EditedAt,
+	}
+	if preview.Image == "" && post.Avatar.Valid {
+		preview.Image = post.Avatar.String
 	}
-	return avatar, nil
+	return preview
 }
 
-/*--------------------------------------------------------------------------------------------------------------------------*/
+// summarize ramène le texte sur une ligne et le coupe à n caractères, en terminant par "…" s'il est tronqué
+func summarize(text string, n int) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	if len(runes) <= n {
+		return string(runes)
+	}
+	return strings.TrimSpace(string(runes[:n-1])) + "…"
+}
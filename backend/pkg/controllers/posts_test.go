@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"bytes"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+var errWriteFailed = errors.New("write failed")
+
+// failingStore fait échouer la création et la modification des posts
+type failingStore struct{ db.Store }
+
+func (s failingStore) Posts() db.PostRepo { return failingPosts{s.Store.Posts()} }
+
+type failingPosts struct{ db.PostRepo }
+
+func (failingPosts) Create(models.Post) (uuid.UUID, error) { return uuid.Nil, errWriteFailed }
+
+func (failingPosts) Update(models.Post, uuid.UUID, time.Time) error { return errWriteFailed }
+
+// TestPostUploadDiscardedOnFailure vérifie que l'image téléversée est retirée du disque quand
+// l'écriture du post échoue, sauf si un autre post utilise déjà ce fichier
+func TestPostUploadDiscardedOnFailure(t *testing.T) {
+	store := dbtest.SQLite(t)
+	s := newTestServer(t, failingStore{store}, config.Default())
+	authorID := dbtest.User(t, store, "author")
+	token := accessToken(t, s, authorID, "author", RoleUser)
+
+	// un post existant utilise déjà shared.png
+	shared, err := store.Posts().Create(models.Post{UserID: authorID, Title: "t", Content: "c", ImagePath: s.Config.ImageURL("shared.png")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(s.Config.Uploads.Dir, "shared.png"), []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	send := func(t *testing.T, method, path, filename string) {
+		t.Helper()
+		var body bytes.Buffer
+		form := multipart.NewWriter(&body)
+		form.WriteField("title", "title")
+		form.WriteField("content", "content")
+		form.WriteField("visibility", "public")
+		part, _ := form.CreateFormFile("image", filename)
+		part.Write([]byte("image"))
+		form.Close()
+
+		req := httptest.NewRequest(method, path, &body)
+		req.Header.Set("Content-Type", form.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+token)
+		rec := httptest.NewRecorder()
+		s.Router.ServeHTTP(rec, req)
+		if rec.Code != http.StatusInternalServerError {
+			t.Fatalf("%s %s = %d %s; want 500", method, path, rec.Code, rec.Body)
+		}
+	}
+	exists := func(filename string) bool {
+		_, err := os.Stat(filepath.Join(s.Config.Uploads.Dir, filename))
+		return err == nil
+	}
+
+	for _, tc := range []struct {
+		name, method, path, filename string
+		kept                         bool
+	}{
+		{"create", http.MethodPost, "/create_post", "created.png", false},
+		{"update", http.MethodPut, "/posts/" + shared.String(), "edited.png", false},
+		{"create with a shared file", http.MethodPost, "/create_post", "shared.png", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			send(t, tc.method, tc.path, tc.filename)
+			if got := exists(tc.filename); got != tc.kept {
+				t.Errorf("%s on disk = %v; want %v", tc.filename, got, tc.kept)
+			}
+		})
+	}
+}
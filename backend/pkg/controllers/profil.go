@@ -1,13 +1,12 @@
 package controllers
 
 import (
+	"backend/pkg/db"
 	"backend/pkg/models"
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
-	"strconv"
 
 	"github.com/gofrs/uuid"
 )
@@ -15,63 +14,22 @@ import (
 func (s *MyServer) MyProfil() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
-		if r.Method != http.MethodGet {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
 			log.Println("User ID not found in context")
-			http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "User ID not found in context")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database for MyProfil", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-
-		tx, err := DB.Begin()
-		if err != nil {
-			http.Error(w, "Failed to begin transaction", http.StatusInternalServerError)
+		cursor, limit, ok := cursorPage(w, r)
+		if !ok {
 			return
 		}
 
-		commitErr := func() error {
-			if err != nil {
-				log.Println("Rolling back transaction due to error:", err)
-				return tx.Rollback()
-			}
-			return tx.Commit()
-		}
-
-		defer func() {
-			if commitErr() != nil {
-				log.Println("Transaction failed to commit/rollback")
-			}
-		}()
-
-		queryParams := r.URL.Query()
-		limit, err := strconv.Atoi(queryParams.Get("limit"))
-		if err != nil || limit <= 0 {
-			limit = 10
-		}
-
-		offset, err := strconv.Atoi(queryParams.Get("offset"))
-		if err != nil || offset < 0 {
-			offset = 0
-		}
-
-		log.Println("Fetching user profile")
-		log.Println("User ID:", userID)
-		log.Println("Pagination limit:", limit, "offset:", offset)
-
-		profil, err := GetMyProfil(DB, userID, limit, offset)
+		profil, next, err := s.getMyProfil(userID, cursor, limit)
 		if err != nil {
-			http.Error(w, "Failed to get MyProfil", http.StatusInternalServerError)
+			log.Println("Failed to get profile:", err)
+			WriteError(w, r, CodeInternal, "Failed to get MyProfil")
 			return
 		}
 
@@ -88,43 +46,43 @@ func (s *MyServer) MyProfil() http.HandlerFunc {
 			Followers:      profil.Followers,
 			Following:      profil.Following,
 			Posts:          profil.Posts,
+			NextCursor:     next.String(),
 		}
 
 		if profil.Bio.Valid {
 			profilJSON.Bio = profil.Bio.String
 		}
 
+		setNextCursor(w, next)
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(profilJSON); err != nil {
 			log.Println("Failed to encode response to JSON:", err)
-			http.Error(w, "Failed to encode response as JSON", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode response as JSON")
 		}
 	}
 }
 
-func GetMyProfil(db *sql.DB, userID uuid.UUID, limit int, offset int) (models.UserProfil, error) {
-	var profil models.UserProfil
-
-	query := `SELECT id, username, first_name, last_name, bio, avatar FROM users WHERE id = ?`
-	err := db.QueryRow(query, userID).Scan(&profil.UserID, &profil.Username, &profil.FirstName, &profil.LastName, &profil.Bio, &profil.Avatar)
+func (s *MyServer) getMyProfil(userID uuid.UUID, after db.Cursor, limit int) (models.UserProfil, db.Cursor, error) {
+	profil, err := s.Store.Users().Profile(userID)
 	if err != nil {
-		return profil, fmt.Errorf("failed to query user profile: %w", err)
+		return profil, db.Cursor{}, fmt.Errorf("failed to query user profile: %w", err)
 	}
 
-	profil.Followers, err = GetFollowers(db, userID)
+	profil.Followers, err = s.Store.Follows().Followers(userID)
 	if err != nil {
-		return profil, fmt.Errorf("failed to get followers: %w", err)
+		return profil, db.Cursor{}, fmt.Errorf("failed to get followers: %w", err)
 	}
 
-	profil.Following, err = GetFollowing(db, userID)
+	profil.Following, err = s.Store.Follows().Following(userID)
 	if err != nil {
-		return profil, fmt.Errorf("failed to get following: %w", err)
+		return profil, db.Cursor{}, fmt.Errorf("failed to get following: %w", err)
 	}
 
-	profil.Posts, err = GetProfilPostsWithPagination(db, userID, limit, offset)
+	var next db.Cursor
+	profil.Posts, next, err = s.Store.Posts().ListByUser(userID, after, limit)
 	if err != nil {
-		return profil, fmt.Errorf("failed to get user posts: %w", err)
+		return profil, db.Cursor{}, fmt.Errorf("failed to get user posts: %w", err)
 	}
 
-	return profil, nil
+	return profil, next, nil
 }
@@ -1,8 +1,8 @@
 package controllers
 
 import (
+	"backend/pkg/db"
 	"backend/pkg/models"
-	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -10,7 +10,6 @@ import (
 	"log"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/gofrs/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -18,87 +17,49 @@ import (
 
 func (s MyServer) RegisterHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.NotFound(w, r)
-			return
-		}
-
 		var user models.User
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
 			log.Println("Failed to read request body:", err)
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid request payload")
 			return
 		}
 		log.Println("Request body received:", string(body))
 		if err := json.Unmarshal(body, &user); err != nil {
 			log.Println("Failed to decode request payload:", err)
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid request payload")
 			return
 		}
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
-		tx, err := DB.Begin()
-		if err != nil {
-			http.Error(w, "Failed to begin transaction", http.StatusInternalServerError)
-			return
-		}
-
-		defer func() {
-			if err != nil {
-				tx.Rollback()
-			} else {
-				tx.Commit()
-			}
-		}()
-
-		log.Println("Database and table ready for register")
-
-		if err := RegisterUser(w, r, DB, user); err != nil {
+		if err := RegisterUser(s.Store.Users(), user); err != nil {
 			log.Println("Failed to create user:", err)
-			http.Error(w, "Failed to create user", http.StatusInternalServerError)
+			switch {
+			case errors.Is(err, errInvalidEmail):
+				WriteError(w, r, CodeValidation, "Invalid email format", fieldInvalid("email", "invalid email format"))
+			case errors.Is(err, db.ErrEmailTaken):
+				WriteError(w, r, CodeEmailTaken, "Email already exists", fieldInvalid("email", "already used"))
+			case errors.Is(err, db.ErrUsernameTaken):
+				WriteError(w, r, CodeUsernameTaken, "Username already exists", fieldInvalid("username", "already used"))
+			default:
+				WriteError(w, r, CodeInternal, "Failed to create user")
+			}
 			return
 		}
 
-		var newUser models.User
-		err = DB.QueryRow(`
-			SELECT id, username, age, email, password_hash, first_name, last_name, role, gender, date_of_birth, avatar, bio, phone_number, address, is_private, created_at, updated_at 
-			FROM users 
-			WHERE email = ?`, user.Email).Scan(
-			&newUser.ID,
-			&newUser.Username,
-			&newUser.Age,
-			&newUser.Email,
-			&newUser.Password,
-			&newUser.FirstName,
-			&newUser.LastName,
-			&newUser.Role,
-			&newUser.Gender,
-			&newUser.DateOfBirth,
-			&newUser.Avatar,
-			&newUser.Bio,
-			&newUser.PhoneNumber,
-			&newUser.Address,
-			&newUser.IsPrivate,
-			&newUser.CreatedAt,
-			&newUser.UpdatedAt,
-		)
-
+		newUser, err := s.Store.Users().ByEmail(user.Email)
 		if err != nil {
 			log.Println("Failed to retrieve new user data:", err)
-			http.Error(w, "Failed to retrieve user data", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to retrieve user data")
 			return
 		}
 
 		log.Println("Newly created user data:", newUser)
 
+		// le compte reste restreint tant que l'email n'est pas confirmé
+		if err := s.sendVerificationEmail(newUser.ID, newUser.Email); err != nil {
+			log.Println("Failed to send verification email:", err)
+		}
+
 		response := models.Response{
 			Message: "User registered successfully",
 			User:    newUser,
@@ -108,19 +69,20 @@ func (s MyServer) RegisterHandler() http.HandlerFunc {
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(response); err != nil {
 			log.Println("Failed to encode response:", err)
-			http.Error(w, "Failed to send response", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to send response")
 			return
 		}
 	}
 }
 
-func RegisterUser(w http.ResponseWriter, r *http.Request, DB *sql.DB, user models.User) error {
+var errInvalidEmail = errors.New("invalid email format")
+
+func RegisterUser(users db.UserRepo, user models.User) error {
 	log.Println("Starting user registration process for email:", user.Email)
 
 	if !IsValidEmail(user.Email) {
 		log.Println("Invalid email format for:", user.Email)
-		http.Error(w, "Invalid email format", http.StatusUnauthorized)
-		return errors.New("invalid email format")
+		return errInvalidEmail
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(user.Password), bcrypt.DefaultCost)
@@ -131,10 +93,11 @@ func RegisterUser(w http.ResponseWriter, r *http.Request, DB *sql.DB, user model
 	user.Password = string(hashedPassword)
 
 	user.ID = uuid.Must(uuid.NewV4())
+	// le rôle n'est jamais choisi à l'inscription, seul un administrateur peut le changer
+	user.Role = RoleUser
 
-	if err = CreateUser(DB, user); err != nil {
+	if err = users.Create(user); err != nil {
 		log.Println("Failed to insert user:", err)
-		http.Error(w, "Failed to create user", http.StatusInternalServerError)
 		return err
 	}
 
@@ -142,60 +105,6 @@ func RegisterUser(w http.ResponseWriter, r *http.Request, DB *sql.DB, user model
 	return nil
 }
 
-func CreateUser(db *sql.DB, user models.User) error {
-	var countEmail, countUsername int
-	err := db.QueryRow("SELECT COUNT(*) FROM users WHERE email = ?", user.Email).Scan(&countEmail)
-	if err != nil {
-		log.Println("Failed to check email existence:", err)
-		return fmt.Errorf("failed to check email existence: %w", err)
-	}
-	if countEmail > 0 {
-		return fmt.Errorf("email already exists")
-	}
-
-	err = db.QueryRow("SELECT COUNT(*) FROM users WHERE username = ?", user.Username).Scan(&countUsername)
-	if err != nil {
-		log.Println("Failed to check username existence:", err)
-		return fmt.Errorf("failed to check username existence: %w", err)
-	}
-	if countUsername > 0 {
-		return fmt.Errorf("username already exists")
-	}
-
-	// execute insertion
-	query := `INSERT INTO users 
-        (id, username, age, email, password_hash, first_name, last_name, role, gender, date_of_birth, avatar, bio, phone_number, address, is_private, created_at, updated_at) 
-        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-
-	_, err = db.Exec(query,
-		user.ID,
-		user.Username,
-		user.Age,
-		user.Email,
-		user.Password,
-		sql.NullString{String: user.FirstName.String, Valid: user.FirstName.Valid},
-		sql.NullString{String: user.LastName.String, Valid: user.LastName.Valid},
-		user.Role,
-		user.Gender,
-		sql.NullTime{Time: user.DateOfBirth.Time, Valid: user.DateOfBirth.Valid},
-		sql.NullString{String: user.Avatar.String, Valid: user.Avatar.Valid},
-		sql.NullString{String: user.Bio.String, Valid: user.Bio.Valid},
-		sql.NullString{String: user.PhoneNumber.String, Valid: user.PhoneNumber.Valid},
-		sql.NullString{String: user.Address.String, Valid: user.Address.Valid},
-		user.IsPrivate,
-		time.Now(),
-		time.Now(),
-	)
-
-	if err != nil {
-		log.Println("Failed to execute insert query:", err)
-		return fmt.Errorf("failed to create user: %w", err)
-	}
-
-	log.Println("User successfully created with ID:", user.ID)
-	return nil
-}
-
 func IsValidEmail(email string) bool {
 	if email == "" {
 		log.Println("Email validation failed: empty email")
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// Middleware enveloppe un handler (CORS, logs, authentification...)
+type Middleware func(http.HandlerFunc) http.HandlerFunc
+
+// Router enregistre des routes "MÉTHODE /chemin" sur un ServeMux.
+// Un groupe hérite du préfixe et des middlewares de son parent et y ajoute les siens
+type Router struct {
+	mux        *http.ServeMux
+	prefix     string
+	base       []Middleware        // appliqués à toutes les routes, y compris aux réponses 405 et aux preflights
+	middleware []Middleware        // propres au groupe
+	methods    map[string][]string // chemin -> méthodes enregistrées, partagé par tous les groupes
+}
+
+// NewRouter crée le groupe racine. base s'applique à toutes les routes
+func NewRouter(mux *http.ServeMux, base ...Middleware) *Router {
+	return &Router{mux: mux, base: base, methods: map[string][]string{}}
+}
+
+// Group crée un sous-groupe sous prefix avec des middlewares supplémentaires
+func (rt *Router) Group(prefix string, middleware ...Middleware) *Router {
+	return &Router{
+		mux:        rt.mux,
+		prefix:     rt.prefix + prefix,
+		base:       rt.base,
+		middleware: append(slices.Clone(rt.middleware), middleware...),
+		methods:    rt.methods,
+	}
+}
+
+// Handle enregistre pattern ("GET /posts/{id}") avec les middlewares du groupe puis ceux de la route.
+// Les paramètres du chemin se lisent avec r.PathValue
+func (rt *Router) Handle(pattern string, handler http.HandlerFunc, middleware ...Middleware) {
+	method, path, ok := strings.Cut(pattern, " ")
+	if !ok || method == "" {
+		panic("router: pattern must be \"METHOD /path\", got " + pattern)
+	}
+	path = rt.prefix + path
+
+	chain := append(slices.Clone(rt.base), rt.middleware...)
+	chain = append(chain, middleware...)
+	rt.mux.HandleFunc(method+" "+path, Chain(handler, chain...))
+	rt.methods[path] = append(rt.methods[path], method)
+}
+
+// standardMethods sont les méthodes auxquelles HandleMethodNotAllowed répond
+var standardMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// HandleMethodNotAllowed enregistre, pour chaque chemin, les méthodes standard qui n'y sont pas routées.
+// Elles sont déclarées une à une plutôt que par un motif sans méthode, qui entrerait en conflit
+// avec des routes comme "GET /users/{id}" et "GET /users/search". À appeler après toutes les routes
+func (rt *Router) HandleMethodNotAllowed() {
+	for path := range rt.methods {
+		routed := rt.methods[path]
+		for _, method := range standardMethods {
+			// un motif GET répond aussi à HEAD
+			if slices.Contains(routed, method) || (method == http.MethodHead && slices.Contains(routed, http.MethodGet)) {
+				continue
+			}
+			rt.mux.HandleFunc(method+" "+path, Chain(rt.methodNotAllowed(path), rt.base...))
+		}
+	}
+}
+
+// methodNotAllowed répond aux méthodes non enregistrées pour path : 204 pour un preflight OPTIONS,
+// 405 sinon, avec dans les deux cas l'en-tête Allow
+func (rt *Router) methodNotAllowed(path string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", strings.Join(rt.allowed(path), ", "))
+		if r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		WriteError(w, r, CodeMethodNotAllowed, "Method not allowed")
+	}
+}
+
+// Routes renvoie les routes enregistrées sous prefix, triées, au format "MÉTHODE /chemin"
+func (rt *Router) Routes(prefix string) []string {
+	var routes []string
+	for path, methods := range rt.methods {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		for _, method := range methods {
+			routes = append(routes, method+" "+path)
+		}
+	}
+	slices.Sort(routes)
+	return routes
+}
+
+// pathOrQuery lit le paramètre de chemin name (routes /api/v1), à défaut le paramètre de requête query (routes historiques)
+func pathOrQuery(r *http.Request, name, query string) string {
+	if v := r.PathValue(name); v != "" {
+		return v
+	}
+	return r.URL.Query().Get(query)
+}
+
+func (rt *Router) allowed(path string) []string {
+	methods := slices.Clone(rt.methods[path])
+	if slices.Contains(methods, http.MethodGet) {
+		methods = append(methods, http.MethodHead)
+	}
+	methods = append(methods, http.MethodOptions)
+	slices.Sort(methods)
+	return slices.Compact(methods)
+}
@@ -9,105 +9,135 @@ import (
 )
 
 func (s *MyServer) routes() {
+	router := NewRouter(s.Router, RequestIDMiddleware, s.enableCORS, LogRequestMiddleware)
+	s.RouteTable = router
 
-	s.Router.HandleFunc("/protected", Chain(s.ProtectedHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
+	public := router.Group("")
+	auth := router.Group("", s.Authenticate)
+	verified := auth.Group("", s.RequireVerifiedEmail)
+	admin := auth.Group("/admin", RequireRole(RoleAdmin))
+	moderation := auth.Group("/moderation", RequireRole(RoleAdmin, RoleModerator))
 
-	/*-------------------------------------------------------------------------------*/
+	auth.Handle("GET /protected", s.ProtectedHandler())
 
-	s.Router.Handle("/verify_token", Chain(s.VerifyTokenHandler(), enableCORS, LogRequestMiddleware))
-	s.Router.Handle("/register", Chain(s.RegisterHandler(), enableCORS, LogRequestMiddleware))
-	s.Router.Handle("/login", Chain(s.LoginHandler(), enableCORS, LogRequestMiddleware))
-	s.Router.HandleFunc("/logout", Chain(s.LogoutHandler(), enableCORS, LogRequestMiddleware))
+	/*-------------------------------------------------------------------------------*/
 
-	s.Router.Handle("/create_post", Chain(s.CreatePostHandlers(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/recent_posts", Chain(s.ListPostHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/like_post", Chain(s.LikePost(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/unlike_post", Chain(s.UnlikePost(), enableCORS, LogRequestMiddleware, s.Authenticate))
+	public.Handle("POST /verify_token", s.VerifyTokenHandler())
+	public.Handle("POST /register", s.RegisterHandler())
+	public.Handle("POST /login", s.LoginHandler())
+	public.Handle("POST /logout", s.LogoutHandler())
+	public.Handle("POST /token/refresh", s.RefreshTokenHandler())
+	public.Handle("GET /auth/{provider}/login", s.OAuthLoginHandler())
+	public.Handle("GET /auth/{provider}/callback", s.OAuthCallbackHandler())
+	public.Handle("GET /verify_email", s.VerifyEmailHandler())
+	public.Handle("POST /verify_email", s.VerifyEmailHandler())
+	auth.Handle("POST /verify_email/resend", s.ResendVerificationHandler())
+	public.Handle("POST /password/forgot", s.ForgotPasswordHandler())
+	public.Handle("POST /password/reset", s.ResetPasswordHandler())
+	public.Handle("POST /mfa/enroll", s.MFAEnrollHandler())
+	public.Handle("POST /mfa/confirm", s.MFAConfirmHandler())
+	public.Handle("POST /mfa/verify", s.MFAVerifyHandler())
+	auth.Handle("POST /mfa/disable", s.MFADisableHandler())
+
+	admin.Handle("GET /mfa_policy", s.MFAPolicyHandler())
+	admin.Handle("PUT /mfa_policy", s.MFAPolicyHandler())
+	admin.Handle("GET /users", s.AdminListUsersHandler())
+	admin.Handle("POST /users/{id}/suspend", s.AdminSuspendHandler(suspensionKindSuspension))
+	admin.Handle("POST /users/{id}/ban", s.AdminSuspendHandler(suspensionKindBan))
+	admin.Handle("POST /users/{id}/unsuspend", s.AdminUnsuspendHandler())
+	admin.Handle("POST /users/{id}/force_password_reset", s.AdminForcePasswordResetHandler())
+	admin.Handle("PUT /users/{id}/role", s.AdminChangeRoleHandler())
+	admin.Handle("POST /users/{id}/role", s.AdminChangeRoleHandler())
+	admin.Handle("POST /users/{id}/impersonate", s.AdminImpersonateHandler())
+	admin.Handle("GET /audit_log", s.AdminAuditLogHandler())
+
+	auth.Handle("POST /report", s.ReportHandler())
+	moderation.Handle("GET /reports", s.ModerationReportsHandler())
+	moderation.Handle("GET /reports/{id}", s.ModerationReportHandler())
+	moderation.Handle("POST /reports/{id}/assign", s.AssignReportHandler())
+	moderation.Handle("POST /reports/{id}/resolve", s.ResolveReportHandler())
+	moderation.Handle("POST /content/{type}/{id}", s.ModerateContentHandler())
+	moderation.Handle("DELETE /content/{type}/{id}", s.ModerateContentHandler())
+
+	auth.Handle("GET /sessions", s.SessionsHandler())
+	auth.Handle("DELETE /sessions", s.SessionsHandler())
+	auth.Handle("DELETE /sessions/{id}", s.RevokeSessionHandler())
+
+	verified.Handle("POST /create_post", s.CreatePostHandlers())
+	auth.Handle("GET /recent_posts", s.ListPostHandler())
+	auth.Handle("GET /feed", s.FeedHandler())
+	auth.Handle("POST /like_post", s.LikePost())
+	auth.Handle("POST /unlike_post", s.UnlikePost())
+	auth.Handle("GET /posts/{id}", s.GetPostHandler())
+	verified.Handle("PUT /posts/{id}", s.UpdatePostHandler())
+	verified.Handle("PATCH /posts/{id}", s.UpdatePostHandler())
+	verified.Handle("DELETE /posts/{id}", s.DeletePostHandler())
+	auth.Handle("GET /posts/{id}/history", s.PostHistoryHandler())
 
 	/*-------------------------------------------------------------------------------*/
 
-	s.Router.Handle("/create_comment", Chain(s.CreateCommentHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/list_comment", Chain(s.ListCommentHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/like_comment", Chain(s.LikeComment(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/unlike_comment", Chain(s.UnlikeComment(), enableCORS, LogRequestMiddleware, s.Authenticate))
+	verified.Handle("POST /create_comment", s.CreateCommentHandler())
+	auth.Handle("GET /list_comment", s.ListCommentHandler())
+	auth.Handle("POST /like_comment", s.LikeComment())
+	auth.Handle("POST /unlike_comment", s.UnlikeComment())
 
 	/*-------------------------------------------------------------------------------*/
-	s.Router.Handle("/list_users", Chain(s.ListUsers(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/list_amis", Chain(s.ListAmis(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/viewprofil/{userId}", Chain(s.GetUserProfilHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/myprofil", Chain(s.MyProfil(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/update_profile", Chain(s.UpdateProfileHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
+	auth.Handle("GET /list_users", s.ListUsers())
+	auth.Handle("GET /list_amis", s.ListAmis())
+	auth.Handle("GET /viewprofil/{id}", s.GetUserProfilHandler())
+	auth.Handle("GET /myprofil", s.MyProfil())
+	auth.Handle("POST /update_profile", s.UpdateProfileHandler())
 
 	/*-------------------------------------------------------------------------------*/
 
-	s.Router.HandleFunc("/notifications", Chain(s.GetNotificationsHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.HandleFunc("/mark_as_read", Chain(
-		s.MarkNotificationAsRead(),
-		enableCORS,
-		LogRequestMiddleware,
-		s.Authenticate,
-	))
-	s.Router.HandleFunc("/follow_request", Chain(s.FollowUserHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.HandleFunc("/accept_follower", Chain(s.AcceptFollowerHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.HandleFunc("/decline_follower", Chain(s.DeclineFollowerHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.HandleFunc("/unfollow", Chain(s.UnfollowUserHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-
-	s.Router.HandleFunc("/search_users", Chain(s.SearchUsersHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.HandleFunc("/get_follow_requests", Chain(s.GetFollowRequestsHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
+	auth.Handle("GET /notifications", s.GetNotificationsHandler())
+	auth.Handle("POST /mark_as_read", s.MarkNotificationAsRead())
+	auth.Handle("POST /follow_request", s.FollowUserHandler())
+	auth.Handle("POST /accept_follower", s.AcceptFollowerHandler())
+	auth.Handle("POST /decline_follower", s.DeclineFollowerHandler())
+	auth.Handle("DELETE /unfollow", s.UnfollowUserHandler())
+
+	auth.Handle("GET /search_users", s.SearchUsersHandler())
+	auth.Handle("GET /get_follow_requests", s.GetFollowRequestsHandler())
 
 	/*-------------------------------------------------------------------------------*/
 
-	s.Router.HandleFunc("/online", Chain(s.OnlineUsersHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.HandleFunc("/message", Chain(func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			s.GetMessagesHandler()(w, r)
-		case http.MethodPost:
-			s.PostMessageHandler()(w, r)
-		default:
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-		}
-	}, enableCORS, LogRequestMiddleware, s.Authenticate))
-
-	s.Router.HandleFunc("/ws", Chain(
-		s.WebSocketChat.HanderUsersConnection,
-		enableCORS,
-		LogRequestMiddleware,
-	))
-
-	s.Router.HandleFunc("/messagegroup", Chain(func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			s.GetMessagesGroupsHandler()(w, r)
-		case http.MethodPost:
-			s.PostMessageGroupHandler()(w, r)
-		default:
-			http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
-		}
-	}, enableCORS, LogRequestMiddleware, s.Authenticate))
+	auth.Handle("GET /online", s.OnlineUsersHandler())
+	auth.Handle("GET /message", s.GetMessagesHandler())
+	auth.Handle("POST /message", s.PostMessageHandler())
+
+	// le token du websocket est vérifié par le hub, dans la query string
+	public.Handle("GET /ws", s.WebSocketChat.HanderUsersConnection)
+
+	auth.Handle("GET /messagegroup", s.GetMessagesGroupsHandler())
+	auth.Handle("POST /messagegroup", s.PostMessageGroupHandler())
 
 	/*-------------------------------------------------------------------------------*/
-	s.Router.Handle("/users", Chain(s.SearchUsersHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/group/{id}", Chain(s.GetGroupDataHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/list_group", Chain(s.ListGroupsHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/create_group", Chain(s.CreateGroupHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/groups/{groupId}/invit_group", Chain(s.InviteToGroupHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/create_post_group", Chain(s.CreatePostGroupHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/list_post_group", Chain(s.ListPostGroupHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/join_group_request", Chain(s.RequestToJoinGroupHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/create_comment_group", Chain(s.CreateCommentPostsGroup(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/list_comments_group", Chain(s.ListCommentsByPostGroupHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
+	auth.Handle("GET /users", s.SearchUsersHandler())
+	auth.Handle("GET /group/{id}", s.GetGroupDataHandler())
+	auth.Handle("GET /list_group", s.ListGroupsHandler())
+	verified.Handle("POST /create_group", s.CreateGroupHandler())
+	auth.Handle("POST /groups/{id}/invit_group", s.InviteToGroupHandler())
+	verified.Handle("POST /create_post_group", s.CreatePostGroupHandler())
+	auth.Handle("GET /list_post_group", s.ListPostGroupHandler())
+	auth.Handle("POST /join_group_request", s.RequestToJoinGroupHandler())
+	verified.Handle("POST /create_comment_group", s.CreateCommentPostsGroup())
+	auth.Handle("GET /list_comments_group", s.ListCommentsByPostGroupHandler())
 
 	/*-------------------------------------------------------------------------------*/
 
-	s.Router.Handle("/group/{id}/create_event", Chain(s.CreateEventHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/list_event", Chain(s.ListEvent(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/respond_to_event", Chain(s.RespondToEventHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/invite_to_event", Chain(s.InviteToEventHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/accept_group_invite", Chain(s.AcceptGroupInviteHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
-	s.Router.Handle("/get_user_votes", Chain(s.GetUserVotesHandler(), enableCORS, LogRequestMiddleware, s.Authenticate))
+	verified.Handle("POST /group/{id}/create_event", s.CreateEventHandler())
+	auth.Handle("GET /list_event", s.ListEvent())
+	auth.Handle("POST /respond_to_event", s.RespondToEventHandler())
+	auth.Handle("POST /invite_to_event", s.InviteToEventHandler())
+	auth.Handle("POST /accept_group_invite", s.AcceptGroupInviteHandler())
+	auth.Handle("GET /get_user_votes", s.GetUserVotesHandler())
 
 	/*-------------------------------------------------------------------------------*/
+
+	s.apiV1Routes(router)
+
+	router.HandleMethodNotAllowed()
 }
 
 // ProtectedHandler affiche un message spécifique à l'utilisateur
@@ -118,11 +148,16 @@ func (s *MyServer) ProtectedHandler() http.HandlerFunc {
 	}
 }
 
-func enableCORS(next http.HandlerFunc) http.HandlerFunc {
+// enableCORS autorise les origines de la configuration : l'origine de la requête est renvoyée si elle est dans la liste
+func (s *MyServer) enableCORS(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3000")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if origin := s.allowedOrigin(r.Header.Get("Origin")); origin != "" {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		w.Header().Add("Vary", "Origin")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID")
+		w.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Retry-After, X-Next-Cursor")
 		w.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		if r.Method == http.MethodOptions {
@@ -134,3 +169,13 @@ func enableCORS(next http.HandlerFunc) http.HandlerFunc {
 		next(w, r)
 	}
 }
+
+func (s *MyServer) allowedOrigin(origin string) string {
+	for _, allowed := range s.Config.Server.AllowedOrigins {
+		// avec les cookies, "*" n'est pas accepté par les navigateurs : on renvoie l'origine elle-même
+		if origin != "" && (allowed == "*" || allowed == origin) {
+			return origin
+		}
+	}
+	return ""
+}
@@ -1,71 +1,72 @@
 package controllers
 
 import (
+	"backend/pkg/config"
 	"backend/pkg/db"
+	"backend/pkg/mail"
+	"backend/pkg/throttle"
 	"backend/pkg/wsk"
+	"backend/pkg/zwt"
 	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"time"
-
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/github"
-	"golang.org/x/oauth2/google"
 )
 
 const (
 	ColorGreen = "\033[32m"
 	ColorBlue  = "\033[34m"
 	ColorReset = "\033[0m"
-	port       = ":8079"
 )
 
 // Structure pour le serveur
 type MyServer struct {
-	Store             db.Store           // instance de la base de données
-	Router            *http.ServeMux     // routeur HTTP
-	Server            *http.Server       // serveur HTTP
-	WebSocketChat     *wsk.WebsocketChat // Gestionnaire de chat WebSocket
-	GoogleOAuthConfig *oauth2.Config     // Configuration OAuth pour Google
-	GitHubOAuthConfig *oauth2.Config     // Configuration OAuth pour GitHub
+	Config         config.Config             // configuration chargée au démarrage
+	Store          db.Store                  // instance de la base de données
+	Router         *http.ServeMux            // routeur HTTP
+	RouteTable     *Router                   // routes enregistrées, comparées à la spécification OpenAPI
+	Server         *http.Server              // serveur HTTP
+	WebSocketChat  *wsk.WebsocketChat        // Gestionnaire de chat WebSocket
+	Tokens         *zwt.Service              // Service de signature et vérification des tokens
+	OAuthProviders map[string]*OAuthProvider // Fournisseurs OAuth activés (google, github)
+	OAuthStateKey  []byte                    // Clé de signature du paramètre "state" OAuth
+	Mailer         mail.Mailer               // Envoi des emails de vérification et de réinitialisation
+	AppBaseURL     string                    // URL du frontend utilisée dans les liens envoyés par email
+	LoginLimiter   throttle.Limiter          // Limitation des tentatives de connexion par compte et par adresse
 }
 
-func NewServer(store db.Store, wsChat *wsk.WebsocketChat) *MyServer {
+func NewServer(cfg config.Config, store db.Store, wsChat *wsk.WebsocketChat, tokens *zwt.Service, mailer mail.Mailer, limiter throttle.Limiter) *MyServer {
 
 	router := http.NewServeMux() // initialisation du routeur HTTP
 
 	// création de la nouvelle instance de MyServer avec les configurations nécessaires
 	server := &MyServer{
-		Store:         store,
-		Router:        router,
-		WebSocketChat: wsChat,
-		GoogleOAuthConfig: &oauth2.Config{
-			ClientID:     "your-google-client-id",
-			ClientSecret: "your-google-client-secret",
-			RedirectURL:  "http://localhost:8079/auth/google/callback",
-			Scopes:       []string{"https://www.googleapis.com/auth/userinfo.email"},
-			Endpoint:     google.Endpoint,
-		},
-		GitHubOAuthConfig: &oauth2.Config{
-			ClientID:     "your-github-client-id",
-			ClientSecret: "your-github-client-secret",
-			RedirectURL:  "http://localhost:8079/auth/github/callback",
-			Scopes:       []string{"user:email"},
-			Endpoint:     github.Endpoint,
-		},
+		Config:         cfg,
+		Store:          store,
+		Router:         router,
+		WebSocketChat:  wsChat,
+		Tokens:         tokens,
+		OAuthProviders: newOAuthProviders(cfg.OAuth),
+		OAuthStateKey:  oauthStateKey(cfg.OAuth.StateSecret),
+		Mailer:         mailer,
+		LoginLimiter:   limiter,
+		AppBaseURL:     cfg.Server.AppBaseURL,
 	}
 
+	// le hub WebSocket refuse les handshakes dont la session a été révoquée
+	wsChat.SessionActive = server.sessionActive
+
 	server.routes() // initialisation des routes du serveur
 
-	router.Handle("/image_path/", http.StripPrefix("/image_path/", http.FileServer(http.Dir("./image_path"))))
+	router.Handle(cfg.Uploads.URLPath, http.StripPrefix(cfg.Uploads.URLPath, http.FileServer(http.Dir(cfg.Uploads.Dir))))
 
-	fmt.Println(ColorBlue, "(http://localhost:8079) - Server started on port", port, ColorReset)
+	fmt.Println(ColorBlue, "("+cfg.Server.PublicURL+") - Server started on", cfg.Server.Addr, ColorReset)
 	fmt.Println(ColorGreen, "[SERVER_INFO] : To stop the server : Ctrl + c", ColorReset)
 
 	// Configuration du serveur HTTP
 	srv := &http.Server{
-		Addr:              "localhost:8079",
+		Addr:              cfg.Server.Addr,
 		Handler:           router,           // routeur pour gérer les requêtes
 		ReadHeaderTimeout: 15 * time.Second, // délai d'attente pour lire l'en-tête
 		ReadTimeout:       15 * time.Second, // délai d'attente pour lire le corps de la requête
@@ -86,13 +87,13 @@ func (s *MyServer) Shutdown(ctx context.Context) error {
 // middleware pour logger les requêtes HTTP et appel du prochain handler
 func LogRequestMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		log.Printf("[%v], %v", r.Method, r.RequestURI)
+		log.Printf("[%v], %v (request %s)", r.Method, r.RequestURI, RequestID(r))
 		next(w, r)
 	}
 }
 
 // fonction Chain pour empiler les middlewares
-func Chain(final http.HandlerFunc, middlewares ...func(http.HandlerFunc) http.HandlerFunc) http.HandlerFunc {
+func Chain(final http.HandlerFunc, middlewares ...Middleware) http.HandlerFunc {
 	for i := len(middlewares) - 1; i >= 0; i-- {
 		final = middlewares[i](final)
 	}
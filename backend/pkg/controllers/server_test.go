@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db"
+	"backend/pkg/mail"
+	"backend/pkg/throttle"
+	"backend/pkg/wsk"
+	"backend/pkg/zwt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+// newTestServer monte le serveur complet sur store avec une clé de signature éphémère,
+// un mailer et un limiteur en mémoire
+func newTestServer(t *testing.T, store db.Store, cfg config.Config) *MyServer {
+	t.Helper()
+	key, err := zwt.EphemeralKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := zwt.NewService(zwt.Config{SigningKeyID: key.ID, Keys: []zwt.KeyConfig{key}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Uploads.Dir = t.TempDir()
+	return NewServer(cfg, store, wsk.NewWebsocketChat(tokens), tokens, mail.NewMemoryMailer(false), throttle.NewMemoryLimiter())
+}
+
+// accessToken ouvre une session pour userID et renvoie son token d'accès
+func accessToken(t *testing.T, s *MyServer, userID uuid.UUID, username, role string) string {
+	t.Helper()
+	session, _, err := CreateSession(s.Store.Sessions(), userID, "test", httptest.NewRequest(http.MethodPost, "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := s.Tokens.GenerateJWT(userID, username, role, session.ID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// serve envoie une requête JSON authentifiée par token au routeur de s
+func serve(s *MyServer, token, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	s.Router.ServeHTTP(rec, req)
+	return rec
+}
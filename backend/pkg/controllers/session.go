@@ -0,0 +1,384 @@
+package controllers
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+const (
+	refreshTokenTTL = 30 * 24 * time.Hour
+	// intervalle minimal entre deux mises à jour de last_used_at pour limiter les écritures
+	sessionTouchInterval = time.Minute
+)
+
+var (
+	ErrSessionRevoked      = db.ErrSessionRevoked
+	ErrRefreshTokenInvalid = db.ErrTokenInvalid
+	ErrRefreshTokenReused  = db.ErrTokenReused
+	// un token d'assistance (impersonation) ne permet que les lectures
+	ErrReadOnlySession = errors.New("impersonation tokens are read-only")
+)
+
+// newRefreshToken génère un refresh token aléatoire et son empreinte stockée en base
+func newRefreshToken() (string, string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token := base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashToken(token), nil
+}
+
+// hashToken retourne l'empreinte SHA-256 d'un token, seule forme conservée en base
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// clientIP retourne l'adresse du client sans le port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// CreateSession enregistre un nouvel appareil connecté et son premier refresh token
+func CreateSession(sessions db.SessionRepo, userID uuid.UUID, device string, r *http.Request) (models.Session, string, error) {
+	now := time.Now()
+	session := models.Session{
+		ID:         uuid.Must(uuid.NewV4()),
+		UserID:     userID,
+		Device:     strings.TrimSpace(device),
+		UserAgent:  r.UserAgent(),
+		IPAddress:  clientIP(r),
+		CreatedAt:  now,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL),
+	}
+
+	refreshToken, refreshHash, err := newRefreshToken()
+	if err != nil {
+		return session, "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	if err := sessions.Create(session, refreshHash); err != nil {
+		return session, "", err
+	}
+	return session, refreshToken, nil
+}
+
+// RotateRefreshToken consomme un refresh token et en émet un nouveau pour la même session.
+// Présenter un token déjà consommé révoque la session entière (vol probable)
+func RotateRefreshToken(sessions db.SessionRepo, presented string) (models.Session, string, error) {
+	newToken, newHash, err := newRefreshToken()
+	if err != nil {
+		return models.Session{}, "", err
+	}
+	session, err := sessions.Rotate(hashToken(presented), newHash, time.Now())
+	if errors.Is(err, ErrRefreshTokenReused) {
+		log.Printf("⚠️ Refresh token reuse detected, session %s revoked", session.ID)
+	}
+	if err != nil {
+		return session, "", err
+	}
+	return session, newToken, nil
+}
+
+// sessionIsActive vérifie que la session n'est pas expirée et n'a pas été révoquée
+func sessionIsActive(session models.Session) bool {
+	return !session.RevokedAt.Valid && time.Now().Before(session.ExpiresAt)
+}
+
+// TouchSession met à jour la dernière activité de la session, au plus une fois par minute
+func TouchSession(sessions db.SessionRepo, sessionID uuid.UUID) error {
+	now := time.Now()
+	return sessions.Touch(sessionID, now, now.Add(-sessionTouchInterval))
+}
+
+// ListActiveSessions retourne les sessions non révoquées et non expirées d'un utilisateur
+func ListActiveSessions(sessions db.SessionRepo, userID uuid.UUID) ([]models.Session, error) {
+	list, err := sessions.ListActive(userID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range list {
+		list[i].Client = describeUserAgent(list[i].UserAgent)
+	}
+	return list, nil
+}
+
+// describeUserAgent donne une description lisible ("Firefox on Linux") d'un User-Agent
+func describeUserAgent(ua string) string {
+	if ua == "" {
+		return "Unknown client"
+	}
+
+	browser := "Unknown browser"
+	switch {
+	case strings.Contains(ua, "Edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "OPR/") || strings.Contains(ua, "Opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "Firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "Chrome/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "Safari/"):
+		browser = "Safari"
+	case strings.HasPrefix(ua, "curl/"):
+		browser = "curl"
+	}
+
+	os := ""
+	switch {
+	case strings.Contains(ua, "Android"):
+		os = "Android"
+	case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPad"):
+		os = "iOS"
+	case strings.Contains(ua, "Windows"):
+		os = "Windows"
+	case strings.Contains(ua, "Mac OS X") || strings.Contains(ua, "Macintosh"):
+		os = "macOS"
+	case strings.Contains(ua, "Linux"):
+		os = "Linux"
+	}
+
+	if os == "" {
+		return browser
+	}
+	return browser + " on " + os
+}
+
+// sessionFromRefreshToken retrouve la session d'un refresh token sans le consommer
+func sessionFromRefreshToken(sessions db.SessionRepo, presented string) (uuid.UUID, error) {
+	sessionID, err := sessions.ByRefreshToken(hashToken(presented))
+	if err != nil {
+		return uuid.Nil, ErrRefreshTokenInvalid
+	}
+	return sessionID, nil
+}
+
+// setAuthCookies dépose le token d'accès et le refresh token en cookies HttpOnly
+func (s *MyServer) setAuthCookies(w http.ResponseWriter, accessToken, refreshToken string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     "token",
+		Value:    accessToken,
+		Expires:  time.Now().Add(s.Tokens.TTL()),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   false,
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "refresh_token",
+		Value:    refreshToken,
+		Expires:  time.Now().Add(refreshTokenTTL),
+		Path:     "/token",
+		HttpOnly: true,
+		Secure:   false,
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// refreshTokenFromRequest lit le refresh token dans le corps JSON ou, à défaut, dans le cookie
+func refreshTokenFromRequest(r *http.Request) string {
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if r.Body != nil {
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body.RefreshToken != "" {
+			return body.RefreshToken
+		}
+	}
+	if cookie, err := r.Cookie("refresh_token"); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// RefreshTokenHandler échange un refresh token valide contre une nouvelle paire de tokens
+func (s *MyServer) RefreshTokenHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		presented := refreshTokenFromRequest(r)
+		if presented == "" {
+			WriteError(w, r, CodeInvalidToken, "Refresh token is required")
+			return
+		}
+
+		session, refreshToken, err := RotateRefreshToken(s.Store.Sessions(), presented)
+		if err != nil {
+			log.Println("Refresh token rejected:", err)
+			if errors.Is(err, ErrRefreshTokenReused) {
+				s.WebSocketChat.CloseSession(session.ID.String())
+			}
+			if errors.Is(err, ErrRefreshTokenInvalid) || errors.Is(err, ErrRefreshTokenReused) || errors.Is(err, ErrSessionRevoked) {
+				WriteError(w, r, CodeInvalidToken, "Invalid refresh token")
+				return
+			}
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		if sus, err := ActiveSuspension(s.Store.Admin(), session.UserID); err != nil || sus != nil {
+			if err != nil {
+				log.Println("Failed to check suspension:", err)
+				WriteError(w, r, CodeInternal, "Internal server error")
+				return
+			}
+			s.Store.Sessions().Revoke(session.ID)
+			WriteError(w, r, CodeAccountSuspended, suspensionMessage(sus))
+			return
+		}
+
+		username, err := s.Store.Users().UsernameByID(session.UserID)
+		if err != nil {
+			log.Println("Failed to get username:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		// le rôle est relu à chaque rafraîchissement, un changement prend effet au plus tard à l'expiration du token
+		role, err := s.Store.Users().Role(session.UserID)
+		if err != nil {
+			log.Println("Failed to get role:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		accessToken, err := s.Tokens.GenerateJWT(session.UserID, username, normalizeRole(role), session.ID.String())
+		if err != nil {
+			log.Println("Failed to generate token:", err)
+			WriteError(w, r, CodeInternal, "Internal server error")
+			return
+		}
+
+		s.setAuthCookies(w, accessToken, refreshToken)
+		SendJSONResponse(w, LoginResponses{
+			Token:        accessToken,
+			RefreshToken: refreshToken,
+			ExpiresIn:    int64(s.Tokens.TTL().Seconds()),
+			Message:      "Token refreshed",
+		}, http.StatusOK)
+	}
+}
+
+// SessionsHandler liste les appareils connectés (GET) ou déconnecte tous les autres appareils (DELETE)
+func (s *MyServer) SessionsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+		sid, _ := r.Context().Value(sessionIDKey).(string)
+		currentSessionID, _ := uuid.FromString(sid)
+
+		switch r.Method {
+		case http.MethodGet:
+			sessions, err := ListActiveSessions(s.Store.Sessions(), userID)
+			if err != nil {
+				log.Println("Failed to list sessions:", err)
+				WriteError(w, r, CodeInternal, "Failed to list sessions")
+				return
+			}
+			for i := range sessions {
+				sessions[i].Current = sessions[i].ID == currentSessionID
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(sessions)
+
+		case http.MethodDelete:
+			revoked, err := s.Store.Sessions().RevokeOthers(userID, currentSessionID)
+			if err != nil {
+				log.Println("Failed to revoke sessions:", err)
+				WriteError(w, r, CodeInternal, "Failed to revoke sessions")
+				return
+			}
+			for _, id := range revoked {
+				s.WebSocketChat.CloseSession(id.String())
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"message": "Other sessions signed out",
+				"revoked": len(revoked),
+			})
+
+		default:
+			WriteError(w, r, CodeMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// RevokeSessionHandler déconnecte un appareil précis de l'utilisateur
+func (s *MyServer) RevokeSessionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
+		if !ok {
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
+			return
+		}
+
+		sessionID, err := uuid.FromString(r.PathValue("id"))
+		if err != nil {
+			WriteError(w, r, CodeInvalidID, "Invalid session ID")
+			return
+		}
+
+		revoked, err := s.Store.Sessions().RevokeForUser(userID, sessionID)
+		if err != nil {
+			log.Println("Failed to revoke session:", err)
+			WriteError(w, r, CodeInternal, "Failed to revoke session")
+			return
+		}
+		if !revoked {
+			WriteError(w, r, CodeSessionNotFound, "Session not found")
+			return
+		}
+
+		s.WebSocketChat.CloseSession(sessionID.String())
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Session signed out"})
+	}
+}
+
+// sessionActive est utilisé par le hub WebSocket pour refuser au handshake les sessions révoquées
+// et celles d'un compte suspendu
+func (s *MyServer) sessionActive(sessionID string) (bool, error) {
+	id, err := uuid.FromString(sessionID)
+	if err != nil {
+		return false, nil
+	}
+
+	session, err := s.Store.Sessions().Get(id)
+	if errors.Is(err, db.ErrNotFound) {
+		return false, nil
+	}
+	if err != nil || !sessionIsActive(session) {
+		return false, err
+	}
+
+	sus, err := ActiveSuspension(s.Store.Admin(), session.UserID)
+	if err != nil {
+		return false, err
+	}
+	return sus == nil, nil
+}
@@ -1,18 +1,24 @@
 package controllers
 
 import (
+	"backend/pkg/db"
 	"backend/pkg/zwt"
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"strings"
+
+	"github.com/gofrs/uuid"
 )
 
 type contextKey string
 
 const userIDKey contextKey = "userID"
 const usernameIDKey contextKey = "username"
+const sessionIDKey contextKey = "sessionID"
+const roleKey contextKey = "role"
 
 func (s *MyServer) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -22,37 +28,34 @@ func (s *MyServer) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			log.Println("No Authorization header")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		token, err := bearerToken(r)
+		if err != nil {
+			log.Println(err)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
-		log.Println("Token reçu :", authHeader)
-
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			log.Println("Invalid Authorization format")
-			http.Error(w, "Invalid Authorization format", http.StatusUnauthorized)
+		claims, err := s.verifyAccessToken(token, r.Method)
+		if errors.Is(err, ErrAccountSuspended) {
+			log.Printf("Rejected request from suspended user %s", claims.UserID)
+			WriteError(w, r, CodeAccountSuspended, "Account suspended")
+			return
+		}
+		if errors.Is(err, ErrReadOnlySession) {
+			WriteError(w, r, CodeReadOnlySession, "Impersonation tokens are read-only")
 			return
 		}
-
-		token := tokenParts[1]
-		claims, err := zwt.VerifyJWT(token)
 		if err != nil {
 			log.Println("Token verification failed:", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
-		log.Println("User ID from token:", claims.UserID)
-		log.Println("Username from token:", claims.Username)
-
 		// Inject User ID et Username
 		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
 		ctx = context.WithValue(ctx, usernameIDKey, claims.Username)
-		log.Println("Injecting User ID and Username into context:", claims.UserID, claims.Username)
+		ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
+		ctx = context.WithValue(ctx, roleKey, normalizeRole(claims.Role))
 		next.ServeHTTP(w, r.WithContext(ctx))
 
 	}
@@ -60,24 +63,17 @@ func (s *MyServer) Authenticate(next http.HandlerFunc) http.HandlerFunc {
 
 func (s *MyServer) VerifyTokenHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header missing", http.StatusUnauthorized)
-			return
-		}
-
-		// extraire le token de l'en-tête Authorization
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			http.Error(w, "Invalid Authorization format", http.StatusUnauthorized)
+		token, err := bearerToken(r)
+		if err != nil {
+			WriteError(w, r, CodeUnauthorized, err.Error())
 			return
 		}
 
-		token := tokenParts[1]
-		claims, err := zwt.VerifyJWT(token)
+		// simple vérification sans effet : un token d'assistance y est accepté
+		claims, err := s.verifyAccessToken(token, http.MethodGet)
 		if err != nil {
 			log.Println("Token verification failed:", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
@@ -87,176 +83,62 @@ func (s *MyServer) VerifyTokenHandler() http.HandlerFunc {
 	}
 }
 
-/*
-import (
-	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/base64"
-	"encoding/json"
-	"fmt"
-	"log"
-	"net/http"
-	"strings"
-	"time"
-
-	"github.com/gofrs/uuid"
-)
-
-type Claims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
-	Exp      int64     `json:"exp"`
-}
-
-var jwtKey = []byte("my_secret_key")
-
-type contextKey string
-
-const userIDKey contextKey = "userID"
-
-// GenerateJWT génère un JWT avec UserID, Username et une date d'expiration
-func GenerateJWT(userID uuid.UUID, username string) (string, error) {
-	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
-
-	expirationTime := time.Now().Add(24 * time.Hour).Unix()
-	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Exp:      expirationTime,
-	}
-	claimsJSON, err := json.Marshal(claims)
+// verifyAccessToken vérifie le token puis s'assure que sa session n'a pas été révoquée.
+// Un token d'assistance (impersonation) n'est accepté que pour une requête GET ou HEAD (ErrReadOnlySession)
+func (s *MyServer) verifyAccessToken(token, method string) (*zwt.Claims, error) {
+	claims, err := s.Tokens.VerifyJWT(token)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	payload := base64.RawURLEncoding.EncodeToString(claimsJSON)
-
-	signature := GenerateHMACSHA256(header + "." + payload)
-	token := fmt.Sprintf("%s.%s.%s", header, payload, signature)
-
-	// Log parts pour verification
-	log.Println("Token generation:")
-	log.Println("Header:", header)
-	log.Println("Payload:", payload)
-	log.Println("Signature:", signature)
-
-	return token, nil
-}
-
-// generateHMACSHA256 génère la signature HMAC-SHA256
-func GenerateHMACSHA256(data string) string {
-	h := hmac.New(sha256.New, jwtKey)
-	h.Write([]byte(data))
-	return strings.TrimRight(base64.URLEncoding.EncodeToString(h.Sum(nil)), "=")
-}
-
-// VerifyJWT vérifie le token JWT et retourne les claims si valides
-func VerifyJWT(token string) (*Claims, error) {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		log.Println("Invalid token format")
-		return nil, fmt.Errorf("invalid token format")
+	if claims.Impersonator != "" && method != http.MethodGet && method != http.MethodHead {
+		return claims, ErrReadOnlySession
 	}
 
-	header := parts[0]
-	payload := parts[1]
-	signature := parts[2]
-
-	expectedSignature := GenerateHMACSHA256(header + "." + payload)
-	if signature != expectedSignature {
-		log.Println("Invalid token signature")
-		log.Println("Expected signature:", expectedSignature)
-		log.Println("Provided signature:", signature)
-		return nil, fmt.Errorf("invalid token signature")
+	// les tokens à usage restreint (étape 2FA) ne donnent pas accès à l'API
+	if claims.Purpose != "" {
+		return nil, fmt.Errorf("token with purpose %q is not an access token", claims.Purpose)
 	}
 
-	payloadData, err := base64.RawURLEncoding.DecodeString(payload)
+	sessionID, err := uuid.FromString(claims.SessionID)
 	if err != nil {
-		log.Println("Invalid token payload:", err)
-		return nil, fmt.Errorf("invalid token payload")
+		return nil, fmt.Errorf("token has no session")
 	}
 
-	var claims Claims
-	err = json.Unmarshal(payloadData, &claims)
-	if err != nil {
-		log.Println("Invalid token claims:", err)
-		return nil, fmt.Errorf("invalid token claims")
+	session, err := s.Store.Sessions().Get(sessionID)
+	if err != nil && !errors.Is(err, db.ErrNotFound) {
+		return nil, fmt.Errorf("failed to check session: %w", err)
 	}
-
-	if time.Now().Unix() > claims.Exp {
-		log.Println("Token has expired")
-		return nil, fmt.Errorf("token has expired")
+	if err != nil || !sessionIsActive(session) {
+		return nil, ErrSessionRevoked
 	}
 
-	log.Println("Token is valid, UserID:", claims.UserID)
-	return &claims, nil
-}
-
-func (s *MyServer) Authenticate(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		// ignore la vérification pour les requêtes OPTIONS (CORS) et la route de déconnexion
-		if r.Method == http.MethodOptions || r.URL.Path == "/logout" {
-			next.ServeHTTP(w, r)
-			return
-		}
-
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			log.Println("No Authorization header")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
-
-		log.Println("Token reçu :", authHeader)
-
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			log.Println("Invalid Authorization format")
-			http.Error(w, "Invalid Authorization format", http.StatusUnauthorized)
-			return
-		}
-
-		token := tokenParts[1]
-		claims, err := VerifyJWT(token)
+	// un token d'assistance reste valide même si l'utilisateur ciblé est suspendu
+	if claims.Impersonator == "" {
+		sus, err := ActiveSuspension(s.Store.Admin(), claims.UserID)
 		if err != nil {
-			log.Println("Token verification failed:", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
+			return nil, err
 		}
+		if sus != nil {
+			return claims, ErrAccountSuspended
+		}
+	}
 
-		// injecte l'ID utilisateur dans le contexte
-		log.Println("User ID from token:", claims.UserID)
-		ctx := context.WithValue(r.Context(), userIDKey, claims.UserID)
-		next.ServeHTTP(w, r.WithContext(ctx))
+	if err := TouchSession(s.Store.Sessions(), sessionID); err != nil {
+		log.Println("Failed to update session activity:", err)
 	}
+	return claims, nil
 }
 
-func (s *MyServer) VerifyTokenHandler() http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header missing", http.StatusUnauthorized)
-			return
-		}
-
-		// extraire le token de l'en-tête Authorization
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			http.Error(w, "Invalid Authorization format", http.StatusUnauthorized)
-			return
-		}
-
-		token := tokenParts[1]
-		claims, err := VerifyJWT(token)
-		if err != nil {
-			log.Println("Token verification failed:", err)
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
-			return
-		}
+// bearerToken extrait le token de l'en-tête Authorization
+func bearerToken(r *http.Request) (string, error) {
+	authHeader := r.Header.Get("Authorization")
+	if authHeader == "" {
+		return "", errors.New("Authorization header missing")
+	}
 
-		// Si le token est valide, répondre avec un statut 200 ok
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte(fmt.Sprintf("Token is valid. User ID: %s", claims.UserID)))
+	tokenParts := strings.Split(authHeader, " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" || tokenParts[1] == "" {
+		return "", errors.New("Invalid Authorization format")
 	}
+	return tokenParts[1], nil
 }
-*/
@@ -1,96 +1,45 @@
 package controllers
 
 import (
+	"backend/pkg/db"
 	"backend/pkg/models"
 	"encoding/json"
+	"errors"
 	"io"
 	"net/http"
-	"strings"
 
 	"github.com/gofrs/uuid"
 )
 
 func (s *MyServer) UpdateProfileHandler() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-			return
-		}
-
 		userID, ok := r.Context().Value(userIDKey).(uuid.UUID)
 		if !ok {
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 
 		var updatedProfile models.UserProfil
 		body, err := io.ReadAll(r.Body)
 		if err != nil {
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid request payload")
 			return
 		}
 		if err := json.Unmarshal(body, &updatedProfile); err != nil {
-			http.Error(w, "Invalid request payload", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid request payload")
 			return
 		}
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		if !checkValid(w, r, &updatedProfile) {
 			return
 		}
-		defer DB.Close()
 
-		query := "UPDATE users SET "
-		var params []interface{}
-		var updates []string
-
-		// Vérifier si chaque champ est valide avant de l'ajouter
-		if updatedProfile.FirstName.Valid {
-			updates = append(updates, "first_name = ?")
-			params = append(params, updatedProfile.FirstName.String)
-		}
-		if updatedProfile.LastName.Valid {
-			updates = append(updates, "last_name = ?")
-			params = append(params, updatedProfile.LastName.String)
-		}
-		if updatedProfile.Email != "" {
-			updates = append(updates, "email = ?")
-			params = append(params, updatedProfile.Email)
-		}
-		if updatedProfile.Gender != "" {
-			updates = append(updates, "gender = ?")
-			params = append(params, updatedProfile.Gender)
-		}
-		if updatedProfile.Avatar.Valid {
-			updates = append(updates, "avatar = ?")
-			params = append(params, updatedProfile.Avatar.String)
-		}
-		if updatedProfile.Bio.Valid {
-			updates = append(updates, "bio = ?")
-			params = append(params, updatedProfile.Bio.String)
-		}
-		if updatedProfile.PhoneNumber.Valid {
-			updates = append(updates, "phone_number = ?")
-			params = append(params, updatedProfile.PhoneNumber.String)
-		}
-		if updatedProfile.Address.Valid {
-			updates = append(updates, "address = ?")
-			params = append(params, updatedProfile.Address.String)
-		}
-
-		if len(updates) == 0 {
-			http.Error(w, "No fields to update", http.StatusBadRequest)
+		err = s.Store.Users().UpdateProfile(userID, updatedProfile)
+		if errors.Is(err, db.ErrNoFields) {
+			WriteError(w, r, CodeValidation, "No fields to update")
 			return
 		}
-
-		updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
-		query += strings.Join(updates, ", ") + " WHERE id = ?"
-		params = append(params, userID)
-
-		_, err = DB.Exec(query, params...)
 		if err != nil {
-			http.Error(w, "Failed to update profile", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to update profile")
 			return
 		}
 
@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"backend/pkg/validate"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+)
+
+// maxJSONBody limite la taille des corps JSON décodés par decodeJSON
+const maxJSONBody = 1 << 20
+
+// decodeValid décode le corps JSON de la requête dans dst puis le valide avec checkValid.
+// En cas d'échec la réponse d'erreur est déjà écrite et decodeValid renvoie false
+func decodeValid(w http.ResponseWriter, r *http.Request, dst any) bool {
+	return decodeJSON(w, r, dst) && checkValid(w, r, dst)
+}
+
+// decodeJSON décode le corps JSON sans le valider, pour compléter dst avant checkValid
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxJSONBody)).Decode(dst); err != nil {
+		log.Println("Invalid request payload:", err)
+		WriteError(w, r, CodeInvalidJSON, "Invalid request payload")
+		return false
+	}
+	return true
+}
+
+// checkValid vérifie les tags `validate` de v et répond 400 avec le détail des champs invalides.
+// À appeler une fois complétés les champs déduits de la requête (chemin, formulaire...)
+func checkValid(w http.ResponseWriter, r *http.Request, v any) bool {
+	err := validate.Struct(v)
+	if err == nil {
+		return true
+	}
+	var fieldErrs validate.Errors
+	if !errors.As(err, &fieldErrs) {
+		WriteError(w, r, CodeInternal, "Failed to validate request")
+		return false
+	}
+	details := make([]FieldError, len(fieldErrs))
+	for i, fe := range fieldErrs {
+		details[i] = FieldError{Field: fe.Field, Code: fe.Code, Message: fe.Message}
+	}
+	WriteValidationError(w, r, details...)
+	return false
+}
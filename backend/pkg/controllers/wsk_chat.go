@@ -2,11 +2,9 @@ package controllers
 
 import (
 	"backend/pkg/models"
-	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -28,7 +26,7 @@ func (s *MyServer) OnlineUsersHandler() http.HandlerFunc {
 
 		w.Header().Set("Content-Type", "application/json")
 		if err := json.NewEncoder(w).Encode(users); err != nil {
-			http.Error(w, "Failed to encode users", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode users")
 		}
 	}
 }
@@ -38,58 +36,23 @@ func (s *MyServer) GetMessagesHandler() http.HandlerFunc {
 		log.Println("Received GET request to fetch messages")
 
 		username := r.URL.Query().Get("user")
-		offsetStr := r.URL.Query().Get("offset")
-		offset, err := strconv.Atoi(offsetStr)
-		if err != nil {
-			offset = 0
-		}
-
-		log.Println("Fetching messages for user:", username, "with offset:", offset)
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		cursor, limit, ok := cursorPage(w, r)
+		if !ok {
 			return
 		}
-		defer DB.Close()
 
-		query := `SELECT id, sender_username, target_username, content, timestamp, type, emoji FROM chatHistory WHERE sender_username = ? OR target_username = ? ORDER BY timestamp DESC LIMIT 10 OFFSET ?`
+		log.Println("Fetching messages for user:", username, "with limit:", limit)
 
-		rows, err := DB.Query(query, username, username, offset)
+		history, next, err := s.Store.Messages().PrivateHistory(username, cursor, limit)
 		if err != nil {
 			log.Println("Failed to fetch chatHistory:", err)
-			http.Error(w, "Failed to fetch chatHistory", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to fetch chatHistory")
 			return
 		}
-		defer rows.Close()
 
 		var messages []map[string]interface{}
-
-		for rows.Next() {
-			var id, senderUsername, targetUsername, content, messageType string
-			var timestamp time.Time
-			var emoji sql.NullString
-
-			if err := rows.Scan(&id, &senderUsername, &targetUsername, &content, &timestamp, &messageType, &emoji); err != nil {
-				log.Println("Failed to scan message:", err)
-				http.Error(w, "Failed to scan message", http.StatusInternalServerError)
-				return
-			}
-
-			msg := map[string]interface{}{
-				"id":              id,
-				"sender_username": senderUsername,
-				"target_username": targetUsername,
-				"content":         content,
-				"timestamp":       timestamp,
-				"type":            messageType,
-				"emoji":           "",
-			}
-			if emoji.Valid {
-				msg["emoji"] = emoji.String
-			}
-			messages = append(messages, msg)
+		for _, msg := range history {
+			messages = append(messages, messageJSON(msg))
 		}
 
 		log.Println("Fetched", len(messages), "messages for user:", username)
@@ -98,10 +61,9 @@ func (s *MyServer) GetMessagesHandler() http.HandlerFunc {
 			messages = []map[string]interface{}{}
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(messages); err != nil {
+		if err := writeList(w, r, messages, next); err != nil {
 			log.Println("Failed to encode messages:", err)
-			http.Error(w, "Failed to encode messages", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode messages")
 		}
 	}
 }
@@ -116,14 +78,14 @@ func (s *MyServer) PostMessageHandler() http.HandlerFunc {
 		var msg models.Message
 		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 			log.Println("Invalid message format:", err)
-			http.Error(w, "Invalid message format", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid message format")
 			return
 		}
 
 		log.Printf("Message reçu : %+v", msg)
 
 		if msg.Content == "" || msg.TargetUsername == "" {
-			http.Error(w, "Message content or target username is missing", http.StatusBadRequest)
+			WriteError(w, r, CodeValidation, "Message content or target username is missing", fieldRequired("content"), fieldRequired("target_username"))
 			return
 		}
 
@@ -134,24 +96,14 @@ func (s *MyServer) PostMessageHandler() http.HandlerFunc {
 		sender, ok := r.Context().Value(usernameIDKey).(string)
 		if !ok {
 			log.Println("Username not found in context")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 		msg.SenderUsername = sender
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
-		query := `INSERT INTO chatHistory (id, sender_username, target_username, content, timestamp, type, emoji) VALUES (?, ?, ?, ?, ?, ?, ?)`
-		_, err = DB.Exec(query, msg.ID.String(), msg.SenderUsername, msg.TargetUsername, msg.Content, msg.Timestamp, msg.Type, msg.Emoji)
-		if err != nil {
+		if err := s.Store.Messages().SavePrivate(msg); err != nil {
 			log.Printf("Failed to save message: %v", err)
-			http.Error(w, "Failed to save message", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to save message")
 			return
 		}
 
@@ -185,3 +137,16 @@ func (s *MyServer) PostMessageHandler() http.HandlerFunc {
 
 	}
 }
+
+// messageJSON met en forme un message de l'historique, emoji toujours présent
+func messageJSON(msg models.Message) map[string]interface{} {
+	return map[string]interface{}{
+		"id":              msg.ID.String(),
+		"sender_username": msg.SenderUsername,
+		"target_username": msg.TargetUsername,
+		"content":         msg.Content,
+		"timestamp":       msg.Timestamp,
+		"type":            msg.Type,
+		"emoji":           msg.Emoji,
+	}
+}
@@ -2,11 +2,9 @@ package controllers
 
 import (
 	"backend/pkg/models"
-	"database/sql"
 	"encoding/json"
 	"log"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -17,65 +15,23 @@ func (s *MyServer) GetMessagesGroupsHandler() http.HandlerFunc {
 		log.Println("Received GET request to fetch messages")
 
 		username := r.URL.Query().Get("user")
-		offsetStr := r.URL.Query().Get("offset")
-		offset, err := strconv.Atoi(offsetStr)
-		if err != nil {
-			offset = 0
-		}
-
-		log.Println("Fetching messages for user:", username, "with offset:", offset)
-
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		cursor, limit, ok := cursorPage(w, r)
+		if !ok {
 			return
 		}
-		defer DB.Close()
-
-		query := `
-			SELECT id, sender_username, target_username, content, timestamp, type, emoji 
-			FROM chatGroup 
-			WHERE target_username = ? 
-			ORDER BY timestamp ASC 
-			LIMIT 10 OFFSET ?
-		`
 
-		rows, err := DB.Query(query, username, offset)
+		log.Println("Fetching messages for user:", username, "with limit:", limit)
 
+		history, next, err := s.Store.Messages().GroupHistory(username, cursor, limit)
 		if err != nil {
 			log.Println("Failed to fetch chatGroup:", err)
-			http.Error(w, "Failed to fetch chatGroup", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to fetch chatGroup")
 			return
 		}
-		defer rows.Close()
 
 		var messages []map[string]interface{}
-
-		for rows.Next() {
-			var id, senderUsername, targetUsername, content, messageType string
-			var timestamp time.Time
-			var emoji sql.NullString
-
-			if err := rows.Scan(&id, &senderUsername, &targetUsername, &content, &timestamp, &messageType, &emoji); err != nil {
-				log.Println("Failed to scan message:", err)
-				http.Error(w, "Failed to scan message", http.StatusInternalServerError)
-				return
-			}
-
-			msg := map[string]interface{}{
-				"id":              id,
-				"sender_username": senderUsername,
-				"target_username": targetUsername,
-				"content":         content,
-				"timestamp":       timestamp,
-				"type":            messageType,
-				"emoji":           "",
-			}
-			if emoji.Valid {
-				msg["emoji"] = emoji.String
-			}
-			messages = append(messages, msg)
+		for _, msg := range history {
+			messages = append(messages, messageJSON(msg))
 		}
 
 		log.Println("Fetched", len(messages), "messages for user:", username)
@@ -84,10 +40,9 @@ func (s *MyServer) GetMessagesGroupsHandler() http.HandlerFunc {
 			messages = []map[string]interface{}{}
 		}
 
-		w.Header().Set("Content-Type", "application/json")
-		if err := json.NewEncoder(w).Encode(messages); err != nil {
+		if err := writeList(w, r, messages, next); err != nil {
 			log.Println("Failed to encode messages:", err)
-			http.Error(w, "Failed to encode messages", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to encode messages")
 		}
 	}
 }
@@ -102,14 +57,14 @@ func (s *MyServer) PostMessageGroupHandler() http.HandlerFunc {
 		var msg models.Message
 		if err := json.NewDecoder(r.Body).Decode(&msg); err != nil {
 			log.Println("Invalid message format:", err)
-			http.Error(w, "Invalid message format", http.StatusBadRequest)
+			WriteError(w, r, CodeInvalidJSON, "Invalid message format")
 			return
 		}
 
 		log.Printf("Message reçu : %+v", msg)
 
 		if msg.Content == "" || msg.TargetUsername == "" {
-			http.Error(w, "Message content or target username is missing", http.StatusBadRequest)
+			WriteError(w, r, CodeValidation, "Message content or target username is missing", fieldRequired("content"), fieldRequired("target_username"))
 			return
 		}
 
@@ -120,24 +75,14 @@ func (s *MyServer) PostMessageGroupHandler() http.HandlerFunc {
 		sender, ok := r.Context().Value(usernameIDKey).(string)
 		if !ok {
 			log.Println("Username not found in context")
-			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			WriteError(w, r, CodeUnauthorized, "Unauthorized")
 			return
 		}
 		msg.SenderUsername = sender
 
-		DB, err := s.Store.OpenDatabase()
-		if err != nil {
-			log.Println("Failed to open database:", err)
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
-		}
-		defer DB.Close()
-
-		query := `INSERT INTO chatGroup (id, sender_username, target_username, content, timestamp, type, emoji) VALUES (?, ?, ?, ?, ?, ?, ?)`
-		_, err = DB.Exec(query, msg.ID.String(), msg.SenderUsername, msg.TargetUsername, msg.Content, msg.Timestamp, msg.Type, msg.Emoji)
-		if err != nil {
+		if err := s.Store.Messages().SaveGroup(msg); err != nil {
 			log.Printf("Failed to save message: %v", err)
-			http.Error(w, "Failed to save message", http.StatusInternalServerError)
+			WriteError(w, r, CodeInternal, "Failed to save message")
 			return
 		}
 
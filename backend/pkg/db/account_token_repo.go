@@ -0,0 +1,92 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// usages des tokens à usage unique envoyés par email (colonne user_tokens.purpose)
+const (
+	TokenPurposeVerifyEmail   = "verify_email"
+	TokenPurposeResetPassword = "reset_password"
+)
+
+type accountTokenRepo struct {
+	db *sql.DB
+}
+
+// Create enregistre un token à usage unique (empreinte tokenHash) valable jusqu'à expiresAt.
+// Les tokens précédents de même usage encore valides sont invalidés
+func (r *accountTokenRepo) Create(userID uuid.UUID, purpose, tokenHash string, now, expiresAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`UPDATE user_tokens SET used_at = ? WHERE user_id = ? AND purpose = ? AND used_at IS NULL`, now, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to invalidate previous tokens: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO user_tokens (id, user_id, purpose, token_hash, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		uuid.Must(uuid.NewV4()), userID, purpose, tokenHash, now, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert token: %w", err)
+	}
+	return tx.Commit()
+}
+
+// VerifyEmail consomme un token de vérification et confirme l'adresse email de son utilisateur
+func (r *accountTokenRepo) VerifyEmail(tokenHash string, now time.Time) (uuid.UUID, error) {
+	return r.consume(tokenHash, TokenPurposeVerifyEmail, now,
+		`UPDATE users SET email_verified_at = COALESCE(email_verified_at, ?), updated_at = ? WHERE id = ?`, now, now)
+}
+
+// ResetPassword consomme un token de réinitialisation et enregistre passwordHash.
+// Recevoir le lien prouve aussi la possession de l'adresse email, qui est confirmée
+func (r *accountTokenRepo) ResetPassword(tokenHash, passwordHash string, now time.Time) (uuid.UUID, error) {
+	return r.consume(tokenHash, TokenPurposeResetPassword, now,
+		`UPDATE users SET password_hash = ?, email_verified_at = COALESCE(email_verified_at, ?), updated_at = ? WHERE id = ?`, passwordHash, now, now)
+}
+
+// consume valide le token et le marque comme utilisé, puis applique update (dont le dernier argument est
+// l'utilisateur du token) dans la même transaction. ErrTokenInvalid pour un token inconnu, expiré ou déjà utilisé
+func (r *accountTokenRepo) consume(tokenHash, purpose string, now time.Time, update string, args ...interface{}) (uuid.UUID, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	var tokenID, userID uuid.UUID
+	var expiresAt time.Time
+	var usedAt sql.NullTime
+	err = tx.QueryRow(`SELECT id, user_id, expires_at, used_at FROM user_tokens WHERE token_hash = ? AND purpose = ?`,
+		tokenHash, purpose).Scan(&tokenID, &userID, &expiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return uuid.Nil, ErrTokenInvalid
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if usedAt.Valid || now.After(expiresAt) {
+		return uuid.Nil, ErrTokenInvalid
+	}
+
+	res, err := tx.Exec(`UPDATE user_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`, now, tokenID)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return uuid.Nil, ErrTokenInvalid
+	}
+
+	if _, err := tx.Exec(update, append(args, userID)...); err != nil {
+		return uuid.Nil, err
+	}
+	return userID, tx.Commit()
+}
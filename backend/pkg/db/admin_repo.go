@@ -0,0 +1,187 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type adminRepo struct {
+	db *sql.DB
+}
+
+// AdminUserFilter choisit les comptes listés par l'administration, un champ vide ne filtre pas
+type AdminUserFilter struct {
+	Query  string // sous-chaîne du nom d'utilisateur ou de l'email
+	Role   string
+	Status string // active, suspended ou banned
+}
+
+// activeSuspension est la sous-requête de la restriction active la plus sévère du compte user_id = column
+func activeSuspension(column string) string {
+	return `SELECT id FROM user_suspensions
+		WHERE user_id = ` + column + ` AND lifted_at IS NULL AND (expires_at IS NULL OR expires_at > ?)
+		ORDER BY CASE kind WHEN 'ban' THEN 0 ELSE 1 END, created_at DESC
+		LIMIT 1`
+}
+
+// ActiveSuspension renvoie la restriction en cours à now, ErrNotFound s'il n'y en a pas
+func (r *adminRepo) ActiveSuspension(userID uuid.UUID, now time.Time) (models.Suspension, error) {
+	var sus models.Suspension
+	var expiresAt sql.NullTime
+	err := r.db.QueryRow(`
+		SELECT id, user_id, kind, reason, expires_at, created_by, created_at
+		FROM user_suspensions
+		WHERE id = (`+activeSuspension("?")+`)`, userID, now).
+		Scan(&sus.ID, &sus.UserID, &sus.Kind, &sus.Reason, &expiresAt, &sus.CreatedBy, &sus.CreatedAt)
+	if err != nil {
+		return sus, notFound(err)
+	}
+	if expiresAt.Valid {
+		sus.ExpiresAt = &expiresAt.Time
+	}
+	return sus, nil
+}
+
+// Suspend enregistre une suspension ou un bannissement
+func (r *adminRepo) Suspend(sus models.Suspension) error {
+	var expires sql.NullTime
+	if sus.ExpiresAt != nil {
+		expires = sql.NullTime{Time: *sus.ExpiresAt, Valid: true}
+	}
+	_, err := r.db.Exec(`INSERT INTO user_suspensions (id, user_id, kind, reason, expires_at, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sus.ID, sus.UserID, sus.Kind, sus.Reason, expires, sus.CreatedBy, sus.CreatedAt)
+	return err
+}
+
+// LiftSuspensions lève toutes les restrictions en cours sur le compte et renvoie leur nombre
+func (r *adminRepo) LiftSuspensions(userID, liftedBy uuid.UUID, now time.Time) (int64, error) {
+	res, err := r.db.Exec(`UPDATE user_suspensions SET lifted_at = ?, lifted_by = ? WHERE user_id = ? AND lifted_at IS NULL`,
+		now, liftedBy, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// ListUsers renvoie une page des comptes, les plus récents d'abord, avec leur restriction active à now
+func (r *adminRepo) ListUsers(filter AdminUserFilter, now time.Time, limit, offset int) ([]models.AdminUser, error) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{now}
+
+	if filter.Query != "" {
+		conditions = append(conditions, "(LOWER(u.username) LIKE LOWER(?) OR LOWER(u.email) LIKE LOWER(?))")
+		args = append(args, "%"+filter.Query+"%", "%"+filter.Query+"%")
+	}
+	if filter.Role != "" {
+		conditions = append(conditions, "u.role = ?")
+		args = append(args, filter.Role)
+	}
+	switch filter.Status {
+	case "":
+	case "active":
+		conditions = append(conditions, "s.id IS NULL")
+	case "suspended":
+		conditions = append(conditions, "s.kind = 'suspension'")
+	case "banned":
+		conditions = append(conditions, "s.kind = 'ban'")
+	default:
+		return nil, fmt.Errorf("unknown status filter %q", filter.Status)
+	}
+	args = append(args, limit, offset)
+
+	// s : restriction active la plus sévère de chaque compte
+	rows, err := r.db.Query(`
+		SELECT u.id, u.username, u.email, u.role, u.email_verified_at IS NOT NULL, u.created_at,
+			s.id, s.kind, s.reason, s.expires_at, s.created_by, s.created_at
+		FROM users u
+		LEFT JOIN user_suspensions s ON s.id = (`+activeSuspension("u.id")+`)
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY u.created_at DESC
+		LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users: %w", err)
+	}
+	defer rows.Close()
+
+	users := []models.AdminUser{}
+	for rows.Next() {
+		var u models.AdminUser
+		var createdAt sql.NullTime
+		var susID, susCreatedBy uuid.NullUUID
+		var susKind, susReason sql.NullString
+		var susExpires, susCreated sql.NullTime
+		if err := rows.Scan(&u.ID, &u.Username, &u.Email, &u.Role, &u.EmailVerified, &createdAt,
+			&susID, &susKind, &susReason, &susExpires, &susCreatedBy, &susCreated); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		u.CreatedAt = createdAt.Time
+		if susID.Valid {
+			u.Suspension = &models.Suspension{
+				ID:        susID.UUID,
+				UserID:    u.ID,
+				Kind:      susKind.String,
+				Reason:    susReason.String,
+				CreatedBy: susCreatedBy.UUID,
+				CreatedAt: susCreated.Time,
+			}
+			if susExpires.Valid {
+				u.Suspension.ExpiresAt = &susExpires.Time
+			}
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// RecordAction ajoute une entrée au journal d'audit (table en ajout seul)
+func (r *adminRepo) RecordAction(entry models.AuditEntry) error {
+	var target uuid.NullUUID
+	if entry.TargetUserID != nil {
+		target = uuid.NullUUID{UUID: *entry.TargetUserID, Valid: true}
+	}
+	_, err := r.db.Exec(`INSERT INTO admin_audit_log (id, actor_id, action, target_user_id, details, ip_address, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		entry.ID, entry.ActorID, entry.Action, target,
+		sql.NullString{String: string(entry.Details), Valid: entry.Details != nil}, entry.IPAddress, entry.CreatedAt)
+	return err
+}
+
+// AuditLog renvoie une page du journal d'audit, la plus récente d'abord, limitée à targetUserID s'il est fourni
+func (r *adminRepo) AuditLog(targetUserID *uuid.UUID, limit, offset int) ([]models.AuditEntry, error) {
+	query := `SELECT id, actor_id, action, target_user_id, COALESCE(details, ''), COALESCE(ip_address, ''), created_at FROM admin_audit_log`
+	args := []interface{}{}
+	if targetUserID != nil {
+		query += ` WHERE target_user_id = ?`
+		args = append(args, *targetUserID)
+	}
+	query += ` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.AuditEntry{}
+	for rows.Next() {
+		var e models.AuditEntry
+		var target uuid.NullUUID
+		var details string
+		if err := rows.Scan(&e.ID, &e.ActorID, &e.Action, &target, &details, &e.IPAddress, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+		if target.Valid {
+			e.TargetUserID = &target.UUID
+		}
+		if details != "" {
+			e.Details = []byte(details)
+		}
+		entries = append(entries, e)
+	}
+	return entries, rows.Err()
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+type commentRepo struct {
+	db *sql.DB
+}
+
+func (r *commentRepo) Create(comment models.Comment) error {
+	query := `INSERT INTO comments (id, post_id, content, user_id, username, created_at)
+              VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.db.Exec(query, comment.ID, comment.PostID, comment.Content, comment.UserID, comment.Username, comment.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert comment into database: %w", err)
+	}
+	return nil
+}
+
+// PostID renvoie le post d'un commentaire, ErrNotFound si le commentaire n'existe pas
+func (r *commentRepo) PostID(commentID uuid.UUID) (uuid.UUID, error) {
+	var postID uuid.UUID
+	err := r.db.QueryRow(`SELECT post_id FROM comments WHERE id = ?`, commentID).Scan(&postID)
+	return postID, notFound(err)
+}
+
+// commentColumns sont les colonnes lues par scanComments ; le premier argument de la requête est le lecteur
+const commentColumns = `c.id, c.content, c.post_id, c.user_id, c.created_at, u.username, u.avatar,
+		       (SELECT COUNT(*) FROM comment_interactions WHERE comment_id = c.id AND interaction_type = 'like') AS total_likes,
+		       EXISTS(SELECT 1 FROM comment_interactions WHERE comment_id = c.id AND user_id = ? AND interaction_type = 'like') AS liked_by_user`
+
+// List renvoie au plus limit commentaires visibles d'un post, du plus ancien au plus récent,
+// à partir de after, avec les likes vus par viewerID, et le curseur de la page suivante
+func (r *commentRepo) List(postID, viewerID uuid.UUID, after Cursor, limit int) ([]models.Comment, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "c.created_at", "c.id", false)
+	query := `
+		SELECT ` + commentColumns + `
+		FROM comments c
+		LEFT JOIN users u ON c.user_id = u.id
+		WHERE c.post_id = ? AND ` + notHidden("comment", "c.id") + ` AND ` + keyset + `
+		ORDER BY c.created_at, c.id
+		LIMIT ?`
+
+	args := append([]any{viewerID, postID}, keysetArgs...)
+	rows, err := r.db.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+	comments, err := scanComments(rows)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+	comments, next := pageOf(comments, limit, func(c models.Comment) Cursor { return Cursor{c.CreatedAt, c.ID} })
+	return comments, next, nil
+}
+
+// SetLike pose (liked) ou retire le like de userID sur le commentaire, comme PostRepo.SetPostLike
+func (r *commentRepo) SetLike(commentID, userID uuid.UUID, liked bool) error {
+	return commentInteractions.set(r.db, commentID, userID, liked)
+}
+
+// ToggleReaction applique la bascule "like" / "unlike" des routes historiques /like_comment et /unlike_comment
+func (r *commentRepo) ToggleReaction(commentID, userID uuid.UUID, reaction string) error {
+	return commentInteractions.toggle(r.db, commentID, userID, reaction)
+}
+
+func scanComments(rows *sql.Rows) ([]models.Comment, error) {
+	defer rows.Close()
+
+	comments := []models.Comment{}
+	for rows.Next() {
+		var comment models.Comment
+		err := rows.Scan(
+			&comment.ID,
+			&comment.Content,
+			&comment.PostID,
+			&comment.UserID,
+			&comment.CreatedAt,
+			&comment.Username,
+			&comment.Avatar,
+			&comment.TotalLikes,
+			&comment.LikedByUser,
+		)
+		if err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
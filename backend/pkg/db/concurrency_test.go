@@ -0,0 +1,112 @@
+package db_test
+
+import (
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/mattn/go-sqlite3"
+)
+
+// TestConcurrentInteractions lance en parallèle likes, unlikes et commentaires sur une base SQLite fichier
+// ouverte avec les pragmas par défaut (WAL, busy_timeout, transactions immédiates) : aucune écriture ne doit
+// échouer sur SQLITE_BUSY et les compteurs dénormalisés doivent correspondre aux lignes
+func TestConcurrentInteractions(t *testing.T) {
+	store := dbtest.SQLite(t)
+	DB := store.DB()
+
+	if mode := dbtest.Scalar[string](t, DB, `PRAGMA journal_mode`); mode != "wal" {
+		t.Fatalf("journal_mode = %s, want wal", mode)
+	}
+	if timeout := dbtest.Scalar[int](t, DB, `PRAGMA busy_timeout`); timeout <= 0 {
+		t.Fatalf("busy_timeout = %d, want a positive timeout", timeout)
+	}
+
+	const workers, rounds = 16, 20
+
+	author := dbtest.User(t, store, "author")
+	posts := []uuid.UUID{
+		dbtest.Post(t, store, author, "public"),
+		dbtest.Post(t, store, author, "public"),
+	}
+	comment := dbtest.Comment(t, store, posts[0], author, "first")
+	users := make([]uuid.UUID, workers)
+	for i := range users {
+		users[i] = dbtest.User(t, store, fmt.Sprintf("user%d", i))
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds*4)
+	for i, userID := range users {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := range rounds {
+				postID := posts[round%len(posts)]
+				ops := []func() error{
+					func() error { return store.Posts().SetPostLike(postID, userID, round%3 != 0) },
+					func() error {
+						return store.Posts().ToggleReaction(postID, userID, []string{"like", "unlike"}[(i+round)%2])
+					},
+					func() error { return store.Comments().SetLike(comment, userID, (i+round)%2 == 0) },
+					func() error {
+						return store.Comments().Create(models.Comment{
+							ID: uuid.Must(uuid.NewV4()), PostID: postID, UserID: userID,
+							Content: fmt.Sprintf("comment %d/%d", i, round), CreatedAt: time.Now(),
+						})
+					},
+				}
+				for _, op := range ops {
+					if err := op(); err != nil {
+						errs <- err
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		var sqliteErr sqlite3.Error
+		if errors.As(err, &sqliteErr) && sqliteErr.Code == sqlite3.ErrBusy || strings.Contains(err.Error(), "database is locked") {
+			t.Errorf("SQLITE_BUSY under concurrent writes: %v", err)
+		} else {
+			t.Errorf("concurrent write failed: %v", err)
+		}
+	}
+
+	for _, postID := range posts {
+		for _, kind := range []string{"like", "unlike"} {
+			total := dbtest.Scalar[int](t, DB, `SELECT total_`+kind+`s FROM posts WHERE id = ?`, postID)
+			rows := dbtest.Scalar[int](t, DB, `SELECT COUNT(*) FROM post_interactions WHERE post_id = ? AND interaction_type = ?`, postID, kind)
+			if total != rows {
+				t.Errorf("post %s: total_%ss = %d, %d rows", postID, kind, total, rows)
+			}
+		}
+		// les commentaires n'ont pas de compteur dénormalisé : ils sont comptés à la lecture
+		want := workers * rounds / len(posts)
+		if postID == posts[0] {
+			want++
+		}
+		if n := dbtest.Scalar[int](t, DB, `SELECT COUNT(*) FROM comments WHERE post_id = ?`, postID); n != want {
+			t.Errorf("post %s: %d comments, want %d", postID, n, want)
+		}
+	}
+
+	total := dbtest.Scalar[int](t, DB, `SELECT total_likes FROM comments WHERE id = ?`, comment)
+	rows := dbtest.Scalar[int](t, DB, `SELECT COUNT(*) FROM comment_interactions WHERE comment_id = ? AND interaction_type = 'like'`, comment)
+	if total != rows {
+		t.Errorf("comment: total_likes = %d, %d rows", total, rows)
+	}
+	// dernier tour : (i+rounds-1)%2 == 0 pour les utilisateurs d'indice impair
+	if rows != workers/2 {
+		t.Errorf("comment: %d likes, want %d", rows, workers/2)
+	}
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DriverSQLite   = "sqlite3"
+	DriverPostgres = "postgres"
+
+	DefaultPostgresMigrationsDir = "pkg/db/migrations/postgres"
+)
+
+// Config choisit le moteur de base de données et l'endroit où la trouver (voir pkg/config)
+type Config struct {
+	Driver        string // sqlite3 ou postgres
+	DSN           string // chemin du fichier SQLite ou URL de connexion Postgres
+	MigrationsDir string
+
+	// pragmas appliqués à chaque connexion SQLite (ignorés pour Postgres)
+	ForeignKeys bool
+	JournalMode string
+	BusyTimeout time.Duration
+	Synchronous string
+}
+
+// sqliteDSN ajoute les pragmas au chemin du fichier. Les transactions prennent le verrou
+// d'écriture dès BEGIN (_txlock=immediate) : une transaction concurrente attend busy_timeout
+// au lieu d'échouer avec "database is locked" au moment d'écrire
+func (c Config) sqliteDSN(foreignKeys bool) string {
+	params := url.Values{}
+	params.Set("_foreign_keys", strconv.FormatBool(foreignKeys))
+	params.Set("_busy_timeout", strconv.FormatInt(c.BusyTimeout.Milliseconds(), 10))
+	params.Set("_txlock", "immediate")
+	if c.JournalMode != "" {
+		params.Set("_journal_mode", c.JournalMode)
+	}
+	if c.Synchronous != "" {
+		params.Set("_synchronous", c.Synchronous)
+	}
+
+	sep := "?"
+	if strings.Contains(c.DSN, "?") {
+		sep = "&"
+	}
+	return c.DSN + sep + params.Encode()
+}
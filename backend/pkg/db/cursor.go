@@ -0,0 +1,92 @@
+package db
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor repère la dernière ligne d'une page dans une pagination par clé (created_at, id).
+// Le client le reçoit encodé dans next_cursor et le renvoie tel quel pour la page suivante
+type Cursor struct {
+	CreatedAt time.Time
+	ID        uuid.UUID
+}
+
+// IsZero indique l'absence de curseur : première page, ou plus de page suivante
+func (c Cursor) IsZero() bool {
+	return c.ID == uuid.Nil
+}
+
+// String encode le curseur de façon opaque, "" pour le curseur vide.
+// Le décalage horaire lu en base est conservé (voir timeArg)
+func (c Cursor) String() string {
+	if c.IsZero() {
+		return ""
+	}
+	raw := c.CreatedAt.Format(time.RFC3339Nano) + "|" + c.ID.String()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseCursor décode un curseur produit par String ; "" donne le curseur vide
+func ParseCursor(s string) (Cursor, error) {
+	if s == "" {
+		return Cursor{}, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	date, id, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return Cursor{}, ErrInvalidCursor
+	}
+	var c Cursor
+	if c.CreatedAt, err = time.Parse(time.RFC3339Nano, date); err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	if c.ID, err = uuid.FromString(id); err != nil || c.ID == uuid.Nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// timeArg formate la date du curseur comme elle a été stockée, pour que SQLite, qui compare
+// des chaînes, retrouve la ligne du curseur à l'identique : CURRENT_TIMESTAMP écrit
+// "2006-01-02 15:04:05" (UTC, sans fraction), le driver écrit les time.Time avec fraction et décalage.
+// Postgres accepte les deux formes
+func (c Cursor) timeArg() string {
+	if c.CreatedAt.Nanosecond() == 0 && c.CreatedAt.Location() == time.UTC {
+		return c.CreatedAt.Format(time.DateTime)
+	}
+	return c.CreatedAt.Format("2006-01-02 15:04:05.999999999-07:00")
+}
+
+// keysetAfter renvoie la condition SQL qui reprend après c une liste triée sur (dateCol, idCol),
+// par ordre décroissant si desc, et ses arguments. Pour le curseur vide la condition est toujours vraie
+func keysetAfter(c Cursor, dateCol, idCol string, desc bool) (string, []any) {
+	if c.IsZero() {
+		return "1 = 1", nil
+	}
+	op := ">"
+	if desc {
+		op = "<"
+	}
+	t := c.timeArg()
+	return "(" + dateCol + " " + op + " ? OR (" + dateCol + " = ? AND " + idCol + " " + op + " ?))", []any{t, t, c.ID.String()}
+}
+
+// pageOf garde les limit premières lignes d'une requête qui en a lu limit+1 et renvoie
+// le curseur de la dernière ligne gardée, vide quand il n'y a pas de page suivante
+func pageOf[T any](items []T, limit int, key func(T) Cursor) ([]T, Cursor) {
+	if len(items) <= limit {
+		return items, Cursor{}
+	}
+	items = items[:limit]
+	return items, key(items[limit-1])
+}
@@ -0,0 +1,164 @@
+// Package dbtest ouvre des bases migrées et jetables pour les tests : un fichier SQLite temporaire
+// avec les pragmas de la configuration par défaut, ou un schéma Postgres dédié quand
+// TEST_POSTGRES_DSN est défini
+package dbtest
+
+import (
+	"backend/pkg/config"
+	"backend/pkg/db"
+	"backend/pkg/models"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// PostgresDSNEnv désigne la base Postgres des tests ; sans elle les variantes Postgres sont ignorées
+const PostgresDSNEnv = "TEST_POSTGRES_DSN"
+
+// migrationsDir renvoie pkg/db/migrations/<driver>, quel que soit le dossier du test
+func migrationsDir(driver string) string {
+	_, file, _, _ := runtime.Caller(0)
+	name := "sqlite"
+	if driver == db.DriverPostgres {
+		name = "postgres"
+	}
+	return filepath.Join(filepath.Dir(file), "..", "migrations", name)
+}
+
+// SQLite ouvre une base SQLite neuve dans un dossier temporaire, migrée, fermée en fin de test
+func SQLite(t testing.TB) *db.DBStore {
+	t.Helper()
+	cfg := config.Default().Database.DB()
+	cfg.DSN = filepath.Join(t.TempDir(), "test.db")
+	return open(t, cfg)
+}
+
+// Postgres ouvre la base TEST_POSTGRES_DSN dans un schéma créé pour le test et supprimé ensuite.
+// Le test est ignoré si la variable n'est pas définie
+func Postgres(t testing.TB) *db.DBStore {
+	t.Helper()
+	dsn := os.Getenv(PostgresDSNEnv)
+	if dsn == "" {
+		t.Skipf("%s not set", PostgresDSNEnv)
+	}
+
+	admin, err := db.Open(db.Config{Driver: db.DriverPostgres, DSN: dsn})
+	if err != nil {
+		t.Fatal(err)
+	}
+	schema := "test_" + strings.ReplaceAll(uuid.Must(uuid.NewV4()).String(), "-", "")
+	if _, err := admin.DB().Exec(`CREATE SCHEMA ` + schema); err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if _, err := admin.DB().Exec(`DROP SCHEMA ` + schema + ` CASCADE`); err != nil {
+			t.Errorf("failed to drop schema %s: %v", schema, err)
+		}
+		admin.Close()
+	})
+
+	return open(t, db.Config{Driver: db.DriverPostgres, DSN: withSearchPath(dsn, schema)})
+}
+
+// Each lance fn sur SQLite puis sur Postgres (ignoré sans TEST_POSTGRES_DSN)
+func Each(t *testing.T, fn func(t *testing.T, store *db.DBStore)) {
+	t.Run("sqlite", func(t *testing.T) { fn(t, SQLite(t)) })
+	t.Run("postgres", func(t *testing.T) { fn(t, Postgres(t)) })
+}
+
+// withSearchPath ajoute search_path au DSN, en URL ou en "clé=valeur"
+func withSearchPath(dsn, schema string) string {
+	if u, err := url.Parse(dsn); err == nil && (u.Scheme == "postgres" || u.Scheme == "postgresql") {
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String()
+	}
+	return dsn + " search_path=" + schema
+}
+
+func open(t testing.TB, cfg db.Config) *db.DBStore {
+	t.Helper()
+	store, err := db.Open(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if err := store.Migrate(migrationsDir(cfg.Driver)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CheckSchema(); err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// User crée un utilisateur à l'email vérifié (mot de passe "password") et renvoie son identifiant
+func User(t testing.TB, store *db.DBStore, username string) uuid.UUID {
+	t.Helper()
+	id := uuid.Must(uuid.NewV4())
+	err := store.Users().Create(models.User{
+		ID:       id,
+		Username: username,
+		Email:    username + "@example.com",
+		Password: PasswordHash,
+		Role:     "user",
+	})
+	if err != nil {
+		t.Fatalf("failed to create user %s: %v", username, err)
+	}
+	if _, err := store.DB().Exec(`UPDATE users SET email_verified_at = ? WHERE id = ?`, time.Now(), id); err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+// PasswordHash est le hash bcrypt (coût minimal) de "password"
+const PasswordHash = "$2a$04$sABJkOWKQtpS53IjdEOk3OFzvFEeKYOrPaHsy2s9yhM230uwGg.Eq"
+
+// Post crée un post de authorID avec la visibilité donnée et renvoie son identifiant
+func Post(t testing.TB, store db.Store, authorID uuid.UUID, visibility string, allowed ...uuid.UUID) uuid.UUID {
+	t.Helper()
+	id, err := store.Posts().Create(models.Post{
+		UserID:       authorID,
+		Title:        visibility,
+		Content:      fmt.Sprintf("%s post", visibility),
+		Visibility:   visibility,
+		AllowedUsers: allowed,
+	})
+	if err != nil {
+		t.Fatalf("failed to create %s post: %v", visibility, err)
+	}
+	return id
+}
+
+// Comment ajoute un commentaire de userID sur postID et renvoie son identifiant
+func Comment(t testing.TB, store db.Store, postID, userID uuid.UUID, content string) uuid.UUID {
+	t.Helper()
+	id := uuid.Must(uuid.NewV4())
+	err := store.Comments().Create(models.Comment{ID: id, PostID: postID, UserID: userID, Content: content, CreatedAt: time.Now()})
+	if err != nil {
+		t.Fatalf("failed to create comment: %v", err)
+	}
+	return id
+}
+
+// Scalar lit une seule valeur, pour vérifier l'état de la base
+func Scalar[T any](t testing.TB, DB *sql.DB, query string, args ...any) T {
+	t.Helper()
+	var v T
+	if err := DB.QueryRow(query, args...).Scan(&v); err != nil {
+		t.Fatalf("%s: %v", query, err)
+	}
+	return v
+}
@@ -0,0 +1,79 @@
+package dbtest
+
+import (
+	"backend/pkg/db"
+	"slices"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+// Visibilities liste les visibilités de post, de la plus ouverte à la plus restreinte
+var Visibilities = []string{"public", "almost_private", "private"}
+
+// VisibilityMatrix indique, pour chaque relation avec l'auteur, les visibilités de post accessibles
+var VisibilityMatrix = map[string][]string{
+	"owner":        {"public", "almost_private", "private"},
+	"follower":     {"public", "private"},
+	"non_follower": {"public"},
+	"allowed":      {"public", "almost_private"},
+	"not_allowed":  {"public", "private"},
+	"anonymous":    {"public"},
+}
+
+// VisibilityFixture : un auteur, un post de chaque visibilité et un lecteur par relation avec l'auteur
+type VisibilityFixture struct {
+	Store    *db.DBStore
+	Author   uuid.UUID
+	Posts    map[string]uuid.UUID // visibilité -> post
+	Comments map[string]uuid.UUID // visibilité -> commentaire de l'auteur sur ce post
+	Viewers  map[string]uuid.UUID // relation -> lecteur, uuid.Nil pour l'anonyme
+	Names    map[uuid.UUID]string // lecteur -> nom d'utilisateur
+}
+
+// Visibility peuple une base SQLite neuve pour la matrice de visibilité
+func Visibility(t *testing.T) *VisibilityFixture {
+	t.Helper()
+	store := SQLite(t)
+	f := &VisibilityFixture{
+		Store:    store,
+		Posts:    map[string]uuid.UUID{},
+		Comments: map[string]uuid.UUID{},
+		Viewers:  map[string]uuid.UUID{"anonymous": uuid.Nil},
+		Names:    map[uuid.UUID]string{},
+	}
+	for _, name := range []string{"owner", "follower", "non_follower", "allowed", "not_allowed"} {
+		id := User(t, store, name)
+		f.Viewers[name], f.Names[id] = id, name
+	}
+	f.Author = f.Viewers["owner"]
+
+	if err := store.Follows().Follow(f.Viewers["follower"], f.Author); err != nil {
+		t.Fatal(err)
+	}
+	// not_allowed suit l'auteur mais n'est pas dans la liste du post almost_private
+	if err := store.Follows().Follow(f.Viewers["not_allowed"], f.Author); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, visibility := range Visibilities {
+		var allowed []uuid.UUID
+		if visibility == "almost_private" {
+			allowed = []uuid.UUID{f.Viewers["allowed"]}
+		}
+		f.Posts[visibility] = Post(t, store, f.Author, visibility, allowed...)
+		f.Comments[visibility] = Comment(t, store, f.Posts[visibility], f.Author, "comment on "+visibility)
+	}
+	return f
+}
+
+// EachCase parcourt toutes les paires (lecteur, visibilité) de la matrice
+func (f *VisibilityFixture) EachCase(t *testing.T, fn func(t *testing.T, viewer, postID uuid.UUID, visibility string, want bool)) {
+	for relation, visible := range VisibilityMatrix {
+		for _, visibility := range Visibilities {
+			t.Run(relation+"/"+visibility, func(t *testing.T) {
+				fn(t, f.Viewers[relation], f.Posts[visibility], visibility, slices.Contains(visible, visibility))
+			})
+		}
+	}
+}
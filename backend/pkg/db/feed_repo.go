@@ -0,0 +1,108 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+
+	"github.com/gofrs/uuid"
+)
+
+type feedRepo struct {
+	db *sql.DB
+}
+
+// Candidates renvoie, pour chaque source (posts puis posts de groupe), au plus q.Limit entrées
+// parmi les plus récentes. Les posts respectent postVisibleTo ; les posts de groupe viennent
+// des groupes dont le lecteur est créateur ou membre accepté
+func (r *feedRepo) Candidates(viewerID uuid.UUID, q FeedQuery) ([]models.FeedItem, error) {
+	posts, err := r.posts(viewerID, q)
+	if err != nil {
+		return nil, err
+	}
+	groupPosts, err := r.groupPosts(viewerID, q)
+	if err != nil {
+		return nil, err
+	}
+	return append(posts, groupPosts...), nil
+}
+
+func (r *feedRepo) posts(viewerID uuid.UUID, q FeedQuery) ([]models.FeedItem, error) {
+	query := `
+		SELECT p.id, p.user_id, u.username, u.avatar, p.title, p.content, COALESCE(p.image_path, ''), p.visibility, p.created_at,
+		       (SELECT COUNT(*) FROM post_interactions WHERE post_id = p.id AND interaction_type = 'like') AS total_likes,
+		       (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND ` + notHidden("comment", "c.id") + `) AS total_comments,
+		       EXISTS(SELECT 1 FROM post_interactions WHERE post_id = p.id AND user_id = ? AND interaction_type = 'like') AS liked_by_user
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE ` + postVisibleTo("p")
+	args := append([]any{viewerID}, visibleArgs(viewerID)...)
+	if !q.Discover {
+		query += ` AND (p.user_id = ? OR EXISTS (SELECT 1 FROM followers fo WHERE fo.followed_id = p.user_id AND fo.follower_id = ? AND fo.status = 'accepted'))`
+		args = append(args, viewerID, viewerID)
+	}
+	if !q.Since.IsZero() {
+		query += ` AND p.created_at >= ?`
+		args = append(args, q.Since)
+	}
+	keyset, keysetArgs := keysetAfter(q.After, "p.created_at", "p.id", true)
+	query += ` AND ` + keyset
+	args = append(args, keysetArgs...)
+	query += ` ORDER BY p.created_at DESC, p.id DESC LIMIT ?`
+
+	rows, err := r.db.Query(query, append(args, q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.FeedItem
+	for rows.Next() {
+		item := models.FeedItem{Type: "post"}
+		if err := rows.Scan(&item.ID, &item.UserID, &item.Username, &item.Avatar, &item.Title, &item.Content, &item.ImagePath,
+			&item.Visibility, &item.CreatedAt, &item.TotalLikes, &item.TotalComments, &item.LikedByUser); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// groupPosts ne dépend pas de q.Discover : un post de groupe n'est visible que des membres
+func (r *feedRepo) groupPosts(viewerID uuid.UUID, q FeedQuery) ([]models.FeedItem, error) {
+	query := `
+		SELECT gp.id, gp.user_id, u.username, u.avatar, gp.title, gp.content, g.id, g.name, gp.created_at,
+		       (SELECT COUNT(*) FROM group_posts_comments c WHERE c.post_id = gp.id AND ` + notHidden("group_comment", "c.id") + `) AS total_comments
+		FROM group_posts gp
+		JOIN groups g ON gp.group_id = g.id
+		JOIN users u ON gp.user_id = u.id
+		WHERE ` + notHidden("group_post", "gp.id") + `
+		AND (g.creator_id = ? OR EXISTS (SELECT 1 FROM group_members gm WHERE gm.group_id = g.id AND gm.user_id = ? AND gm.status = 'accepted'))`
+	args := []any{viewerID, viewerID}
+	if !q.Since.IsZero() {
+		query += ` AND gp.created_at >= ?`
+		args = append(args, q.Since)
+	}
+	keyset, keysetArgs := keysetAfter(q.After, "gp.created_at", "gp.id", true)
+	query += ` AND ` + keyset
+	args = append(args, keysetArgs...)
+	query += ` ORDER BY gp.created_at DESC, gp.id DESC LIMIT ?`
+
+	rows, err := r.db.Query(query, append(args, q.Limit)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.FeedItem
+	for rows.Next() {
+		item := models.FeedItem{Type: "group_post"}
+		var groupID uuid.UUID
+		if err := rows.Scan(&item.ID, &item.UserID, &item.Username, &item.Avatar, &item.Title, &item.Content,
+			&groupID, &item.GroupName, &item.CreatedAt, &item.TotalComments); err != nil {
+			return nil, err
+		}
+		item.GroupID = &groupID
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
@@ -0,0 +1,175 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+type followRepo struct {
+	db *sql.DB
+}
+
+// IsFollower indique si followerID suit userID avec un abonnement accepté
+func (r *followRepo) IsFollower(userID, followerID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM followers WHERE followed_id = ? AND follower_id = ? AND status = 'accepted')`, userID, followerID).Scan(&exists)
+	return exists, err
+}
+
+// IsFollowing indique s'il existe un lien d'abonnement, quel que soit son statut
+func (r *followRepo) IsFollowing(followerID, followedID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM followers WHERE follower_id = ? AND followed_id = ?)`, followerID, followedID).Scan(&exists)
+	return exists, err
+}
+
+func (r *followRepo) Followers(userID uuid.UUID) ([]models.SimpleUser, error) {
+	return r.simpleUsers(`SELECT u.id, u.username
+			  FROM users u
+			  INNER JOIN followers f ON u.id = f.follower_id
+			  WHERE f.followed_id = ? AND f.status = 'accepted'`, userID)
+}
+
+func (r *followRepo) Following(userID uuid.UUID) ([]models.SimpleUser, error) {
+	return r.simpleUsers(`SELECT u.id, u.username
+			  FROM users u
+			  INNER JOIN followers f ON u.id = f.followed_id
+			  WHERE f.follower_id = ? AND f.status = 'accepted'`, userID)
+}
+
+func (r *followRepo) simpleUsers(query string, args ...interface{}) ([]models.SimpleUser, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.SimpleUser
+	for rows.Next() {
+		var user models.SimpleUser
+		if err := rows.Scan(&user.UserID, &user.Username); err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+	return users, rows.Err()
+}
+
+// Friends renvoie une page des utilisateurs suivis par userID
+func (r *followRepo) Friends(userID uuid.UUID, limit, offset int) ([]models.UserSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT u.id, u.username, COALESCE(u.avatar, '')
+		FROM users u
+		INNER JOIN followers f ON f.followed_id = u.id
+		WHERE f.follower_id = ?
+		LIMIT ? OFFSET ?`, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var friends []models.UserSummary
+	for rows.Next() {
+		var u models.UserSummary
+		if err := rows.Scan(&u.ID, &u.Username, &u.Avatar); err != nil {
+			return nil, err
+		}
+		u.IsFollowing = true
+		friends = append(friends, u)
+	}
+	return friends, rows.Err()
+}
+
+func (r *followRepo) Follow(followerID, followedID uuid.UUID) error {
+	_, err := r.db.Exec(`INSERT INTO followers (id, follower_id, followed_id, status) VALUES (?, ?, ?, 'accepted')`,
+		uuid.Must(uuid.NewV4()), followerID, followedID)
+	return err
+}
+
+// Unfollow supprime l'abonnement ainsi qu'une éventuelle demande en attente
+func (r *followRepo) Unfollow(followerID, followedID uuid.UUID) error {
+	res, err := r.db.Exec(`DELETE FROM followers WHERE follower_id = ? AND followed_id = ?`, followerID, followedID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	_, err = r.db.Exec(`DELETE FROM follow_requests WHERE sender_id = ? AND receiver_id = ?`, followerID, followedID)
+	return err
+}
+
+func (r *followRepo) RequestExists(senderID, receiverID uuid.UUID) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM follow_requests WHERE sender_id = ? AND receiver_id = ?)`, senderID, receiverID).Scan(&exists)
+	return exists, err
+}
+
+func (r *followRepo) CreateRequest(senderID, receiverID uuid.UUID) error {
+	_, err := r.db.Exec(`INSERT INTO follow_requests (id, sender_id, receiver_id) VALUES (?, ?, ?)`,
+		uuid.Must(uuid.NewV4()), senderID, receiverID)
+	return err
+}
+
+// PendingRequests renvoie les demandes d'abonnement reçues par receiverID
+func (r *followRepo) PendingRequests(receiverID uuid.UUID) ([]models.PendingFollowRequest, error) {
+	rows, err := r.db.Query(`
+		SELECT fr.id, fr.sender_id, u.username, COALESCE(u.avatar, '')
+		FROM follow_requests fr
+		JOIN users u ON fr.sender_id = u.id
+		WHERE fr.receiver_id = ?`, receiverID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var requests []models.PendingFollowRequest
+	for rows.Next() {
+		var req models.PendingFollowRequest
+		if err := rows.Scan(&req.ID, &req.SenderID, &req.Username, &req.Avatar); err != nil {
+			return nil, err
+		}
+		requests = append(requests, req)
+	}
+	return requests, rows.Err()
+}
+
+func (r *followRepo) Request(requestID uuid.UUID) (senderID, receiverID uuid.UUID, err error) {
+	err = r.db.QueryRow(`SELECT sender_id, receiver_id FROM follow_requests WHERE id = ?`, requestID).Scan(&senderID, &receiverID)
+	return senderID, receiverID, notFound(err)
+}
+
+// AcceptRequest transforme la demande en abonnement accepté dans une seule transaction,
+// ErrAlreadyExists si l'abonnement existe déjà
+func (r *followRepo) AcceptRequest(requestID, senderID, receiverID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRow(`SELECT EXISTS (SELECT 1 FROM followers WHERE follower_id = ? AND followed_id = ?)`, senderID, receiverID).Scan(&exists); err != nil {
+		return err
+	}
+	if exists {
+		return ErrAlreadyExists
+	}
+
+	if _, err := tx.Exec(`INSERT INTO followers (id, follower_id, followed_id, status) VALUES (?, ?, ?, 'accepted')`,
+		uuid.Must(uuid.NewV4()), senderID, receiverID); err != nil {
+		return fmt.Errorf("failed to insert follower: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM follow_requests WHERE id = ?`, requestID); err != nil {
+		return fmt.Errorf("failed to delete follow request: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *followRepo) DeleteRequest(requestID uuid.UUID) error {
+	_, err := r.db.Exec(`DELETE FROM follow_requests WHERE id = ?`, requestID)
+	return err
+}
@@ -0,0 +1,351 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+)
+
+type groupRepo struct {
+	db *sql.DB
+}
+
+// Create insère le groupe et ajoute son créateur comme membre accepté, dans une seule transaction
+func (r *groupRepo) Create(group models.Group) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`INSERT INTO groups (id, name, description, creator_id) VALUES (?, ?, ?, ?)`,
+		group.ID, group.Name, group.Description, group.CreatorID); err != nil {
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+
+	// ajouter le créateur comme membre du groupe avec le rôle de "creator"
+	if _, err := tx.Exec(`INSERT INTO group_members (id, group_id, user_id, status, role) VALUES (?, ?, ?, 'accepted', 'creator')`,
+		uuid.Must(uuid.NewV4()), group.ID, group.CreatorID); err != nil {
+		return fmt.Errorf("failed to add group creator as member: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *groupRepo) Get(groupID string) (models.Group, error) {
+	var group models.Group
+	err := r.db.QueryRow(`SELECT id, name, description, creator_id, created_at FROM groups WHERE id = ?`, groupID).
+		Scan(&group.ID, &group.Name, &group.Description, &group.CreatorID, &group.CreatedAt)
+	return group, notFound(err)
+}
+
+// Creator renvoie le créateur du groupe, ErrNotFound si le groupe n'existe pas
+func (r *groupRepo) Creator(groupID uuid.UUID) (uuid.UUID, error) {
+	var creatorID uuid.UUID
+	err := r.db.QueryRow(`SELECT creator_id FROM groups WHERE id = ?`, groupID).Scan(&creatorID)
+	return creatorID, notFound(err)
+}
+
+// ListForMember renvoie au plus limit groupes dont userID est membre accepté, du plus récent au plus ancien,
+// à partir de after, et le curseur de la page suivante
+func (r *groupRepo) ListForMember(userID uuid.UUID, after Cursor, limit int) ([]models.Group, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "g.created_at", "g.id", true)
+	args := append([]any{userID}, keysetArgs...)
+	rows, err := r.db.Query(`
+		SELECT g.id, g.name, g.description, g.created_at
+		FROM groups g
+		JOIN group_members gm ON g.id = gm.group_id
+		WHERE gm.user_id = ? AND gm.status = 'accepted' AND `+keyset+`
+		ORDER BY g.created_at DESC, g.id DESC
+		LIMIT ?`, append(args, limit+1)...)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+	defer rows.Close()
+
+	groups := []models.Group{}
+	for rows.Next() {
+		var group models.Group
+		if err := rows.Scan(&group.ID, &group.Name, &group.Description, &group.CreatedAt); err != nil {
+			return nil, Cursor{}, err
+		}
+		groups = append(groups, group)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Cursor{}, err
+	}
+	groups, next := pageOf(groups, limit, func(g models.Group) Cursor { return Cursor{g.CreatedAt, g.ID} })
+	return groups, next, nil
+}
+
+func (r *groupRepo) MembershipCount(userID uuid.UUID) (int, error) {
+	var count int
+	err := r.db.QueryRow(`SELECT COUNT(*) FROM group_members WHERE user_id = ? AND status = 'accepted'`, userID).Scan(&count)
+	return count, err
+}
+
+func (r *groupRepo) Members(groupID string) ([]models.GroupMember, error) {
+	rows, err := r.db.Query(`
+		SELECT gm.user_id, u.username, u.avatar, gm.role, gm.status
+		FROM group_members gm
+		INNER JOIN users u ON gm.user_id = u.id
+		WHERE gm.group_id = ?`, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.GroupMember
+	for rows.Next() {
+		var member models.GroupMember
+		if err := rows.Scan(&member.UserID, &member.Username, &member.Avatar, &member.Role, &member.Status); err != nil {
+			return nil, err
+		}
+		members = append(members, member)
+	}
+	return members, rows.Err()
+}
+
+// MemberStatus renvoie le statut (pending/accepted) de userID dans le groupe, ErrNotFound s'il n'y figure pas
+func (r *groupRepo) MemberStatus(groupID, userID uuid.UUID) (string, error) {
+	var status string
+	err := r.db.QueryRow(`SELECT status FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID).Scan(&status)
+	return status, notFound(err)
+}
+
+// InvitedBy renvoie l'auteur de l'invitation de userID, uuid.Nil pour une demande d'adhésion et ErrNotFound sans ligne
+func (r *groupRepo) InvitedBy(groupID, userID uuid.UUID) (uuid.UUID, error) {
+	var inviterID uuid.NullUUID
+	err := r.db.QueryRow(`SELECT invited_by FROM group_members WHERE group_id = ? AND user_id = ?`, groupID, userID).Scan(&inviterID)
+	return inviterID.UUID, notFound(err)
+}
+
+// AddPendingMember enregistre une demande d'adhésion de userID
+func (r *groupRepo) AddPendingMember(groupID, userID uuid.UUID) error {
+	_, err := r.db.Exec(`INSERT INTO group_members (id, group_id, user_id, role, status) VALUES (?, ?, ?, 'member', 'pending')`,
+		uuid.Must(uuid.NewV4()), groupID, userID)
+	return err
+}
+
+// InviteMember enregistre l'invitation de userID par inviterID
+func (r *groupRepo) InviteMember(groupID, userID, inviterID uuid.UUID) error {
+	_, err := r.db.Exec(`INSERT INTO group_members (id, group_id, user_id, role, status, invited_by) VALUES (?, ?, ?, 'member', 'pending', ?)`,
+		uuid.Must(uuid.NewV4()), groupID, userID, inviterID)
+	return err
+}
+
+func (r *groupRepo) AcceptMember(groupID, userID uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE group_members SET status = 'accepted' WHERE group_id = ? AND user_id = ?`, groupID, userID)
+	return err
+}
+
+func (r *groupRepo) CreatePost(post models.PostGroup) error {
+	_, err := r.db.Exec(`INSERT INTO group_posts (id, group_id, user_id, title, content, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		post.ID, post.GroupID, post.UserID, post.Title, post.Content, post.CreatedAt, post.UpdatedAt)
+	return err
+}
+
+func (r *groupRepo) PostGroupID(postID uuid.UUID) (uuid.UUID, error) {
+	var groupID uuid.UUID
+	err := r.db.QueryRow(`SELECT group_id FROM group_posts WHERE id = ?`, postID).Scan(&groupID)
+	return groupID, notFound(err)
+}
+
+// AllPosts renvoie tous les posts visibles du groupe (page du groupe)
+func (r *groupRepo) AllPosts(groupID string) ([]models.PostGroup, error) {
+	rows, err := r.db.Query(`SELECT id, group_id, user_id, title, content, created_at, updated_at FROM group_posts WHERE group_id = ? AND `+notHidden("group_post", "group_posts.id"), groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.PostGroup
+	for rows.Next() {
+		var post models.PostGroup
+		if err := rows.Scan(&post.ID, &post.GroupID, &post.UserID, &post.Title, &post.Content, &post.CreatedAt, &post.UpdatedAt); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func (r *groupRepo) ListPosts(groupID uuid.UUID, limit, offset int) ([]models.PostGroup, error) {
+	rows, err := r.db.Query(`
+		SELECT gp.id, gp.group_id, gp.user_id, u.username, gp.title, gp.content, gp.created_at, gp.updated_at
+		FROM group_posts gp
+		JOIN users u ON gp.user_id = u.id
+		WHERE gp.group_id = ? AND `+notHidden("group_post", "gp.id")+`
+		LIMIT ? OFFSET ?`, groupID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.PostGroup
+	for rows.Next() {
+		var post models.PostGroup
+		if err := rows.Scan(&post.ID, &post.GroupID, &post.UserID, &post.Username, &post.Title, &post.Content, &post.CreatedAt, &post.UpdatedAt); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+func (r *groupRepo) CreateComment(comment models.CommentPostGroup) error {
+	_, err := r.db.Exec(`INSERT INTO group_posts_comments (id, post_id, content, user_id, username, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		comment.ID, comment.PostID, comment.Content, comment.UserID, comment.Username, comment.CreatedAt)
+	return err
+}
+
+func (r *groupRepo) ListComments(postID uuid.UUID, limit, offset int) ([]models.CommentPostGroup, error) {
+	rows, err := r.db.Query(`
+		SELECT c.id, c.post_id, c.content, c.user_id, u.username, c.created_at
+		FROM group_posts_comments AS c
+		INNER JOIN users AS u ON c.user_id = u.id
+		WHERE c.post_id = ? AND `+notHidden("group_comment", "c.id")+`
+		ORDER BY c.created_at DESC
+		LIMIT ? OFFSET ?`, postID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var comments []models.CommentPostGroup
+	for rows.Next() {
+		var comment models.CommentPostGroup
+		if err := rows.Scan(&comment.ID, &comment.PostID, &comment.Content, &comment.UserID, &comment.Username, &comment.CreatedAt); err != nil {
+			return nil, err
+		}
+		comments = append(comments, comment)
+	}
+	return comments, rows.Err()
+}
+
+func (r *groupRepo) CreateEvent(event models.GroupEvent) error {
+	_, err := r.db.Exec(`INSERT INTO group_events (id, group_id, user_id, title, description, event_date) VALUES (?, ?, ?, ?, ?, ?)`,
+		event.ID, event.GroupID, event.UserID, event.Title, event.Description, event.EventDate)
+	return err
+}
+
+func (r *groupRepo) EventGroupID(eventID uuid.UUID) (uuid.UUID, error) {
+	var groupID uuid.UUID
+	err := r.db.QueryRow(`SELECT group_id FROM group_events WHERE id = ?`, eventID).Scan(&groupID)
+	return groupID, notFound(err)
+}
+
+// ListEvents renvoie au plus limit événements du groupe, du plus récemment créé au plus ancien,
+// à partir de after, et le curseur de la page suivante
+func (r *groupRepo) ListEvents(groupID uuid.UUID, after Cursor, limit int) ([]models.GroupEvent, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "created_at", "id", true)
+	args := append([]any{groupID}, keysetArgs...)
+	rows, err := r.db.Query(`SELECT id, group_id, user_id, title, description, event_date, created_at FROM group_events
+		WHERE group_id = ? AND `+keyset+`
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?`, append(args, limit+1)...)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+	defer rows.Close()
+
+	events := []models.GroupEvent{}
+	for rows.Next() {
+		var event models.GroupEvent
+		if err := rows.Scan(&event.ID, &event.GroupID, &event.UserID, &event.Title, &event.Description, &event.EventDate, &event.CreatedAt); err != nil {
+			return nil, Cursor{}, err
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Cursor{}, err
+	}
+	events, next := pageOf(events, limit, func(e models.GroupEvent) Cursor { return Cursor{e.CreatedAt, e.ID} })
+	return events, next, nil
+}
+
+// RespondToEvent enregistre le vote de userID : un vote identique l'annule, un vote différent le remplace.
+// Les compteurs stockés dans group_events.options sont recalculés dans la même transaction
+func (r *groupRepo) RespondToEvent(eventID, userID uuid.UUID, response string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var existing string
+	err = tx.QueryRow(`SELECT response FROM event_responses WHERE event_id = ? AND user_id = ?`, eventID, userID).Scan(&existing)
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.Exec(`INSERT INTO event_responses (id, event_id, user_id, response) VALUES (?, ?, ?, ?)`,
+			uuid.Must(uuid.NewV4()), eventID, userID, response)
+	case err != nil:
+		return err
+	case existing == response:
+		_, err = tx.Exec(`DELETE FROM event_responses WHERE event_id = ? AND user_id = ?`, eventID, userID)
+	default:
+		_, err = tx.Exec(`UPDATE event_responses SET response = ? WHERE event_id = ? AND user_id = ?`, response, eventID, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record vote: %w", err)
+	}
+
+	// le JSON est reconstruit ici plutôt qu'avec JSON_SET/jsonb_set, pour rester portable entre SQLite et Postgres
+	var raw sql.NullString
+	if err := tx.QueryRow(`SELECT options FROM group_events WHERE id = ?`, eventID).Scan(&raw); err != nil {
+		return fmt.Errorf("failed to read vote counts: %w", err)
+	}
+	options := map[string]interface{}{}
+	if raw.Valid && raw.String != "" {
+		if err := json.Unmarshal([]byte(raw.String), &options); err != nil {
+			return fmt.Errorf("invalid event options: %w", err)
+		}
+	}
+
+	for key, value := range map[string]string{"Going": "Going", "NotGoing": "Not going"} {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM event_responses WHERE event_id = ? AND response = ?`, eventID, value).Scan(&count); err != nil {
+			return fmt.Errorf("failed to count votes: %w", err)
+		}
+		options[key] = count
+	}
+
+	encoded, err := json.Marshal(options)
+	if err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`UPDATE group_events SET options = ? WHERE id = ?`, string(encoded), eventID); err != nil {
+		return fmt.Errorf("failed to update vote counts: %w", err)
+	}
+	return tx.Commit()
+}
+
+func (r *groupRepo) InviteToEvent(eventID, userID uuid.UUID) error {
+	_, err := r.db.Exec(`INSERT INTO event_invitations (event_id, user_id) VALUES (?, ?) ON CONFLICT DO NOTHING`, eventID, userID)
+	return err
+}
+
+// UserVotes renvoie les votes de userID pour les événements du groupe
+func (r *groupRepo) UserVotes(userID, groupID uuid.UUID) ([]models.EventResponse, error) {
+	rows, err := r.db.Query(`
+		SELECT event_id, response FROM event_responses
+		WHERE user_id = ? AND event_id IN (
+			SELECT id FROM group_events WHERE group_id = ?
+		)`, userID, groupID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var votes []models.EventResponse
+	for rows.Next() {
+		vote := models.EventResponse{UserID: userID}
+		if err := rows.Scan(&vote.EventID, &vote.Response); err != nil {
+			return nil, err
+		}
+		votes = append(votes, vote)
+	}
+	return votes, rows.Err()
+}
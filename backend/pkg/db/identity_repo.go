@@ -0,0 +1,110 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type identityRepo struct {
+	db *sql.DB
+}
+
+// Identity est un compte chez un fournisseur OAuth, à rattacher à un compte local
+type Identity struct {
+	Provider  string
+	Subject   string // identifiant stable chez le fournisseur
+	Email     string // adresse vérifiée par le fournisseur
+	Username  string // nom proposé pour un nouveau compte
+	FirstName string
+	LastName  string
+}
+
+// User renvoie le compte lié à l'identité et note la connexion, ErrNotFound si elle n'est liée à aucun compte
+func (r *identityRepo) User(provider, subject string, now time.Time) (uuid.UUID, string, error) {
+	var userID uuid.UUID
+	var username string
+	err := r.db.QueryRow(`
+		SELECT u.id, u.username
+		FROM user_identities ui
+		JOIN users u ON u.id = ui.user_id
+		WHERE ui.provider = ? AND ui.subject = ?`, provider, subject).Scan(&userID, &username)
+	if err != nil {
+		return uuid.Nil, "", notFound(err)
+	}
+	_, err = r.db.Exec(`UPDATE user_identities SET last_login_at = ? WHERE provider = ? AND subject = ?`, now, provider, subject)
+	return userID, username, err
+}
+
+// Link rattache l'identité au compte de même email, ou à un nouveau compte sans mot de passe s'il n'y en a pas
+// (created vaut alors vrai). ErrEmailNotVerified si le compte existant n'a pas confirmé son adresse
+func (r *identityRepo) Link(identity Identity, now time.Time) (userID uuid.UUID, username string, created bool, err error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, "", false, err
+	}
+	defer tx.Rollback()
+
+	var verifiedAt sql.NullTime
+	err = tx.QueryRow(`SELECT id, username, email_verified_at FROM users WHERE LOWER(email) = LOWER(?)`, identity.Email).
+		Scan(&userID, &username, &verifiedAt)
+	switch {
+	case err == sql.ErrNoRows:
+		created = true
+		userID = uuid.Must(uuid.NewV4())
+		username, err = availableUsername(tx, identity.Username)
+		if err != nil {
+			return uuid.Nil, "", false, err
+		}
+		_, err = tx.Exec(`INSERT INTO users
+			(id, username, age, email, password_hash, first_name, last_name, role, gender, is_private, email_verified_at, created_at, updated_at)
+			VALUES (?, ?, 0, ?, '', ?, ?, 'user', '', false, ?, ?, ?)`,
+			userID, username, identity.Email,
+			sql.NullString{String: identity.FirstName, Valid: identity.FirstName != ""},
+			sql.NullString{String: identity.LastName, Valid: identity.LastName != ""},
+			now, now, now)
+		if err != nil {
+			return uuid.Nil, "", false, fmt.Errorf("failed to create user: %w", err)
+		}
+	case err != nil:
+		return uuid.Nil, "", false, fmt.Errorf("failed to query user by email: %w", err)
+	case !verifiedAt.Valid:
+		return uuid.Nil, "", false, ErrEmailNotVerified
+	}
+
+	_, err = tx.Exec(`INSERT INTO user_identities (id, user_id, provider, subject, email, created_at, last_login_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		uuid.Must(uuid.NewV4()), userID, identity.Provider, identity.Subject, identity.Email, now, now)
+	if err != nil {
+		return uuid.Nil, "", false, fmt.Errorf("failed to link identity: %w", err)
+	}
+	return userID, username, created, tx.Commit()
+}
+
+var usernameCleaner = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// availableUsername dérive un nom d'utilisateur libre à partir de celui proposé par le fournisseur
+func availableUsername(tx *sql.Tx, wanted string) (string, error) {
+	base := usernameCleaner.ReplaceAllString(wanted, "")
+	if base == "" {
+		base = "user"
+	}
+	if len(base) > 40 {
+		base = base[:40]
+	}
+
+	candidate := base
+	for i := 1; i < 100; i++ {
+		var count int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM users WHERE username = ?`, candidate).Scan(&count); err != nil {
+			return "", fmt.Errorf("failed to check username existence: %w", err)
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s%d", base, i)
+	}
+	return "", fmt.Errorf("no available username for %q", wanted)
+}
@@ -0,0 +1,95 @@
+package db
+
+import (
+	"database/sql"
+
+	"github.com/gofrs/uuid"
+)
+
+// interactionTable décrit où sont rangées les réactions ("like", "unlike") d'un type de contenu
+// et quelle table porte ses compteurs total_likes et total_unlikes
+type interactionTable struct {
+	table        string // table des réactions
+	column       string // colonne désignant le contenu dans table
+	counterTable string // table du contenu, qui porte les compteurs
+}
+
+var (
+	postInteractions    = interactionTable{"post_interactions", "post_id", "posts"}
+	commentInteractions = interactionTable{"comment_interactions", "comment_id", "comments"}
+)
+
+// set pose (liked) ou retire le like de userID. L'opération est idempotente :
+// total_likes ne change que si une ligne a réellement été ajoutée ou supprimée
+func (t interactionTable) set(db *sql.DB, targetID, userID uuid.UUID, liked bool) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if liked {
+		_, err = t.add(tx, targetID, userID, "like")
+	} else {
+		_, err = t.remove(tx, targetID, userID, "like")
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// toggle applique la bascule des routes historiques : un like existant est toujours retiré,
+// sinon "like" ajoute un like et "unlike" ajoute ou retire le unlike
+func (t interactionTable) toggle(db *sql.DB, targetID, userID uuid.UUID, reaction string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	removed, err := t.remove(tx, targetID, userID, "like")
+	if err == nil && !removed {
+		switch reaction {
+		case "like":
+			_, err = t.add(tx, targetID, userID, "like")
+		default:
+			if removed, err = t.remove(tx, targetID, userID, "unlike"); err == nil && !removed {
+				_, err = t.add(tx, targetID, userID, "unlike")
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// add insère la réaction si elle n'existe pas encore et incrémente son compteur
+func (t interactionTable) add(tx *sql.Tx, targetID, userID uuid.UUID, kind string) (bool, error) {
+	res, err := tx.Exec(`INSERT INTO `+t.table+` (id, `+t.column+`, user_id, interaction_type) VALUES (?, ?, ?, ?)
+		ON CONFLICT (`+t.column+`, user_id, interaction_type) DO NOTHING`, uuid.Must(uuid.NewV4()), targetID, userID, kind)
+	return t.count(tx, res, err, targetID, kind, "+")
+}
+
+// remove supprime la réaction si elle existe et décrémente son compteur
+func (t interactionTable) remove(tx *sql.Tx, targetID, userID uuid.UUID, kind string) (bool, error) {
+	res, err := tx.Exec(`DELETE FROM `+t.table+` WHERE `+t.column+` = ? AND user_id = ? AND interaction_type = ?`, targetID, userID, kind)
+	return t.count(tx, res, err, targetID, kind, "-")
+}
+
+// count répercute sur total_likes ou total_unlikes une écriture qui a modifié une ligne
+func (t interactionTable) count(tx *sql.Tx, res sql.Result, err error, targetID uuid.UUID, kind, op string) (bool, error) {
+	if err != nil {
+		return false, err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return false, err
+	}
+	counter := "total_likes"
+	if kind == "unlike" {
+		counter = "total_unlikes"
+	}
+	_, err = tx.Exec(`UPDATE `+t.counterTable+` SET `+counter+` = `+counter+` `+op+` 1 WHERE id = ?`, targetID)
+	return err == nil, err
+}
@@ -0,0 +1,236 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang-migrate/migrate/v4"
+	"github.com/mattn/go-sqlite3"
+)
+
+var ErrUnsupported = errors.New("operation not supported by this database driver")
+
+// MigrationStatus décrit une migration du dossier et si elle est appliquée
+type MigrationStatus struct {
+	Version uint
+	Name    string
+	Applied bool
+}
+
+// MigrateDown annule les steps dernières migrations
+func (s *DBStore) MigrateDown(dir string, steps int) error {
+	m, done, err := s.migrator(dir)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := m.Steps(-steps); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to revert migrations: %w", err)
+	}
+	return nil
+}
+
+// MigrateTo amène la base exactement à la version donnée, en montant ou en descendant
+func (s *DBStore) MigrateTo(dir string, version uint) error {
+	m, done, err := s.migrator(dir)
+	if err != nil {
+		return err
+	}
+	defer done()
+	if err := m.Migrate(version); err != nil && err != migrate.ErrNoChange {
+		return fmt.Errorf("failed to migrate to %d: %w", version, err)
+	}
+	return nil
+}
+
+// MigrationStatus renvoie la version courante, l'état dirty et la liste des migrations du dossier
+func (s *DBStore) MigrationStatus(dir string) (uint, bool, []MigrationStatus, error) {
+	m, done, err := s.migrator(dir)
+	if err != nil {
+		return 0, false, nil, err
+	}
+	defer done()
+	current, dirty, err := m.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return 0, false, nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, false, nil, err
+	}
+	var list []MigrationStatus
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".up.sql")
+		if !ok {
+			continue
+		}
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.ParseUint(prefix, 10, 64)
+		if err != nil {
+			continue
+		}
+		list = append(list, MigrationStatus{
+			Version: uint(version),
+			Name:    name,
+			Applied: current != 0 && uint(version) <= current,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return current, dirty, list, nil
+}
+
+// Backup copie la base SQLite vers dest avec l'API de sauvegarde en ligne :
+// la copie est cohérente même si le serveur écrit pendant l'opération
+func (s *DBStore) Backup(dest string) error {
+	if s.driver != DriverSQLite {
+		return ErrUnsupported
+	}
+	if _, err := os.Stat(dest); err == nil {
+		return fmt.Errorf("%s already exists", dest)
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+
+	destDB, err := sql.Open(DriverSQLite, dest)
+	if err != nil {
+		return err
+	}
+	defer destDB.Close()
+
+	return copySQLite(destDB, s.db)
+}
+
+// Restore remplace le contenu de la base par celui de src, après avoir vérifié son intégrité.
+// À lancer serveur arrêté
+func (s *DBStore) Restore(src string) error {
+	if s.driver != DriverSQLite {
+		return ErrUnsupported
+	}
+	if _, err := os.Stat(src); err != nil {
+		return err
+	}
+
+	srcDB, err := sql.Open(DriverSQLite, "file:"+src+"?mode=ro")
+	if err != nil {
+		return err
+	}
+	defer srcDB.Close()
+
+	// seule la corruption bloque : les violations de clés étrangères sont déjà présentes dans la base courante
+	if problems, err := integrityProblems(srcDB); err != nil {
+		return err
+	} else if len(problems) > 0 {
+		return fmt.Errorf("refusing to restore %s, integrity check failed: %s", src, strings.Join(problems, "; "))
+	}
+
+	return copySQLite(s.db, srcDB)
+}
+
+// Vacuum reconstruit le fichier pour récupérer l'espace libéré par les suppressions
+func (s *DBStore) Vacuum() error {
+	_, err := s.db.Exec(`VACUUM`)
+	return err
+}
+
+// Check lance integrity_check et foreign_key_check, et renvoie les problèmes trouvés
+func (s *DBStore) Check() ([]string, error) {
+	if s.driver != DriverSQLite {
+		return nil, ErrUnsupported
+	}
+	problems, err := integrityProblems(s.db)
+	if err != nil {
+		return nil, err
+	}
+	violations, err := foreignKeyProblems(s.db)
+	if err != nil {
+		return nil, err
+	}
+	return append(problems, violations...), nil
+}
+
+func integrityProblems(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			return nil, err
+		}
+		if line != "ok" {
+			problems = append(problems, line)
+		}
+	}
+	return problems, rows.Err()
+}
+
+func foreignKeyProblems(db *sql.DB) ([]string, error) {
+	rows, err := db.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var problems []string
+	for rows.Next() {
+		var table, parent string
+		var rowid sql.NullInt64
+		var fkid int
+		if err := rows.Scan(&table, &rowid, &parent, &fkid); err != nil {
+			return nil, err
+		}
+		problems = append(problems, fmt.Sprintf("foreign key violation: %s row %d references missing %s", table, rowid.Int64, parent))
+	}
+	return problems, rows.Err()
+}
+
+// copySQLite copie toute la base src dans dest, page par page
+func copySQLite(dest, src *sql.DB) error {
+	ctx := context.Background()
+	destConn, err := dest.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer destConn.Close()
+	srcConn, err := src.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer srcConn.Close()
+
+	return destConn.Raw(func(d interface{}) error {
+		return srcConn.Raw(func(s interface{}) error {
+			destSQLite, ok := d.(*sqlite3.SQLiteConn)
+			if !ok {
+				return ErrUnsupported
+			}
+			srcSQLite, ok := s.(*sqlite3.SQLiteConn)
+			if !ok {
+				return ErrUnsupported
+			}
+
+			backup, err := destSQLite.Backup("main", srcSQLite, "main")
+			if err != nil {
+				return fmt.Errorf("failed to start backup: %w", err)
+			}
+			if _, err := backup.Step(-1); err != nil {
+				backup.Finish()
+				return fmt.Errorf("failed to copy database: %w", err)
+			}
+			return backup.Finish()
+		})
+	})
+}
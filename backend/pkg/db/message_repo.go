@@ -0,0 +1,81 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+
+	"github.com/gofrs/uuid"
+)
+
+type messageRepo struct {
+	db *sql.DB
+}
+
+func (r *messageRepo) SavePrivate(msg models.Message) error {
+	return r.save("chatHistory", msg)
+}
+
+func (r *messageRepo) SaveGroup(msg models.Message) error {
+	return r.save("chatGroup", msg)
+}
+
+func (r *messageRepo) save(table string, msg models.Message) error {
+	_, err := r.db.Exec(`INSERT INTO `+table+` (id, sender_username, target_username, content, timestamp, type, emoji) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		msg.ID.String(), msg.SenderUsername, msg.TargetUsername, msg.Content, msg.Timestamp, msg.Type, msg.Emoji)
+	return err
+}
+
+// PrivateHistory renvoie au plus limit messages privés envoyés ou reçus par username, les plus récents d'abord,
+// à partir de after, et le curseur de la page suivante
+func (r *messageRepo) PrivateHistory(username string, after Cursor, limit int) ([]models.Message, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "timestamp", "id", true)
+	args := append([]any{username, username}, keysetArgs...)
+	return r.history(`
+		SELECT id, sender_username, target_username, content, timestamp, type, emoji
+		FROM chatHistory
+		WHERE (sender_username = ? OR target_username = ?) AND `+notHidden("private_message", "chatHistory.id")+` AND `+keyset+`
+		ORDER BY timestamp DESC, id DESC
+		LIMIT ?`,
+		limit, append(args, limit+1)...)
+}
+
+// GroupHistory renvoie au plus limit messages adressés au groupe target, dans l'ordre chronologique,
+// à partir de after, et le curseur de la page suivante
+func (r *messageRepo) GroupHistory(target string, after Cursor, limit int) ([]models.Message, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "timestamp", "id", false)
+	args := append([]any{target}, keysetArgs...)
+	return r.history(`
+		SELECT id, sender_username, target_username, content, timestamp, type, emoji
+		FROM chatGroup
+		WHERE target_username = ? AND `+notHidden("group_message", "chatGroup.id")+` AND `+keyset+`
+		ORDER BY timestamp ASC, id ASC
+		LIMIT ?`,
+		limit, append(args, limit+1)...)
+}
+
+// history lit une page de messages ; la requête en demande limit+1 pour savoir s'il reste une page
+func (r *messageRepo) history(query string, limit int, args ...interface{}) ([]models.Message, Cursor, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+	defer rows.Close()
+
+	var messages []models.Message
+	for rows.Next() {
+		var msg models.Message
+		var id string
+		var emoji sql.NullString
+		if err := rows.Scan(&id, &msg.SenderUsername, &msg.TargetUsername, &msg.Content, &msg.Timestamp, &msg.Type, &emoji); err != nil {
+			return nil, Cursor{}, err
+		}
+		msg.ID = uuid.FromStringOrNil(id)
+		msg.Emoji = emoji.String
+		messages = append(messages, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Cursor{}, err
+	}
+	messages, next := pageOf(messages, limit, func(m models.Message) Cursor { return Cursor{m.Timestamp, m.ID} })
+	return messages, next, nil
+}
@@ -0,0 +1,144 @@
+package db
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type mfaRepo struct {
+	db *sql.DB
+}
+
+// Status indique si la 2FA est active pour l'utilisateur et si son rôle l'exige
+func (r *mfaRepo) Status(userID uuid.UUID) (enabled bool, required bool, err error) {
+	err = r.db.QueryRow(`
+		SELECT
+			EXISTS (SELECT 1 FROM user_mfa WHERE user_id = u.id AND enabled_at IS NOT NULL),
+			EXISTS (SELECT 1 FROM mfa_required_roles WHERE role = u.role)
+		FROM users u WHERE u.id = ?`, userID).Scan(&enabled, &required)
+	return enabled, required, notFound(err)
+}
+
+// Enroll enregistre un nouveau secret en attente de confirmation, à la place de l'éventuel précédent
+func (r *mfaRepo) Enroll(userID uuid.UUID, secret string, now time.Time) error {
+	_, err := r.db.Exec(`INSERT INTO user_mfa (user_id, secret, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(user_id) DO UPDATE SET secret = excluded.secret, enabled_at = NULL, last_used_step = 0, created_at = excluded.created_at`,
+		userID, secret, now)
+	return err
+}
+
+// PendingSecret renvoie le secret en attente de confirmation, ErrNotFound sans enrôlement en cours
+func (r *mfaRepo) PendingSecret(userID uuid.UUID) (string, error) {
+	var secret string
+	err := r.db.QueryRow(`SELECT secret FROM user_mfa WHERE user_id = ? AND enabled_at IS NULL`, userID).Scan(&secret)
+	return secret, notFound(err)
+}
+
+// Enable active la 2FA à la fenêtre step et remplace les codes de secours par les empreintes codeHashes
+func (r *mfaRepo) Enable(userID uuid.UUID, step int64, codeHashes []string, now time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE user_mfa SET enabled_at = ?, last_used_step = ? WHERE user_id = ?`, now, step, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	for _, hash := range codeHashes {
+		_, err := tx.Exec(`INSERT INTO mfa_recovery_codes (id, user_id, code_hash, created_at) VALUES (?, ?, ?, ?)`,
+			uuid.Must(uuid.NewV4()), userID, hash, now)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Secret renvoie le secret d'une 2FA active et la dernière fenêtre utilisée, ErrNotFound si la 2FA n'est pas active
+func (r *mfaRepo) Secret(userID uuid.UUID) (secret string, lastStep int64, err error) {
+	err = r.db.QueryRow(`SELECT secret, last_used_step FROM user_mfa WHERE user_id = ? AND enabled_at IS NOT NULL`, userID).
+		Scan(&secret, &lastStep)
+	return secret, lastStep, notFound(err)
+}
+
+// UseStep enregistre la fenêtre step comme utilisée, faux si une fenêtre égale ou postérieure l'a déjà été (rejeu)
+func (r *mfaRepo) UseStep(userID uuid.UUID, step int64) (bool, error) {
+	res, err := r.db.Exec(`UPDATE user_mfa SET last_used_step = ? WHERE user_id = ? AND last_used_step < ?`, step, userID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode consomme le code de secours d'empreinte codeHash, faux s'il est inconnu ou déjà utilisé
+func (r *mfaRepo) UseRecoveryCode(userID uuid.UUID, codeHash string, now time.Time) (bool, error) {
+	res, err := r.db.Exec(`UPDATE mfa_recovery_codes SET used_at = ? WHERE user_id = ? AND code_hash = ? AND used_at IS NULL`,
+		now, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// Disable supprime le secret et les codes de secours de l'utilisateur
+func (r *mfaRepo) Disable(userID uuid.UUID) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM user_mfa WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM mfa_recovery_codes WHERE user_id = ?`, userID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// RequiredRoles renvoie les rôles soumis à la 2FA obligatoire
+func (r *mfaRepo) RequiredRoles() ([]string, error) {
+	rows, err := r.db.Query(`SELECT role FROM mfa_required_roles ORDER BY role`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []string{}
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// SetRequiredRoles remplace la liste des rôles soumis à la 2FA obligatoire
+func (r *mfaRepo) SetRequiredRoles(roles []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM mfa_required_roles`); err != nil {
+		return err
+	}
+	for _, role := range roles {
+		if _, err := tx.Exec(`INSERT INTO mfa_required_roles (role) VALUES (?) ON CONFLICT DO NOTHING`, role); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
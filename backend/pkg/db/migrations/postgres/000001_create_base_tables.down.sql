@@ -0,0 +1,17 @@
+DROP TABLE IF EXISTS chatGroup;
+DROP TABLE IF EXISTS chatHistory;
+DROP TABLE IF EXISTS notifications;
+DROP TABLE IF EXISTS event_responses;
+DROP TABLE IF EXISTS group_events;
+DROP TABLE IF EXISTS group_posts_comments;
+DROP TABLE IF EXISTS group_posts;
+DROP TABLE IF EXISTS group_members;
+DROP TABLE IF EXISTS groups;
+DROP TABLE IF EXISTS comment_interactions;
+DROP TABLE IF EXISTS post_interactions;
+DROP TABLE IF EXISTS comments;
+DROP TABLE IF EXISTS post_allowed_users;
+DROP TABLE IF EXISTS posts;
+DROP TABLE IF EXISTS follow_requests;
+DROP TABLE IF EXISTS followers;
+DROP TABLE IF EXISTS users;
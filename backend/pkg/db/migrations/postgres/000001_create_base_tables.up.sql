@@ -0,0 +1,167 @@
+-- schéma de départ pour une base Postgres vide : tables créées côté SQLite avant l'arrivée des migrations
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	username VARCHAR(50) NOT NULL UNIQUE,
+	age INTEGER,
+	email VARCHAR(100) NOT NULL UNIQUE,
+	password_hash VARCHAR(255) NOT NULL,
+	first_name VARCHAR(50),
+	last_name VARCHAR(50),
+	role VARCHAR(50) NOT NULL DEFAULT 'user',
+	gender VARCHAR(10) NOT NULL,
+	date_of_birth DATE,
+	avatar VARCHAR(255),
+	bio TEXT,
+	phone_number VARCHAR(20),
+	address VARCHAR(255),
+	is_private BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS followers (
+	id TEXT PRIMARY KEY,
+	follower_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	followed_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	status TEXT CHECK(status IN ('pending', 'accepted')) DEFAULT 'pending',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS follow_requests (
+	id TEXT PRIMARY KEY,
+	sender_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	receiver_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS posts (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	visibility TEXT CHECK(visibility IN ('public', 'private', 'almost_private')) DEFAULT 'public',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	image_path TEXT,
+	total_likes INTEGER DEFAULT 0,
+	total_unlikes INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS post_allowed_users (
+	post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS comments (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	username TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	total_likes INTEGER DEFAULT 0,
+	total_unlikes INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS post_interactions (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	interaction_type TEXT CHECK(interaction_type IN ('like', 'unlike')) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS comment_interactions (
+	id TEXT PRIMARY KEY,
+	comment_id TEXT NOT NULL REFERENCES comments(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	interaction_type TEXT CHECK(interaction_type IN ('like', 'unlike')) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS groups (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	description TEXT,
+	creator_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_members (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	status TEXT CHECK(status IN ('pending', 'accepted')) DEFAULT 'pending',
+	role TEXT CHECK(role IN ('creator', 'member')) DEFAULT 'member',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_posts (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	likes INTEGER DEFAULT 0,
+	username TEXT,
+	avatar TEXT,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_posts_comments (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL REFERENCES group_posts(id) ON DELETE CASCADE,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	username TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_events (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL REFERENCES groups(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	event_date TIMESTAMPTZ NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	options JSONB DEFAULT '{}'
+);
+
+CREATE TABLE IF NOT EXISTS event_responses (
+	id TEXT PRIMARY KEY,
+	event_id TEXT NOT NULL REFERENCES group_events(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	response TEXT CHECK(response IN ('Going', 'Not going')) NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS notifications (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	sender_id TEXT,
+	content TEXT NOT NULL,
+	type TEXT NOT NULL,
+	created_at TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	read BOOLEAN DEFAULT FALSE
+);
+
+CREATE TABLE IF NOT EXISTS chatHistory (
+	id TEXT PRIMARY KEY,
+	sender_username TEXT NOT NULL,
+	target_username TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	type TEXT NOT NULL,
+	emoji TEXT
+);
+
+CREATE TABLE IF NOT EXISTS chatGroup (
+	id TEXT PRIMARY KEY,
+	sender_username TEXT NOT NULL,
+	target_username TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp TIMESTAMPTZ DEFAULT CURRENT_TIMESTAMP,
+	type TEXT NOT NULL,
+	emoji TEXT
+);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS user_mfa (
+	user_id TEXT PRIMARY KEY,
+	secret TEXT NOT NULL,
+	enabled_at TIMESTAMPTZ,
+	last_used_step INTEGER NOT NULL DEFAULT 0,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	code_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	used_at TIMESTAMPTZ,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user_id ON mfa_recovery_codes(user_id);
+
+-- rôles pour lesquels la 2FA est obligatoire (ex. admin, moderator)
+CREATE TABLE IF NOT EXISTS mfa_required_roles (
+	role TEXT PRIMARY KEY,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS login_throttle;
+DROP INDEX IF EXISTS idx_login_attempts_ip_address;
+DROP INDEX IF EXISTS idx_login_attempts_user_id;
+DROP TABLE IF EXISTS login_attempts;
@@ -0,0 +1,2 @@
+-- les comptes créés avant le contrôle des rôles peuvent avoir un rôle vide ou arbitraire
+UPDATE users SET role = 'user' WHERE role IS NULL OR role NOT IN ('admin', 'moderator', 'user');
@@ -0,0 +1,41 @@
+CREATE TABLE IF NOT EXISTS user_suspensions (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	kind TEXT NOT NULL CHECK(kind IN ('suspension', 'ban')),
+	reason TEXT NOT NULL,
+	expires_at TIMESTAMPTZ,
+	created_by TEXT NOT NULL,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	lifted_at TIMESTAMPTZ,
+	lifted_by TEXT,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_suspensions_user_id ON user_suspensions(user_id);
+
+CREATE TABLE IF NOT EXISTS admin_audit_log (
+	id TEXT PRIMARY KEY,
+	actor_id TEXT NOT NULL,
+	action TEXT NOT NULL,
+	target_user_id TEXT,
+	details TEXT,
+	ip_address TEXT,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_log_created_at ON admin_audit_log(created_at);
+
+-- journal en ajout seul : aucune ligne ne peut être modifiée ni supprimée
+CREATE OR REPLACE FUNCTION admin_audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'admin_audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER admin_audit_log_no_update
+BEFORE UPDATE ON admin_audit_log
+FOR EACH ROW EXECUTE FUNCTION admin_audit_log_append_only();
+
+CREATE TRIGGER admin_audit_log_no_delete
+BEFORE DELETE ON admin_audit_log
+FOR EACH ROW EXECUTE FUNCTION admin_audit_log_append_only();
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS hidden_content;
+DROP INDEX IF EXISTS idx_reports_target;
+DROP INDEX IF EXISTS idx_reports_status;
+DROP TABLE IF EXISTS reports;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS reports (
+	id TEXT PRIMARY KEY,
+	reporter_id TEXT NOT NULL,
+	target_type TEXT NOT NULL CHECK(target_type IN ('post', 'comment', 'group_post', 'group_comment', 'private_message', 'group_message')),
+	target_id TEXT NOT NULL,
+	reason TEXT NOT NULL,
+	details TEXT,
+	status TEXT NOT NULL DEFAULT 'open' CHECK(status IN ('open', 'in_review', 'resolved', 'dismissed')),
+	assigned_to TEXT,
+	resolution TEXT,
+	resolved_by TEXT,
+	resolved_at TIMESTAMPTZ,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	UNIQUE (reporter_id, target_type, target_id),
+	FOREIGN KEY (reporter_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_reports_status ON reports(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_reports_target ON reports(target_type, target_id);
+
+-- contenus masqués par la modération, exclus des listes
+CREATE TABLE IF NOT EXISTS hidden_content (
+	target_type TEXT NOT NULL,
+	target_id TEXT NOT NULL,
+	reason TEXT,
+	hidden_by TEXT NOT NULL,
+	hidden_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (target_type, target_id)
+);
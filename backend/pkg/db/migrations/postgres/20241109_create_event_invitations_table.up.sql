@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS event_invitations (
+	event_id TEXT NOT NULL REFERENCES group_events(id) ON DELETE CASCADE,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (event_id, user_id)
+);
@@ -0,0 +1,2 @@
+-- Postgres applique déjà les clés étrangères : seules les notifications, sans contrainte, peuvent être orphelines
+DELETE FROM notifications WHERE user_id NOT IN (SELECT id FROM users);
@@ -0,0 +1,15 @@
+ALTER TABLE posts ADD COLUMN IF NOT EXISTS edited_at TIMESTAMPTZ;
+
+CREATE TABLE IF NOT EXISTS post_revisions (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	visibility TEXT NOT NULL,
+	image_path TEXT,
+	allowed_users TEXT NOT NULL DEFAULT '[]',
+	edited_by TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	replaced_at TIMESTAMPTZ NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_revisions_post ON post_revisions(post_id, replaced_at);
@@ -0,0 +1,19 @@
+-- un utilisateur ne peut avoir qu'une interaction de chaque type par post ou commentaire :
+-- les doublons laissés par les anciennes bascules like/unlike sont supprimés avant l'index unique
+DELETE FROM post_interactions WHERE id NOT IN (
+	SELECT MIN(id) FROM post_interactions GROUP BY post_id, user_id, interaction_type
+);
+DELETE FROM comment_interactions WHERE id NOT IN (
+	SELECT MIN(id) FROM comment_interactions GROUP BY comment_id, user_id, interaction_type
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_post_interactions_unique ON post_interactions(post_id, user_id, interaction_type);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_comment_interactions_unique ON comment_interactions(comment_id, user_id, interaction_type);
+
+-- les compteurs sont recalculés à partir des interactions restantes
+UPDATE posts SET
+	total_likes = (SELECT COUNT(*) FROM post_interactions pi WHERE pi.post_id = posts.id AND pi.interaction_type = 'like'),
+	total_unlikes = (SELECT COUNT(*) FROM post_interactions pi WHERE pi.post_id = posts.id AND pi.interaction_type = 'unlike');
+UPDATE comments SET
+	total_likes = (SELECT COUNT(*) FROM comment_interactions ci WHERE ci.comment_id = comments.id AND ci.interaction_type = 'like'),
+	total_unlikes = (SELECT COUNT(*) FROM comment_interactions ci WHERE ci.comment_id = comments.id AND ci.interaction_type = 'unlike');
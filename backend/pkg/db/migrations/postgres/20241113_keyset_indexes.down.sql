@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_posts_keyset;
+DROP INDEX IF EXISTS idx_comments_keyset;
+DROP INDEX IF EXISTS idx_notifications_keyset;
+DROP INDEX IF EXISTS idx_chat_history_keyset;
+DROP INDEX IF EXISTS idx_chat_group_keyset;
@@ -0,0 +1,4 @@
+-- invited_by : membre qui a envoyé l'invitation, NULL pour une demande d'adhésion.
+-- Une invitation est acceptée par l'invité, une demande par le créateur du groupe ;
+-- les lignes en attente existantes sont traitées comme des demandes
+ALTER TABLE group_members ADD COLUMN invited_by TEXT REFERENCES users(id) ON DELETE SET NULL;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS comments (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	username TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	total_likes INTEGER DEFAULT 0,
+	total_unlikes INTEGER DEFAULT 0,
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS comment_interactions (
+	id TEXT PRIMARY KEY,
+	comment_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	interaction_type TEXT CHECK(interaction_type IN ('like', 'unlike')) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS post_interactions (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	interaction_type TEXT CHECK(interaction_type IN ('like', 'unlike')) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS users (
+	id TEXT PRIMARY KEY,
+	username VARCHAR(50) NOT NULL,
+	age INT,
+	email VARCHAR(100) NOT NULL,
+	password_hash VARCHAR(255) NOT NULL,
+	first_name VARCHAR(50),
+	last_name VARCHAR(50),
+	role VARCHAR(50) NOT NULL,
+	gender VARCHAR(10) NOT NULL,
+	date_of_birth DATE,
+	avatar VARCHAR(255),
+	bio TEXT,
+	phone_number VARCHAR(20),
+	address VARCHAR(255),
+	is_private BOOLEAN NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS posts (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	visibility TEXT CHECK(visibility IN ('public', 'private', 'almost_private')) DEFAULT 'public',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	image_path TEXT,
+	total_likes INTEGER DEFAULT 0,
+	total_unlikes INTEGER DEFAULT 0,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS followers (
+	id TEXT PRIMARY KEY,
+	follower_id TEXT NOT NULL,
+	followed_id TEXT NOT NULL,
+	status TEXT CHECK(status IN ('pending', 'accepted')) DEFAULT 'pending',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (follower_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (followed_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+-- doublon historique de 000004, conservé pour ne pas décaler les versions déjà appliquées
+SELECT 1;
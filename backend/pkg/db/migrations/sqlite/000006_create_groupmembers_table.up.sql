@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS group_members (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	status TEXT CHECK(status IN ('pending', 'accepted')) DEFAULT 'pending',
+	role TEXT CHECK(role IN ('creator', 'member')) DEFAULT 'member',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS notifications (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL,
+	sender_id TEXT,
+	content TEXT NOT NULL,
+	type TEXT NOT NULL,
+	created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+	read BOOLEAN DEFAULT FALSE
+);
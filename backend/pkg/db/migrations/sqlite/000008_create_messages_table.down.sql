@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS chatGroup;
+DROP TABLE IF EXISTS chatHistory;
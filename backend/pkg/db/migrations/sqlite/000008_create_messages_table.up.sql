@@ -0,0 +1,21 @@
+-- messages privés
+CREATE TABLE IF NOT EXISTS chatHistory (
+	id TEXT PRIMARY KEY,
+	sender_username TEXT NOT NULL,
+	target_username TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	type TEXT NOT NULL,
+	emoji TEXT
+);
+
+-- messages de groupe : target_username contient l'identifiant du groupe
+CREATE TABLE IF NOT EXISTS chatGroup (
+	id TEXT PRIMARY KEY,
+	sender_username TEXT NOT NULL,
+	target_username TEXT NOT NULL,
+	content TEXT NOT NULL,
+	timestamp DATETIME DEFAULT CURRENT_TIMESTAMP,
+	type TEXT NOT NULL,
+	emoji TEXT
+);
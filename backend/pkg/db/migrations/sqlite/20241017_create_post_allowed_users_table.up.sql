@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS post_allowed_users (
+	post_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
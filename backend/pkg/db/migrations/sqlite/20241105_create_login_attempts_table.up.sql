@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS login_attempts (
+	id TEXT PRIMARY KEY,
+	identifier TEXT NOT NULL,
+	user_id TEXT,
+	ip_address TEXT,
+	user_agent TEXT,
+	success BOOLEAN NOT NULL,
+	reason TEXT,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id ON login_attempts(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip_address ON login_attempts(ip_address, created_at);
+
+-- compteurs du limiteur de tentatives (clés "account:..." et "ip:...")
+CREATE TABLE IF NOT EXISTS login_throttle (
+	key TEXT PRIMARY KEY,
+	failures INTEGER NOT NULL DEFAULT 0,
+	last_failure_at DATETIME,
+	blocked_until DATETIME
+);
@@ -0,0 +1,2 @@
+-- rien à annuler : les anciennes valeurs de rôle ne sont pas conservées
+SELECT 1;
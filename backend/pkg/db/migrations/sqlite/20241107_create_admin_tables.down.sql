@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS admin_audit_log_no_delete;
+DROP TRIGGER IF EXISTS admin_audit_log_no_update;
+DROP INDEX IF EXISTS idx_admin_audit_log_created_at;
+DROP TABLE IF EXISTS admin_audit_log;
+DROP INDEX IF EXISTS idx_user_suspensions_user_id;
+DROP TABLE IF EXISTS user_suspensions;
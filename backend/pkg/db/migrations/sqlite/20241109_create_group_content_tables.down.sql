@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS event_invitations;
+DROP TABLE IF EXISTS event_responses;
+DROP TABLE IF EXISTS group_events;
+DROP TABLE IF EXISTS group_posts_comments;
+DROP TABLE IF EXISTS group_posts;
+DROP TABLE IF EXISTS follow_requests;
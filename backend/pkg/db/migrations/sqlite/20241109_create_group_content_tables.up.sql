@@ -0,0 +1,65 @@
+-- tables utilisées par le code mais créées jusqu'ici à la main dans data.db
+CREATE TABLE IF NOT EXISTS follow_requests (
+	id TEXT PRIMARY KEY,
+	sender_id TEXT NOT NULL,
+	receiver_id TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (receiver_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS group_posts (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	likes INTEGER DEFAULT 0,
+	username TEXT,
+	avatar TEXT,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS group_posts_comments (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	username TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (post_id) REFERENCES group_posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS group_events (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	event_date DATETIME NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	options JSON DEFAULT '{}',
+	FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS event_responses (
+	id TEXT PRIMARY KEY,
+	event_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	response TEXT CHECK(response IN ('Going', 'Not going')) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS event_invitations (
+	event_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (event_id, user_id),
+	FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
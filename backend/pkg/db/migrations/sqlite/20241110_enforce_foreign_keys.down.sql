@@ -0,0 +1,2 @@
+-- rien à annuler : les lignes orphelines supprimées ne sont pas conservées
+SELECT 1;
@@ -0,0 +1,183 @@
+-- Les clés étrangères sont désormais appliquées (_foreign_keys=1).
+-- 1. Certaines bases référencent encore une table "users_old" disparue : ces tables sont reconstruites
+--    pour pointer vers users. Les migrations tournent sans clés étrangères, DROP TABLE ne cascade donc pas.
+-- 2. Les lignes orphelines accumulées tant que les cascades ne se déclenchaient pas sont supprimées.
+
+CREATE TABLE follow_requests_new (
+	id TEXT PRIMARY KEY,
+	sender_id TEXT NOT NULL,
+	receiver_id TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (sender_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (receiver_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO follow_requests_new (id, sender_id, receiver_id, created_at) SELECT id, sender_id, receiver_id, created_at FROM follow_requests;
+DROP TABLE follow_requests;
+ALTER TABLE follow_requests_new RENAME TO follow_requests;
+
+CREATE TABLE groups_new (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	description TEXT,
+	creator_id TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (creator_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO groups_new (id, name, description, creator_id, created_at) SELECT id, name, description, creator_id, created_at FROM groups;
+DROP TABLE groups;
+ALTER TABLE groups_new RENAME TO groups;
+
+CREATE TABLE group_members_new (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	status TEXT CHECK(status IN ('pending', 'accepted')) DEFAULT 'pending',
+	role TEXT CHECK(role IN ('creator', 'member')) DEFAULT 'member',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO group_members_new (id, group_id, user_id, status, role, created_at) SELECT id, group_id, user_id, status, role, created_at FROM group_members;
+DROP TABLE group_members;
+ALTER TABLE group_members_new RENAME TO group_members;
+
+CREATE TABLE posts_new (
+	id TEXT PRIMARY KEY,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	visibility TEXT CHECK(visibility IN ('public', 'private', 'almost_private')) DEFAULT 'public',
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	image_path TEXT,
+	total_likes INTEGER DEFAULT 0,
+	total_unlikes INTEGER DEFAULT 0,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO posts_new (id, title, content, user_id, visibility, created_at, image_path, total_likes, total_unlikes) SELECT id, title, content, user_id, visibility, created_at, image_path, total_likes, total_unlikes FROM posts;
+DROP TABLE posts;
+ALTER TABLE posts_new RENAME TO posts;
+
+CREATE TABLE post_allowed_users_new (
+	post_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO post_allowed_users_new (post_id, user_id) SELECT post_id, user_id FROM post_allowed_users;
+DROP TABLE post_allowed_users;
+ALTER TABLE post_allowed_users_new RENAME TO post_allowed_users;
+
+CREATE TABLE comments_new (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	username TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	total_likes INTEGER DEFAULT 0,
+	total_unlikes INTEGER DEFAULT 0,
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO comments_new (id, post_id, content, user_id, username, created_at, total_likes, total_unlikes) SELECT id, post_id, content, user_id, username, created_at, total_likes, total_unlikes FROM comments;
+DROP TABLE comments;
+ALTER TABLE comments_new RENAME TO comments;
+
+CREATE TABLE comment_interactions_new (
+	id TEXT PRIMARY KEY,
+	comment_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	interaction_type TEXT CHECK(interaction_type IN ('like', 'unlike')) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (comment_id) REFERENCES comments(id) ON DELETE CASCADE
+);
+INSERT INTO comment_interactions_new (id, comment_id, user_id, interaction_type, created_at) SELECT id, comment_id, user_id, interaction_type, created_at FROM comment_interactions;
+DROP TABLE comment_interactions;
+ALTER TABLE comment_interactions_new RENAME TO comment_interactions;
+
+CREATE TABLE post_interactions_new (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	interaction_type TEXT CHECK(interaction_type IN ('like', 'unlike')) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE
+);
+INSERT INTO post_interactions_new (id, post_id, user_id, interaction_type, created_at) SELECT id, post_id, user_id, interaction_type, created_at FROM post_interactions;
+DROP TABLE post_interactions;
+ALTER TABLE post_interactions_new RENAME TO post_interactions;
+
+CREATE TABLE group_posts_comments_new (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL,
+	content TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	username TEXT NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (post_id) REFERENCES group_posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO group_posts_comments_new (id, post_id, content, user_id, username, created_at) SELECT id, post_id, content, user_id, username, created_at FROM group_posts_comments;
+DROP TABLE group_posts_comments;
+ALTER TABLE group_posts_comments_new RENAME TO group_posts_comments;
+
+CREATE TABLE group_events_new (
+	id TEXT PRIMARY KEY,
+	group_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	description TEXT NOT NULL,
+	event_date DATETIME NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	options JSON DEFAULT '{}',
+	FOREIGN KEY (group_id) REFERENCES groups(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO group_events_new (id, group_id, user_id, title, description, event_date, created_at, options) SELECT id, group_id, user_id, title, description, event_date, created_at, options FROM group_events;
+DROP TABLE group_events;
+ALTER TABLE group_events_new RENAME TO group_events;
+
+CREATE TABLE event_responses_new (
+	id TEXT PRIMARY KEY,
+	event_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	response TEXT CHECK(response IN ('Going', 'Not going')) NOT NULL,
+	created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (event_id) REFERENCES group_events(id) ON DELETE CASCADE,
+	FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+INSERT INTO event_responses_new (id, event_id, user_id, response, created_at) SELECT id, event_id, user_id, response, created_at FROM event_responses;
+DROP TABLE event_responses;
+ALTER TABLE event_responses_new RENAME TO event_responses;
+
+-- réseau social
+DELETE FROM followers WHERE follower_id NOT IN (SELECT id FROM users) OR followed_id NOT IN (SELECT id FROM users);
+DELETE FROM follow_requests WHERE sender_id NOT IN (SELECT id FROM users) OR receiver_id NOT IN (SELECT id FROM users);
+DELETE FROM notifications WHERE user_id NOT IN (SELECT id FROM users);
+
+-- publications, des parents vers les enfants
+DELETE FROM posts WHERE user_id NOT IN (SELECT id FROM users);
+DELETE FROM post_allowed_users WHERE post_id NOT IN (SELECT id FROM posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM post_interactions WHERE post_id NOT IN (SELECT id FROM posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM comments WHERE post_id NOT IN (SELECT id FROM posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM comment_interactions WHERE comment_id NOT IN (SELECT id FROM comments) OR user_id NOT IN (SELECT id FROM users);
+
+-- groupes
+DELETE FROM groups WHERE creator_id NOT IN (SELECT id FROM users);
+DELETE FROM group_members WHERE group_id NOT IN (SELECT id FROM groups) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM group_posts WHERE group_id NOT IN (SELECT id FROM groups);
+DELETE FROM group_posts_comments WHERE post_id NOT IN (SELECT id FROM group_posts) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM group_events WHERE group_id NOT IN (SELECT id FROM groups) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM event_responses WHERE event_id NOT IN (SELECT id FROM group_events) OR user_id NOT IN (SELECT id FROM users);
+DELETE FROM event_invitations WHERE event_id NOT IN (SELECT id FROM group_events) OR user_id NOT IN (SELECT id FROM users);
+
+-- comptes
+DELETE FROM sessions WHERE user_id NOT IN (SELECT id FROM users);
+DELETE FROM refresh_tokens WHERE session_id NOT IN (SELECT id FROM sessions);
+DELETE FROM user_identities WHERE user_id NOT IN (SELECT id FROM users);
+DELETE FROM user_tokens WHERE user_id NOT IN (SELECT id FROM users);
+DELETE FROM user_mfa WHERE user_id NOT IN (SELECT id FROM users);
+DELETE FROM mfa_recovery_codes WHERE user_id NOT IN (SELECT id FROM users);
+DELETE FROM user_suspensions WHERE user_id NOT IN (SELECT id FROM users);
@@ -0,0 +1,19 @@
+-- edited_at : date de la dernière modification, NULL tant que le post n'a pas été modifié
+ALTER TABLE posts ADD COLUMN edited_at DATETIME;
+
+-- chaque modification conserve l'état précédent du post
+CREATE TABLE IF NOT EXISTS post_revisions (
+	id TEXT PRIMARY KEY,
+	post_id TEXT NOT NULL,
+	title TEXT NOT NULL,
+	content TEXT NOT NULL,
+	visibility TEXT NOT NULL,
+	image_path TEXT,
+	allowed_users TEXT NOT NULL DEFAULT '[]', -- identifiants JSON des utilisateurs autorisés (almost_private)
+	edited_by TEXT NOT NULL,
+	replaced_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE CASCADE,
+	FOREIGN KEY (edited_by) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_post_revisions_post ON post_revisions(post_id, replaced_at);
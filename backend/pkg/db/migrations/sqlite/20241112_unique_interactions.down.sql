@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_comment_interactions_unique;
+DROP INDEX IF EXISTS idx_post_interactions_unique;
@@ -0,0 +1,7 @@
+-- index (date, id) des listes paginées par curseur (keysetAfter) : le tri et la reprise
+-- après le curseur suivent l'index au lieu de parcourir puis trier toute la table
+CREATE INDEX IF NOT EXISTS idx_posts_keyset ON posts(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_comments_keyset ON comments(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_notifications_keyset ON notifications(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_chat_history_keyset ON chatHistory(timestamp, id);
+CREATE INDEX IF NOT EXISTS idx_chat_group_keyset ON chatGroup(timestamp, id);
@@ -0,0 +1,203 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type moderationRepo struct {
+	db *sql.DB
+}
+
+// reportTargets associe chaque type de contenu signalable à la requête qui lit son texte
+var reportTargets = map[string]string{
+	"post":            `SELECT content FROM posts WHERE id = ?`,
+	"comment":         `SELECT content FROM comments WHERE id = ?`,
+	"group_post":      `SELECT content FROM group_posts WHERE id = ?`,
+	"group_comment":   `SELECT content FROM group_posts_comments WHERE id = ?`,
+	"private_message": `SELECT content FROM chatHistory WHERE id = ?`,
+	"group_message":   `SELECT content FROM chatGroup WHERE id = ?`,
+}
+
+// IsReportTarget indique si targetType est un type de contenu signalable et masquable
+func IsReportTarget(targetType string) bool {
+	_, ok := reportTargets[targetType]
+	return ok
+}
+
+// TargetContent lit le texte du contenu ciblé, ErrNotFound s'il n'existe pas
+func (r *moderationRepo) TargetContent(targetType, targetID string) (string, error) {
+	var content string
+	err := r.db.QueryRow(reportTargets[targetType], targetID).Scan(&content)
+	return content, notFound(err)
+}
+
+// IsMessageParticipant indique si username a envoyé ou reçu le message privé
+func (r *moderationRepo) IsMessageParticipant(messageID, username string) (bool, error) {
+	var participant bool
+	err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM chatHistory WHERE id = ? AND (sender_username = ? OR target_username = ?))`,
+		messageID, username, username).Scan(&participant)
+	return participant, err
+}
+
+// CreateReport enregistre un signalement, ErrAlreadyExists si l'utilisateur a déjà signalé ce contenu
+func (r *moderationRepo) CreateReport(report models.Report) error {
+	res, err := r.db.Exec(`INSERT INTO reports (id, reporter_id, target_type, target_id, reason, details, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		report.ID, report.ReporterID, report.TargetType, report.TargetID, report.Reason,
+		sql.NullString{String: report.Details, Valid: report.Details != ""}, report.Status, report.CreatedAt, report.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrAlreadyExists
+	}
+	return nil
+}
+
+const reportColumns = `r.id, r.reporter_id, r.target_type, r.target_id, r.reason, COALESCE(r.details, ''), r.status,
+	r.assigned_to, COALESCE(r.resolution, ''), r.resolved_by, r.resolved_at, r.created_at, r.updated_at,
+	EXISTS (SELECT 1 FROM hidden_content hc WHERE hc.target_type = r.target_type AND hc.target_id = r.target_id)`
+
+func scanReport(row interface{ Scan(...interface{}) error }) (models.Report, error) {
+	var rep models.Report
+	var assignedTo, resolvedBy uuid.NullUUID
+	var resolvedAt sql.NullTime
+	err := row.Scan(&rep.ID, &rep.ReporterID, &rep.TargetType, &rep.TargetID, &rep.Reason, &rep.Details, &rep.Status,
+		&assignedTo, &rep.Resolution, &resolvedBy, &resolvedAt, &rep.CreatedAt, &rep.UpdatedAt, &rep.TargetHidden)
+	if err != nil {
+		return rep, err
+	}
+	if assignedTo.Valid {
+		rep.AssignedTo = &assignedTo.UUID
+	}
+	if resolvedBy.Valid {
+		rep.ResolvedBy = &resolvedBy.UUID
+	}
+	if resolvedAt.Valid {
+		rep.ResolvedAt = &resolvedAt.Time
+	}
+	return rep, nil
+}
+
+// Report lit un signalement et l'aperçu du contenu visé, ErrNotFound s'il n'existe pas
+func (r *moderationRepo) Report(reportID uuid.UUID) (models.Report, error) {
+	rep, err := scanReport(r.db.QueryRow(`SELECT `+reportColumns+` FROM reports r WHERE r.id = ?`, reportID))
+	if err != nil {
+		return rep, notFound(err)
+	}
+	rep.TargetContent, _ = r.TargetContent(rep.TargetType, rep.TargetID)
+	return rep, nil
+}
+
+// ListReports renvoie la file des signalements retenus par filter, du plus ancien au plus récent
+func (r *moderationRepo) ListReports(filter ReportFilter, limit, offset int) ([]models.Report, error) {
+	conditions := []string{"1 = 1"}
+	args := []interface{}{}
+	if filter.Status != "" {
+		conditions = append(conditions, "r.status = ?")
+		args = append(args, filter.Status)
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, "r.target_type = ?")
+		args = append(args, filter.TargetType)
+	}
+	switch {
+	case filter.AssignedTo == nil:
+	case *filter.AssignedTo == uuid.Nil:
+		conditions = append(conditions, "r.assigned_to IS NULL")
+	default:
+		conditions = append(conditions, "r.assigned_to = ?")
+		args = append(args, *filter.AssignedTo)
+	}
+	args = append(args, limit, offset)
+
+	rows, err := r.db.Query(`SELECT `+reportColumns+` FROM reports r
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY r.created_at ASC
+		LIMIT ? OFFSET ?`, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := []models.Report{}
+	for rows.Next() {
+		rep, err := scanReport(rows)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		reports = append(reports, rep)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range reports {
+		reports[i].TargetContent, _ = r.TargetContent(reports[i].TargetType, reports[i].TargetID)
+	}
+	return reports, nil
+}
+
+// AssignReport attribue un signalement encore ouvert et le passe en cours de traitement,
+// ErrNotFound s'il n'existe pas ou est déjà clos
+func (r *moderationRepo) AssignReport(reportID, assigneeID uuid.UUID, at time.Time) error {
+	res, err := r.db.Exec(`UPDATE reports SET assigned_to = ?, status = ?, updated_at = ? WHERE id = ? AND status IN (?, ?)`,
+		assigneeID, models.ReportStatusInReview, at, reportID, models.ReportStatusOpen, models.ReportStatusInReview)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ResolveReport clôt le signalement avec status. Avec hide, le contenu est masqué et tous les
+// signalements ouverts qui le visent sont clos dans la même transaction
+func (r *moderationRepo) ResolveReport(report models.Report, status, resolution string, hide bool, moderatorID uuid.UUID, at time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if hide {
+		_, err = tx.Exec(`INSERT INTO hidden_content (target_type, target_id, reason, hidden_by, hidden_at) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+			report.TargetType, report.TargetID, report.Reason, moderatorID, at)
+		if err == nil {
+			_, err = tx.Exec(`UPDATE reports SET status = ?, resolution = ?, resolved_by = ?, resolved_at = ?, updated_at = ?
+				WHERE target_type = ? AND target_id = ? AND status IN (?, ?)`,
+				status, resolution, moderatorID, at, at, report.TargetType, report.TargetID, models.ReportStatusOpen, models.ReportStatusInReview)
+		}
+	} else {
+		_, err = tx.Exec(`UPDATE reports SET status = ?, resolution = ?, resolved_by = ?, resolved_at = ?, updated_at = ? WHERE id = ?`,
+			status, resolution, moderatorID, at, at, report.ID)
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Hide masque un contenu, sans effet s'il l'est déjà
+func (r *moderationRepo) Hide(targetType, targetID, reason string, moderatorID uuid.UUID) error {
+	_, err := r.db.Exec(`INSERT INTO hidden_content (target_type, target_id, reason, hidden_by, hidden_at) VALUES (?, ?, ?, ?, ?) ON CONFLICT DO NOTHING`,
+		targetType, targetID, reason, moderatorID, time.Now())
+	return err
+}
+
+// Unhide rend un contenu à nouveau visible, false s'il n'était pas masqué
+func (r *moderationRepo) Unhide(targetType, targetID string) (bool, error) {
+	res, err := r.db.Exec(`DELETE FROM hidden_content WHERE target_type = ? AND target_id = ?`, targetType, targetID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
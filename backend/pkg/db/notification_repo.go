@@ -0,0 +1,60 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+
+	"github.com/gofrs/uuid"
+)
+
+type notificationRepo struct {
+	db *sql.DB
+}
+
+func (r *notificationRepo) Add(userID, senderID, content, notificationType string) error {
+	_, err := r.db.Exec(
+		"INSERT INTO notifications (id, user_id, sender_id, content, type) VALUES (?, ?, ?, ?, ?)",
+		uuid.Must(uuid.NewV4()).String(), userID, senderID, content, notificationType,
+	)
+	return err
+}
+
+// ListUnread renvoie au plus limit notifications non lues, les plus récentes d'abord, à partir de after,
+// et le curseur de la page suivante
+func (r *notificationRepo) ListUnread(userID uuid.UUID, after Cursor, limit int) ([]models.Notification, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "n.created_at", "n.id", true)
+	args := append([]any{userID.String()}, keysetArgs...)
+	rows, err := r.db.Query(`
+		SELECT n.id, n.content, n.created_at, n.read, n.type, COALESCE(u.username, ''), COALESCE(u.avatar, '')
+		FROM notifications n
+		LEFT JOIN users u ON n.sender_id = u.id
+		WHERE n.user_id = ? AND n.read = FALSE AND `+keyset+`
+		ORDER BY n.created_at DESC, n.id DESC
+		LIMIT ?
+	`, append(args, limit+1)...)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+	defer rows.Close()
+
+	notifications := []models.Notification{}
+	for rows.Next() {
+		var n models.Notification
+		if err := rows.Scan(&n.ID, &n.Content, &n.CreatedAt, &n.Read, &n.Type, &n.SenderName, &n.Avatar); err != nil {
+			return nil, Cursor{}, err
+		}
+		notifications = append(notifications, n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Cursor{}, err
+	}
+	notifications, next := pageOf(notifications, limit, func(n models.Notification) Cursor {
+		return Cursor{n.CreatedAt, uuid.FromStringOrNil(n.ID)}
+	})
+	return notifications, next, nil
+}
+
+func (r *notificationRepo) MarkRead(notificationID string) error {
+	_, err := r.db.Exec(`UPDATE notifications SET read = TRUE WHERE id = ?`, notificationID)
+	return err
+}
@@ -0,0 +1,390 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type postRepo struct {
+	db *sql.DB
+}
+
+// postVisibleTo est la règle de visibilité des posts, appliquée par toutes les lectures de posts :
+// l'auteur voit tous ses posts, les autres les posts publics, les posts "private" s'ils sont abonnés
+// (abonnement accepté) à l'auteur et les posts "almost_private" s'ils sont dans post_allowed_users.
+// Les posts masqués par la modération ne sont visibles par personne. alias est celui de la table posts
+// dans la requête, qui doit passer les arguments de visibleArgs
+func postVisibleTo(alias string) string {
+	return `((` + alias + `.user_id = ?
+		OR ` + alias + `.visibility = 'public'
+		OR (` + alias + `.visibility = 'private' AND EXISTS (SELECT 1 FROM followers f WHERE f.followed_id = ` + alias + `.user_id AND f.follower_id = ? AND f.status = 'accepted'))
+		OR (` + alias + `.visibility = 'almost_private' AND EXISTS (SELECT 1 FROM post_allowed_users pa WHERE pa.post_id = ` + alias + `.id AND pa.user_id = ?)))
+		AND ` + notHidden("post", alias+".id") + `)`
+}
+
+// visibleArgs renvoie les arguments de postVisibleTo pour viewerID
+func visibleArgs(viewerID uuid.UUID) []any {
+	return []any{viewerID, viewerID, viewerID}
+}
+
+// Create insère le post avec ses utilisateurs autorisés et renvoie l'identifiant généré.
+// Un post sans visibilité est public, comme la valeur par défaut du modèle
+func (r *postRepo) Create(post models.Post) (uuid.UUID, error) {
+	if post.Visibility == "" {
+		post.Visibility = "public"
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	defer tx.Rollback()
+
+	postID := uuid.Must(uuid.NewV4())
+	query := `INSERT INTO posts (id, user_id, title, content, visibility, image_path)
+	VALUES (?, ?, ?, ?, ?, ?)`
+	_, err = tx.Exec(query, postID, post.UserID, post.Title, post.Content, post.Visibility, post.ImagePath)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to insert post: %w", err)
+	}
+	for _, userID := range post.AllowedUsers {
+		if _, err := tx.Exec(`INSERT INTO post_allowed_users (post_id, user_id) VALUES (?, ?)`, postID, userID); err != nil {
+			return uuid.Nil, fmt.Errorf("failed to insert allowed user: %w", err)
+		}
+	}
+	return postID, tx.Commit()
+}
+
+// Get renvoie un post avec son auteur, ses likes vus par viewerID et ses utilisateurs autorisés,
+// ErrNotFound s'il n'existe pas. La visibilité n'est pas vérifiée (voir Visible)
+func (r *postRepo) Get(id, viewerID uuid.UUID) (models.Post, error) {
+	var post models.Post
+	err := r.db.QueryRow(`
+		SELECT p.id, p.user_id, p.title, p.content, COALESCE(p.visibility, 'public'), COALESCE(p.image_path, ''),
+		       p.created_at, p.edited_at, u.username, u.avatar,
+		       (SELECT COUNT(*) FROM post_interactions WHERE post_id = p.id AND interaction_type = 'like') AS total_likes,
+		       EXISTS(SELECT 1 FROM post_interactions WHERE post_id = p.id AND user_id = ? AND interaction_type = 'like') AS liked_by_user
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = ?`, viewerID, id).
+		Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.Visibility, &post.ImagePath,
+			&post.CreatedAt, &post.EditedAt, &post.Username, &post.Avatar, &post.TotalLikes, &post.LikedByUser)
+	if err != nil {
+		return post, notFound(err)
+	}
+	post.AllowedUsers, err = allowedUsers(r.db, id)
+	return post, err
+}
+
+// Visible indique si viewerID peut voir le post selon postVisibleTo.
+// ErrNotFound si le post n'existe pas ou a été masqué par la modération
+func (r *postRepo) Visible(postID, viewerID uuid.UUID) (bool, error) {
+	var visible bool
+	args := append(visibleArgs(viewerID), postID)
+	err := r.db.QueryRow(`SELECT `+postVisibleTo("p")+` FROM posts p WHERE p.id = ? AND `+notHidden("post", "p.id"), args...).Scan(&visible)
+	return visible, notFound(err)
+}
+
+// Author renvoie l'auteur du post, ErrNotFound si le post n'existe pas
+func (r *postRepo) Author(postID uuid.UUID) (uuid.UUID, error) {
+	var userID uuid.UUID
+	err := r.db.QueryRow(`SELECT user_id FROM posts WHERE id = ?`, postID).Scan(&userID)
+	return userID, notFound(err)
+}
+
+// Update remplace le contenu du post et conserve l'état précédent dans post_revisions, dans une seule transaction.
+// Les utilisateurs autorisés sont remplacés par post.AllowedUsers
+func (r *postRepo) Update(post models.Post, editorID uuid.UUID, editedAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var prev models.PostRevision
+	err = tx.QueryRow(`SELECT title, content, COALESCE(visibility, 'public'), COALESCE(image_path, '') FROM posts WHERE id = ?`, post.ID).
+		Scan(&prev.Title, &prev.Content, &prev.Visibility, &prev.ImagePath)
+	if err != nil {
+		return notFound(err)
+	}
+	prevAllowed, err := allowedUsers(tx, post.ID)
+	if err != nil {
+		return err
+	}
+	if prevAllowed == nil {
+		prevAllowed = []uuid.UUID{}
+	}
+	allowedJSON, err := json.Marshal(prevAllowed)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`INSERT INTO post_revisions (id, post_id, title, content, visibility, image_path, allowed_users, edited_by, replaced_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		uuid.Must(uuid.NewV4()), post.ID, prev.Title, prev.Content, prev.Visibility, prev.ImagePath, string(allowedJSON), editorID, editedAt); err != nil {
+		return fmt.Errorf("failed to save post revision: %w", err)
+	}
+
+	if _, err := tx.Exec(`UPDATE posts SET title = ?, content = ?, visibility = ?, image_path = ?, edited_at = ? WHERE id = ?`,
+		post.Title, post.Content, post.Visibility, post.ImagePath, editedAt, post.ID); err != nil {
+		return fmt.Errorf("failed to update post: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM post_allowed_users WHERE post_id = ?`, post.ID); err != nil {
+		return fmt.Errorf("failed to reset allowed users: %w", err)
+	}
+	for _, userID := range post.AllowedUsers {
+		if _, err := tx.Exec(`INSERT INTO post_allowed_users (post_id, user_id) VALUES (?, ?)`, post.ID, userID); err != nil {
+			return fmt.Errorf("failed to insert allowed user: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete supprime le post, ses commentaires, ses likes, ses utilisateurs autorisés, ses révisions,
+// ainsi que les signalements et masquages du post et de ses commentaires.
+// Elle renvoie les images du post et de ses révisions que plus rien ne référence, à supprimer du disque
+func (r *postRepo) Delete(id uuid.UUID) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	images, err := postImages(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range []string{
+		`DELETE FROM reports WHERE target_type = 'comment' AND target_id IN (SELECT id FROM comments WHERE post_id = ?)`,
+		`DELETE FROM hidden_content WHERE target_type = 'comment' AND target_id IN (SELECT id FROM comments WHERE post_id = ?)`,
+		`DELETE FROM reports WHERE target_type = 'post' AND target_id = ?`,
+		`DELETE FROM hidden_content WHERE target_type = 'post' AND target_id = ?`,
+		`DELETE FROM comment_interactions WHERE comment_id IN (SELECT id FROM comments WHERE post_id = ?)`,
+		`DELETE FROM comments WHERE post_id = ?`,
+		`DELETE FROM post_interactions WHERE post_id = ?`,
+		`DELETE FROM post_allowed_users WHERE post_id = ?`,
+		`DELETE FROM post_revisions WHERE post_id = ?`,
+	} {
+		if _, err := tx.Exec(query, id); err != nil {
+			return nil, fmt.Errorf("failed to delete post dependencies: %w", err)
+		}
+	}
+	res, err := tx.Exec(`DELETE FROM posts WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete post: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return nil, ErrNotFound
+	}
+
+	// une même image peut être partagée (même nom de fichier téléversé deux fois, avatar...)
+	var orphans []string
+	for _, image := range images {
+		used, err := imageInUse(tx, image)
+		if err != nil {
+			return nil, err
+		}
+		if !used {
+			orphans = append(orphans, image)
+		}
+	}
+	return orphans, tx.Commit()
+}
+
+// ImageInUse indique si un post, une révision ou un avatar référence l'image
+func (r *postRepo) ImageInUse(image string) (bool, error) {
+	return imageInUse(r.db, image)
+}
+
+func imageInUse(q querier, image string) (bool, error) {
+	var used bool
+	err := q.QueryRow(`SELECT EXISTS (SELECT 1 FROM posts WHERE image_path = ?)
+		OR EXISTS (SELECT 1 FROM post_revisions WHERE image_path = ?)
+		OR EXISTS (SELECT 1 FROM users WHERE avatar = ?)`, image, image, image).Scan(&used)
+	return used, err
+}
+
+// Revisions renvoie les versions précédentes du post, de la plus récente à la plus ancienne
+func (r *postRepo) Revisions(postID uuid.UUID) ([]models.PostRevision, error) {
+	rows, err := r.db.Query(`
+		SELECT id, post_id, title, content, visibility, COALESCE(image_path, ''), allowed_users, edited_by, replaced_at
+		FROM post_revisions
+		WHERE post_id = ?
+		ORDER BY replaced_at DESC`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := []models.PostRevision{}
+	for rows.Next() {
+		var rev models.PostRevision
+		var allowed string
+		if err := rows.Scan(&rev.ID, &rev.PostID, &rev.Title, &rev.Content, &rev.Visibility, &rev.ImagePath, &allowed, &rev.EditedBy, &rev.ReplacedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal([]byte(allowed), &rev.AllowedUsers); err != nil {
+			return nil, fmt.Errorf("invalid allowed_users in revision %s: %w", rev.ID, err)
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+// querier est satisfait par *sql.DB et *sql.Tx
+type querier interface {
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+func allowedUsers(q querier, postID uuid.UUID) ([]uuid.UUID, error) {
+	rows, err := q.Query(`SELECT user_id FROM post_allowed_users WHERE post_id = ?`, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		users = append(users, id)
+	}
+	return users, rows.Err()
+}
+
+// postImages renvoie les images distinctes du post et de ses révisions
+func postImages(q querier, postID uuid.UUID) ([]string, error) {
+	rows, err := q.Query(`
+		SELECT image_path FROM posts WHERE id = ? AND image_path <> ''
+		UNION
+		SELECT image_path FROM post_revisions WHERE post_id = ? AND image_path <> ''`, postID, postID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var images []string
+	for rows.Next() {
+		var image string
+		if err := rows.Scan(&image); err != nil {
+			return nil, err
+		}
+		images = append(images, image)
+	}
+	return images, rows.Err()
+}
+
+// ListVisible renvoie au plus limit posts que viewerID a le droit de voir, du plus récent au plus ancien,
+// à partir de after, et le curseur de la page suivante
+func (r *postRepo) ListVisible(viewerID uuid.UUID, after Cursor, limit int) ([]models.Post, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "p.created_at", "p.id", true)
+	query := `
+		SELECT
+			p.id, p.user_id, p.title, p.content, p.image_path, p.visibility, p.created_at, p.edited_at, u.username, u.avatar,
+			(SELECT COUNT(*) FROM post_interactions WHERE post_id = p.id AND interaction_type = 'like') AS total_likes,
+			EXISTS(SELECT 1 FROM post_interactions WHERE post_id = p.id AND user_id = ? AND interaction_type = 'like') AS liked_by_user
+		FROM posts p
+		LEFT JOIN users u ON p.user_id = u.id
+		WHERE ` + postVisibleTo("p") + ` AND ` + keyset + `
+		ORDER BY p.created_at DESC, p.id DESC
+		LIMIT ?
+	`
+
+	args := append([]any{viewerID}, visibleArgs(viewerID)...)
+	args = append(args, keysetArgs...)
+	rows, err := r.db.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, Cursor{}, err
+	}
+	defer rows.Close()
+
+	posts := []models.Post{}
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.ImagePath, &post.Visibility, &post.CreatedAt, &post.EditedAt, &post.Username, &post.Avatar, &post.TotalLikes, &post.LikedByUser); err != nil {
+			return nil, Cursor{}, err
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Cursor{}, err
+	}
+	posts, next := pageOf(posts, limit, func(p models.Post) Cursor { return Cursor{p.CreatedAt, p.ID} })
+	return posts, next, nil
+}
+
+// ListByUser renvoie au plus limit posts de userID, du plus récent au plus ancien, à partir de after,
+// et le curseur de la page suivante (page "mon profil" : l'auteur voit tous ses posts)
+func (r *postRepo) ListByUser(userID uuid.UUID, after Cursor, limit int) ([]models.Post, Cursor, error) {
+	keyset, keysetArgs := keysetAfter(after, "p.created_at", "p.id", true)
+	query := `SELECT p.id, p.title, p.content, p.image_path, p.user_id, p.created_at
+			  FROM posts p
+			  WHERE p.user_id = ? AND ` + notHidden("post", "p.id") + ` AND ` + keyset + `
+			  ORDER BY p.created_at DESC, p.id DESC
+			  LIMIT ?`
+
+	args := append([]any{userID}, keysetArgs...)
+	rows, err := r.db.Query(query, append(args, limit+1)...)
+	if err != nil {
+		return nil, Cursor{}, fmt.Errorf("failed to query posts: %w", err)
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.Title, &post.Content, &post.ImagePath, &post.UserID, &post.CreatedAt); err != nil {
+			return nil, Cursor{}, fmt.Errorf("failed to scan post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, Cursor{}, fmt.Errorf("rows iteration error: %w", err)
+	}
+	posts, next := pageOf(posts, limit, func(p models.Post) Cursor { return Cursor{p.CreatedAt, p.ID} })
+	return posts, next, nil
+}
+
+// ListAllByUser renvoie les posts de authorID que viewerID a le droit de voir (page de profil)
+func (r *postRepo) ListAllByUser(authorID, viewerID uuid.UUID) ([]models.Post, error) {
+	query := `SELECT p.id, p.user_id, p.title, p.content, p.created_at, p.edited_at, p.visibility, p.image_path
+		FROM posts p
+		WHERE p.user_id = ? AND ` + postVisibleTo("p") + `
+		ORDER BY p.created_at DESC`
+	rows, err := r.db.Query(query, append([]any{authorID}, visibleArgs(viewerID)...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var posts []models.Post
+	for rows.Next() {
+		var post models.Post
+		if err := rows.Scan(&post.ID, &post.UserID, &post.Title, &post.Content, &post.CreatedAt, &post.EditedAt, &post.Visibility, &post.ImagePath); err != nil {
+			return nil, err
+		}
+		posts = append(posts, post)
+	}
+	return posts, rows.Err()
+}
+
+// SetPostLike pose (liked) ou retire le like de userID sur le post. L'opération est idempotente :
+// total_likes ne change que si une ligne a réellement été ajoutée ou supprimée
+func (r *postRepo) SetPostLike(postID, userID uuid.UUID, liked bool) error {
+	return postInteractions.set(r.db, postID, userID, liked)
+}
+
+// ToggleReaction applique la bascule "like" / "unlike" des routes historiques /like_post et /unlike_post
+func (r *postRepo) ToggleReaction(postID, userID uuid.UUID, reaction string) error {
+	return postInteractions.toggle(r.db, postID, userID, reaction)
+}
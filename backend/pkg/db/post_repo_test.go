@@ -0,0 +1,64 @@
+package db_test
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// TestPostDeleteModeration vérifie que la suppression d'un post emporte les signalements et masquages
+// du post et de ses commentaires, sans toucher à ceux d'un autre post
+func TestPostDeleteModeration(t *testing.T) {
+	dbtest.Each(t, func(t *testing.T, store *db.DBStore) {
+		author := dbtest.User(t, store, "author")
+		reporter := dbtest.User(t, store, "reporter")
+		moderation := store.Moderation()
+
+		type target struct{ kind, id string }
+		targets := func(postID uuid.UUID) []target {
+			comment := dbtest.Comment(t, store, postID, author, "comment")
+			return []target{{"post", postID.String()}, {"comment", comment.String()}}
+		}
+		deleted := dbtest.Post(t, store, author, "public")
+		kept := dbtest.Post(t, store, author, "public")
+		deletedTargets, keptTargets := targets(deleted), targets(kept)
+
+		now := time.Now()
+		for _, tg := range append(deletedTargets, keptTargets...) {
+			err := moderation.CreateReport(models.Report{
+				ID: uuid.Must(uuid.NewV4()), ReporterID: reporter, TargetType: tg.kind, TargetID: tg.id,
+				Reason: "spam", Status: models.ReportStatusOpen, CreatedAt: now, UpdatedAt: now,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := moderation.Hide(tg.kind, tg.id, "spam", reporter); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if _, err := store.Posts().Delete(deleted); err != nil {
+			t.Fatal(err)
+		}
+
+		count := func(tg target) (reports, hidden int) {
+			reports = dbtest.Scalar[int](t, store.DB(), `SELECT COUNT(*) FROM reports WHERE target_type = ? AND target_id = ?`, tg.kind, tg.id)
+			hidden = dbtest.Scalar[int](t, store.DB(), `SELECT COUNT(*) FROM hidden_content WHERE target_type = ? AND target_id = ?`, tg.kind, tg.id)
+			return reports, hidden
+		}
+		for _, tg := range deletedTargets {
+			if reports, hidden := count(tg); reports != 0 || hidden != 0 {
+				t.Errorf("deleted %s: %d reports, %d hidden rows left", tg.kind, reports, hidden)
+			}
+		}
+		for _, tg := range keptTargets {
+			if reports, hidden := count(tg); reports != 1 || hidden != 1 {
+				t.Errorf("other %s: %d reports, %d hidden rows; want 1 and 1", tg.kind, reports, hidden)
+			}
+		}
+	})
+}
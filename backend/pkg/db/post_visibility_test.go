@@ -0,0 +1,49 @@
+package db_test
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"slices"
+	"testing"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestPostVisibilityRepo(t *testing.T) {
+	f := dbtest.Visibility(t)
+	posts := f.Store.Posts()
+
+	f.EachCase(t, func(t *testing.T, viewer, postID uuid.UUID, visibility string, want bool) {
+		if got, err := posts.Visible(postID, viewer); err != nil || got != want {
+			t.Errorf("Visible = %v, %v; want %v", got, err, want)
+		}
+
+		feed, err := f.Store.Feed().Candidates(viewer, db.FeedQuery{Discover: true, Limit: 100})
+		if err != nil {
+			t.Fatal(err)
+		}
+		inFeed := slices.ContainsFunc(feed, func(item models.FeedItem) bool { return item.ID == postID })
+		if inFeed != want {
+			t.Errorf("discover feed contains post = %v; want %v", inFeed, want)
+		}
+
+		profile, err := posts.ListAllByUser(f.Author, viewer)
+		if err != nil {
+			t.Fatal(err)
+		}
+		onProfile := slices.ContainsFunc(profile, func(p models.Post) bool { return p.ID == postID })
+		if onProfile != want {
+			t.Errorf("profile contains post = %v; want %v", onProfile, want)
+		}
+
+		list, _, err := posts.ListVisible(viewer, db.Cursor{}, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		listed := slices.ContainsFunc(list, func(p models.Post) bool { return p.ID == postID })
+		if listed != want {
+			t.Errorf("ListVisible contains post = %v; want %v", listed, want)
+		}
+	})
+}
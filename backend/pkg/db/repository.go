@@ -0,0 +1,230 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+var (
+	ErrNotFound      = errors.New("not found")
+	ErrEmailTaken    = errors.New("email already exists")
+	ErrUsernameTaken = errors.New("username already exists")
+	ErrAlreadyExists = errors.New("already exists")
+	ErrNoFields      = errors.New("no fields to update")
+
+	ErrSessionRevoked   = errors.New("session revoked")
+	ErrTokenInvalid     = errors.New("invalid or expired token")
+	ErrTokenReused      = errors.New("token reuse detected")
+	ErrEmailNotVerified = errors.New("email not verified")
+)
+
+// UserRepo regroupe les accès à la table users
+type UserRepo interface {
+	Create(user models.User) error
+	ByEmail(email string) (models.User, error)
+	Email(id uuid.UUID) (string, error)
+	IDByEmail(email string) (uuid.UUID, error)
+	EmailVerified(id uuid.UUID) (bool, error)
+	Credentials(identifier string) (id uuid.UUID, passwordHash, username string, err error)
+	Role(id uuid.UUID) (string, error)
+	SetRole(id uuid.UUID, role string) error
+	ClearPassword(id uuid.UUID) error
+	UsernameByID(id uuid.UUID) (string, error)
+	IDByUsername(username string) (uuid.UUID, error)
+	Avatar(id uuid.UUID) (sql.NullString, error)
+	IsPrivate(id uuid.UUID) (bool, error)
+	Profile(id uuid.UUID) (models.UserProfil, error)
+	UpdateProfile(id uuid.UUID, profile models.UserProfil) error
+	List(viewerID uuid.UUID, limit, offset int) ([]models.UserSummary, error)
+	Search(viewerID uuid.UUID, prefix string, limit int) ([]models.UserSummary, error)
+	RecordLoginAttempt(attempt models.LoginAttempt) error
+}
+
+// PostRepo regroupe les accès aux posts et à leurs réactions
+type PostRepo interface {
+	Create(post models.Post) (uuid.UUID, error)
+	Get(id, viewerID uuid.UUID) (models.Post, error)
+	Visible(postID, viewerID uuid.UUID) (bool, error)
+	Author(postID uuid.UUID) (uuid.UUID, error)
+	Update(post models.Post, editorID uuid.UUID, editedAt time.Time) error
+	Delete(id uuid.UUID) (orphanImages []string, err error)
+	ImageInUse(image string) (bool, error)
+	Revisions(postID uuid.UUID) ([]models.PostRevision, error)
+	ListVisible(viewerID uuid.UUID, after Cursor, limit int) ([]models.Post, Cursor, error)
+	ListByUser(userID uuid.UUID, after Cursor, limit int) ([]models.Post, Cursor, error)
+	ListAllByUser(authorID, viewerID uuid.UUID) ([]models.Post, error)
+	SetPostLike(postID, userID uuid.UUID, liked bool) error
+	ToggleReaction(postID, userID uuid.UUID, reaction string) error
+}
+
+// CommentRepo regroupe les commentaires des posts et leurs réactions
+type CommentRepo interface {
+	Create(comment models.Comment) error
+	PostID(commentID uuid.UUID) (uuid.UUID, error)
+	List(postID, viewerID uuid.UUID, after Cursor, limit int) ([]models.Comment, Cursor, error)
+	SetLike(commentID, userID uuid.UUID, liked bool) error
+	ToggleReaction(commentID, userID uuid.UUID, reaction string) error
+}
+
+// FeedRepo fournit les candidats du fil d'actualité, classés ensuite par le package feed
+type FeedRepo interface {
+	Candidates(viewerID uuid.UUID, q FeedQuery) ([]models.FeedItem, error)
+}
+
+// FeedQuery choisit les candidats du fil d'actualité
+type FeedQuery struct {
+	Discover bool      // false : abonnements acceptés, posts du lecteur et de ses groupes ; true : en plus tout post qu'il peut voir
+	Since    time.Time // candidats publiés depuis Since, sans borne si zéro
+	After    Cursor    // candidats publiés avant le curseur, dans l'ordre (created_at, id) décroissant
+	Limit    int       // nombre maximal de candidats par source, les plus récents
+}
+
+// FollowRepo regroupe les abonnements et les demandes d'abonnement
+type FollowRepo interface {
+	IsFollower(userID, followerID uuid.UUID) (bool, error)
+	IsFollowing(followerID, followedID uuid.UUID) (bool, error)
+	Followers(userID uuid.UUID) ([]models.SimpleUser, error)
+	Following(userID uuid.UUID) ([]models.SimpleUser, error)
+	Friends(userID uuid.UUID, limit, offset int) ([]models.UserSummary, error)
+	Follow(followerID, followedID uuid.UUID) error
+	Unfollow(followerID, followedID uuid.UUID) error
+
+	RequestExists(senderID, receiverID uuid.UUID) (bool, error)
+	CreateRequest(senderID, receiverID uuid.UUID) error
+	PendingRequests(receiverID uuid.UUID) ([]models.PendingFollowRequest, error)
+	Request(requestID uuid.UUID) (senderID, receiverID uuid.UUID, err error)
+	AcceptRequest(requestID, senderID, receiverID uuid.UUID) error
+	DeleteRequest(requestID uuid.UUID) error
+}
+
+// GroupRepo regroupe les groupes, leurs membres, posts, commentaires et événements
+type GroupRepo interface {
+	Create(group models.Group) error
+	Get(groupID string) (models.Group, error)
+	Creator(groupID uuid.UUID) (uuid.UUID, error)
+	ListForMember(userID uuid.UUID, after Cursor, limit int) ([]models.Group, Cursor, error)
+	MembershipCount(userID uuid.UUID) (int, error)
+	Members(groupID string) ([]models.GroupMember, error)
+	MemberStatus(groupID, userID uuid.UUID) (string, error)
+	InvitedBy(groupID, userID uuid.UUID) (uuid.UUID, error)
+	AddPendingMember(groupID, userID uuid.UUID) error
+	InviteMember(groupID, userID, inviterID uuid.UUID) error
+	AcceptMember(groupID, userID uuid.UUID) error
+
+	CreatePost(post models.PostGroup) error
+	PostGroupID(postID uuid.UUID) (uuid.UUID, error)
+	AllPosts(groupID string) ([]models.PostGroup, error)
+	ListPosts(groupID uuid.UUID, limit, offset int) ([]models.PostGroup, error)
+	CreateComment(comment models.CommentPostGroup) error
+	ListComments(postID uuid.UUID, limit, offset int) ([]models.CommentPostGroup, error)
+
+	CreateEvent(event models.GroupEvent) error
+	EventGroupID(eventID uuid.UUID) (uuid.UUID, error)
+	ListEvents(groupID uuid.UUID, after Cursor, limit int) ([]models.GroupEvent, Cursor, error)
+	RespondToEvent(eventID, userID uuid.UUID, response string) error
+	InviteToEvent(eventID, userID uuid.UUID) error
+	UserVotes(userID, groupID uuid.UUID) ([]models.EventResponse, error)
+}
+
+// MessageRepo regroupe l'historique des messages privés et des messages de groupe
+type MessageRepo interface {
+	SavePrivate(msg models.Message) error
+	PrivateHistory(username string, after Cursor, limit int) ([]models.Message, Cursor, error)
+	SaveGroup(msg models.Message) error
+	GroupHistory(target string, after Cursor, limit int) ([]models.Message, Cursor, error)
+}
+
+// NotificationRepo regroupe les notifications des utilisateurs
+type NotificationRepo interface {
+	Add(userID, senderID, content, notificationType string) error
+	ListUnread(userID uuid.UUID, after Cursor, limit int) ([]models.Notification, Cursor, error)
+	MarkRead(notificationID string) error
+}
+
+// ModerationRepo regroupe les signalements et le masquage des contenus
+type ModerationRepo interface {
+	TargetContent(targetType, targetID string) (string, error)
+	IsMessageParticipant(messageID, username string) (bool, error)
+	CreateReport(report models.Report) error
+	Report(reportID uuid.UUID) (models.Report, error)
+	ListReports(filter ReportFilter, limit, offset int) ([]models.Report, error)
+	AssignReport(reportID, assigneeID uuid.UUID, at time.Time) error
+	ResolveReport(report models.Report, status, resolution string, hide bool, moderatorID uuid.UUID, at time.Time) error
+	Hide(targetType, targetID, reason string, moderatorID uuid.UUID) error
+	Unhide(targetType, targetID string) (bool, error)
+}
+
+// SessionRepo regroupe les sessions (appareils connectés) et leurs refresh tokens, stockés par empreinte
+type SessionRepo interface {
+	Create(session models.Session, refreshHash string) error
+	Rotate(presentedHash, newHash string, now time.Time) (models.Session, error)
+	Get(sessionID uuid.UUID) (models.Session, error)
+	ByRefreshToken(tokenHash string) (uuid.UUID, error)
+	Touch(sessionID uuid.UUID, now, since time.Time) error
+	ListActive(userID uuid.UUID, now time.Time) ([]models.Session, error)
+	Revoke(sessionID uuid.UUID) error
+	RevokeForUser(userID, sessionID uuid.UUID) (bool, error)
+	RevokeOthers(userID, keepSessionID uuid.UUID) ([]uuid.UUID, error)
+}
+
+// MFARepo regroupe les secrets TOTP, les codes de secours et les rôles soumis à la 2FA
+type MFARepo interface {
+	Status(userID uuid.UUID) (enabled bool, required bool, err error)
+	Enroll(userID uuid.UUID, secret string, now time.Time) error
+	PendingSecret(userID uuid.UUID) (string, error)
+	Enable(userID uuid.UUID, step int64, codeHashes []string, now time.Time) error
+	Secret(userID uuid.UUID) (secret string, lastStep int64, err error)
+	UseStep(userID uuid.UUID, step int64) (bool, error)
+	UseRecoveryCode(userID uuid.UUID, codeHash string, now time.Time) (bool, error)
+	Disable(userID uuid.UUID) error
+	RequiredRoles() ([]string, error)
+	SetRequiredRoles(roles []string) error
+}
+
+// AdminRepo regroupe les suspensions de comptes et le journal d'audit de l'administration
+type AdminRepo interface {
+	ActiveSuspension(userID uuid.UUID, now time.Time) (models.Suspension, error)
+	Suspend(sus models.Suspension) error
+	LiftSuspensions(userID, liftedBy uuid.UUID, now time.Time) (int64, error)
+	ListUsers(filter AdminUserFilter, now time.Time, limit, offset int) ([]models.AdminUser, error)
+	RecordAction(entry models.AuditEntry) error
+	AuditLog(targetUserID *uuid.UUID, limit, offset int) ([]models.AuditEntry, error)
+}
+
+// AccountTokenRepo regroupe les tokens à usage unique envoyés par email, stockés par empreinte
+type AccountTokenRepo interface {
+	Create(userID uuid.UUID, purpose, tokenHash string, now, expiresAt time.Time) error
+	VerifyEmail(tokenHash string, now time.Time) (uuid.UUID, error)
+	ResetPassword(tokenHash, passwordHash string, now time.Time) (uuid.UUID, error)
+}
+
+// IdentityRepo regroupe les identités OAuth liées aux comptes
+type IdentityRepo interface {
+	User(provider, subject string, now time.Time) (uuid.UUID, string, error)
+	Link(identity Identity, now time.Time) (userID uuid.UUID, username string, created bool, err error)
+}
+
+// ReportFilter choisit les signalements de la file de modération, un champ vide ne filtre pas
+type ReportFilter struct {
+	Status     string     // open, in_review, resolved ou dismissed
+	TargetType string     // voir IsReportTarget
+	AssignedTo *uuid.UUID // uuid.Nil : signalements non attribués
+}
+
+// notHidden renvoie la condition SQL excluant les contenus masqués par la modération,
+// column étant la colonne d'identifiant du contenu dans la requête (ex. "p.id")
+func notHidden(targetType, column string) string {
+	return `NOT EXISTS (SELECT 1 FROM hidden_content hc WHERE hc.target_type = '` + targetType + `' AND hc.target_id = ` + column + `)`
+}
+
+// notFound traduit sql.ErrNoRows en ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,88 @@
+package db
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// expectedSchema liste les tables et colonnes lues ou écrites par le code.
+// À tenir à jour avec les migrations : CheckSchema refuse de démarrer s'il en manque une
+var expectedSchema = map[string][]string{
+	"users":                {"id", "username", "age", "email", "password_hash", "first_name", "last_name", "role", "gender", "date_of_birth", "avatar", "bio", "phone_number", "address", "is_private", "created_at", "updated_at", "email_verified_at"},
+	"posts":                {"id", "title", "content", "user_id", "visibility", "created_at", "image_path", "total_likes", "total_unlikes", "edited_at"},
+	"post_revisions":       {"id", "post_id", "title", "content", "visibility", "image_path", "allowed_users", "edited_by", "replaced_at"},
+	"post_allowed_users":   {"post_id", "user_id"},
+	"comments":             {"id", "post_id", "content", "user_id", "username", "created_at", "total_likes", "total_unlikes"},
+	"post_interactions":    {"id", "post_id", "user_id", "interaction_type"},
+	"comment_interactions": {"id", "comment_id", "user_id", "interaction_type"},
+	"followers":            {"id", "follower_id", "followed_id", "status", "created_at"},
+	"follow_requests":      {"id", "sender_id", "receiver_id", "created_at"},
+	"groups":               {"id", "name", "description", "creator_id", "created_at"},
+	"group_members":        {"id", "group_id", "user_id", "status", "role", "invited_by"},
+	"group_posts":          {"id", "group_id", "user_id", "title", "content", "created_at", "updated_at"},
+	"group_posts_comments": {"id", "post_id", "content", "user_id", "username", "created_at"},
+	"group_events":         {"id", "group_id", "user_id", "title", "description", "event_date", "created_at", "options"},
+	"event_responses":      {"id", "event_id", "user_id", "response"},
+	"event_invitations":    {"event_id", "user_id"},
+	"notifications":        {"id", "user_id", "sender_id", "content", "type", "created_at", "read"},
+	"chatHistory":          {"id", "sender_username", "target_username", "content", "timestamp", "type", "emoji"},
+	"chatGroup":            {"id", "sender_username", "target_username", "content", "timestamp", "type", "emoji"},
+	"sessions":             {"id", "user_id", "device", "user_agent", "ip_address", "created_at", "last_used_at", "expires_at", "revoked_at"},
+	"refresh_tokens":       {"id", "session_id", "token_hash", "created_at", "used_at"},
+	"user_identities":      {"id", "user_id", "provider", "subject", "email", "created_at", "last_login_at"},
+	"user_tokens":          {"id", "user_id", "purpose", "token_hash", "created_at", "expires_at", "used_at"},
+	"user_mfa":             {"user_id", "secret", "enabled_at", "last_used_step", "created_at"},
+	"mfa_recovery_codes":   {"id", "user_id", "code_hash", "created_at", "used_at"},
+	"mfa_required_roles":   {"role"},
+	"login_attempts":       {"id", "identifier", "user_id", "ip_address", "user_agent", "success", "reason", "created_at"},
+	"login_throttle":       {"key", "failures", "last_failure_at", "blocked_until"},
+	"user_suspensions":     {"id", "user_id", "kind", "reason", "expires_at", "created_by", "created_at", "lifted_at", "lifted_by"},
+	"admin_audit_log":      {"id", "actor_id", "action", "target_user_id", "details", "ip_address", "created_at"},
+	"reports":              {"id", "reporter_id", "target_type", "target_id", "reason", "details", "status", "assigned_to", "resolution", "resolved_by", "resolved_at", "created_at", "updated_at"},
+	"hidden_content":       {"target_type", "target_id", "reason", "hidden_by", "hidden_at"},
+}
+
+// CheckSchema vérifie, après les migrations, que chaque table et colonne attendue existe.
+// Toutes les absences sont rapportées d'un coup pour corriger la base en une fois
+func (s *DBStore) CheckSchema() error {
+	tables := make([]string, 0, len(expectedSchema))
+	for table := range expectedSchema {
+		tables = append(tables, table)
+	}
+	sort.Strings(tables)
+
+	var problems []string
+	for _, table := range tables {
+		// LIMIT 0 ne lit aucune ligne mais renvoie les colonnes, sur SQLite comme sur Postgres
+		rows, err := s.db.Query(`SELECT * FROM ` + table + ` LIMIT 0`)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("missing table %s", table))
+			continue
+		}
+		columns, err := rows.Columns()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+
+		present := make(map[string]bool, len(columns))
+		for _, c := range columns {
+			present[strings.ToLower(c)] = true
+		}
+		var missing []string
+		for _, c := range expectedSchema[table] {
+			if !present[strings.ToLower(c)] {
+				missing = append(missing, c)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("table %s is missing columns: %s", table, strings.Join(missing, ", ")))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("schema check failed (%d problems):\n  - %s", len(problems), strings.Join(problems, "\n  - "))
+	}
+	return nil
+}
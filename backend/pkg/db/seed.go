@@ -0,0 +1,106 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// SeedPassword est le mot de passe de tous les comptes générés par Seed
+const SeedPassword = "Passw0rd!seed"
+
+var (
+	seedFirstNames = []string{"Camille", "Lucas", "Léa", "Hugo", "Chloé", "Louis", "Manon", "Nathan", "Inès", "Jules", "Sarah", "Adam", "Emma", "Yanis", "Jade", "Karim", "Lina", "Théo", "Zoé", "Malik"}
+	seedLastNames  = []string{"Martin", "Bernard", "Dubois", "Durand", "Leroy", "Moreau", "Simon", "Laurent", "Lefebvre", "Michel", "Garcia", "Roux", "Fournier", "Girard", "Benali", "Diallo", "Nguyen", "Mercier", "Blanc", "Faure"}
+	seedBios       = []string{"Passionné de randonnée et de photo.", "Développeuse le jour, musicienne la nuit.", "Toujours partant pour un café.", "Fan de cinéma coréen.", "J'apprends le japonais, doucement.", "Cuisine, voyages et jeux de société.", ""}
+	seedTopics     = []string{"week-end à la montagne", "nouvelle recette de lasagnes", "concert de samedi", "projet Go du moment", "livre de l'été", "match d'hier soir", "sortie vélo", "exposition au musée", "premier marathon", "jardin partagé"}
+	seedSentences  = []string{"Franchement, je recommande.", "Quelqu'un a déjà essayé ?", "C'était encore mieux que prévu.", "Je posterai des photos bientôt.", "Merci à tous ceux qui sont venus !", "On remet ça le mois prochain ?", "Petit retour d'expérience ci-dessous.", "J'ai appris plein de choses."}
+)
+
+// Seed crée users comptes vérifiés (mot de passe SeedPassword), quelques abonnements entre eux et posts publications
+func (s *DBStore) Seed(users, posts int) error {
+	if users <= 0 {
+		return errors.New("at least one user is required")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(SeedPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	suffix := time.Now().Unix() % 100000
+	ids := make([]uuid.UUID, 0, users)
+
+	for i := 0; i < users; i++ {
+		first := seedFirstNames[rng.Intn(len(seedFirstNames))]
+		last := seedLastNames[rng.Intn(len(seedLastNames))]
+		username := fmt.Sprintf("%s.%s%d_%d", strings.ToLower(asciiName(first)), strings.ToLower(asciiName(last)), suffix, i)
+		gender := "Femme"
+		if rng.Intn(2) == 0 {
+			gender = "Homme"
+		}
+
+		user := models.User{
+			ID:        uuid.Must(uuid.NewV4()),
+			Username:  username,
+			Age:       18 + rng.Intn(50),
+			Email:     username + "@example.com",
+			Password:  string(hash),
+			FirstName: models.NullString{NullString: sql.NullString{String: first, Valid: true}},
+			LastName:  models.NullString{NullString: sql.NullString{String: last, Valid: true}},
+			Role:      "user",
+			Gender:    gender,
+			Bio:       models.NullString{NullString: sql.NullString{String: seedBios[rng.Intn(len(seedBios))], Valid: true}},
+			IsPrivate: rng.Intn(5) == 0,
+		}
+		if err := s.users.Create(user); err != nil {
+			return fmt.Errorf("failed to seed user %s: %w", username, err)
+		}
+		if _, err := s.db.Exec(`UPDATE users SET email_verified_at = CURRENT_TIMESTAMP WHERE id = ?`, user.ID); err != nil {
+			return err
+		}
+		ids = append(ids, user.ID)
+	}
+
+	// chacun suit jusqu'à trois autres comptes générés
+	for _, follower := range ids {
+		for _, j := range rng.Perm(len(ids))[:min(3, len(ids))] {
+			if ids[j] == follower {
+				continue
+			}
+			if err := s.follows.Follow(follower, ids[j]); err != nil {
+				return fmt.Errorf("failed to seed follow: %w", err)
+			}
+		}
+	}
+
+	for i := 0; i < posts; i++ {
+		topic := seedTopics[rng.Intn(len(seedTopics))]
+		var content []string
+		for n := 1 + rng.Intn(3); n > 0; n-- {
+			content = append(content, seedSentences[rng.Intn(len(seedSentences))])
+		}
+		post := models.Post{
+			UserID:     ids[rng.Intn(len(ids))],
+			Title:      strings.ToUpper(topic[:1]) + topic[1:],
+			Content:    "À propos : " + topic + ". " + strings.Join(content, " "),
+			Visibility: "public",
+		}
+		if _, err := s.posts.Create(post); err != nil {
+			return fmt.Errorf("failed to seed post: %w", err)
+		}
+	}
+	return nil
+}
+
+// asciiName retire les accents pour construire des identifiants lisibles
+func asciiName(name string) string {
+	return strings.NewReplacer("é", "e", "è", "e", "ë", "e", "ï", "i", "ô", "o").Replace(name)
+}
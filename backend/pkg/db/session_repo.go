@@ -0,0 +1,197 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type sessionRepo struct {
+	db *sql.DB
+}
+
+// Create enregistre la session et son premier refresh token (empreinte refreshHash)
+func (r *sessionRepo) Create(session models.Session, refreshHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT INTO sessions (id, user_id, device, user_agent, ip_address, created_at, last_used_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		session.ID, session.UserID, session.Device, session.UserAgent, session.IPAddress, session.CreatedAt, session.LastUsedAt, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert session: %w", err)
+	}
+
+	_, err = tx.Exec(`INSERT INTO refresh_tokens (id, session_id, token_hash, created_at) VALUES (?, ?, ?, ?)`,
+		uuid.Must(uuid.NewV4()), session.ID, refreshHash, session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert refresh token: %w", err)
+	}
+	return tx.Commit()
+}
+
+// Rotate consomme le refresh token d'empreinte presentedHash et enregistre newHash pour la même session.
+// Un token inconnu ou expiré donne ErrTokenInvalid ; un token déjà consommé révoque la session et donne ErrTokenReused
+func (r *sessionRepo) Rotate(presentedHash, newHash string, now time.Time) (models.Session, error) {
+	var session models.Session
+	var tokenID uuid.UUID
+	var usedAt sql.NullTime
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return session, err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		SELECT rt.id, rt.used_at, s.id, s.user_id, s.expires_at, s.revoked_at
+		FROM refresh_tokens rt
+		JOIN sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = ?`, presentedHash).Scan(&tokenID, &usedAt, &session.ID, &session.UserID, &session.ExpiresAt, &session.RevokedAt)
+	if err == sql.ErrNoRows {
+		return session, ErrTokenInvalid
+	}
+	if err != nil {
+		return session, err
+	}
+
+	if session.RevokedAt.Valid {
+		return session, ErrSessionRevoked
+	}
+	if now.After(session.ExpiresAt) {
+		return session, ErrTokenInvalid
+	}
+
+	if usedAt.Valid {
+		if _, err := tx.Exec(`UPDATE sessions SET revoked_at = ? WHERE id = ?`, now, session.ID); err != nil {
+			return session, err
+		}
+		if err := tx.Commit(); err != nil {
+			return session, err
+		}
+		return session, ErrTokenReused
+	}
+
+	// le "used_at IS NULL" protège contre deux rotations concurrentes du même token
+	res, err := tx.Exec(`UPDATE refresh_tokens SET used_at = ? WHERE id = ? AND used_at IS NULL`, now, tokenID)
+	if err != nil {
+		return session, err
+	}
+	if n, _ := res.RowsAffected(); n != 1 {
+		return session, ErrTokenReused
+	}
+
+	_, err = tx.Exec(`INSERT INTO refresh_tokens (id, session_id, token_hash, created_at) VALUES (?, ?, ?, ?)`,
+		uuid.Must(uuid.NewV4()), session.ID, newHash, now)
+	if err != nil {
+		return session, err
+	}
+
+	if _, err := tx.Exec(`UPDATE sessions SET last_used_at = ? WHERE id = ?`, now, session.ID); err != nil {
+		return session, err
+	}
+	session.LastUsedAt = now
+
+	return session, tx.Commit()
+}
+
+// Get relit une session, ErrNotFound si elle n'existe pas
+func (r *sessionRepo) Get(sessionID uuid.UUID) (models.Session, error) {
+	session := models.Session{ID: sessionID}
+	err := r.db.QueryRow(`SELECT user_id, expires_at, revoked_at FROM sessions WHERE id = ?`, sessionID).
+		Scan(&session.UserID, &session.ExpiresAt, &session.RevokedAt)
+	return session, notFound(err)
+}
+
+// ByRefreshToken retrouve la session d'un refresh token sans le consommer, ErrNotFound s'il est inconnu
+func (r *sessionRepo) ByRefreshToken(tokenHash string) (uuid.UUID, error) {
+	var sessionID uuid.UUID
+	err := r.db.QueryRow(`SELECT session_id FROM refresh_tokens WHERE token_hash = ?`, tokenHash).Scan(&sessionID)
+	return sessionID, notFound(err)
+}
+
+// Touch met à jour la dernière activité de la session si elle date d'avant since
+func (r *sessionRepo) Touch(sessionID uuid.UUID, now, since time.Time) error {
+	_, err := r.db.Exec(`UPDATE sessions SET last_used_at = ? WHERE id = ? AND last_used_at < ?`, now, sessionID, since)
+	return err
+}
+
+// ListActive renvoie les sessions non révoquées et non expirées à now, la plus récemment utilisée d'abord
+func (r *sessionRepo) ListActive(userID uuid.UUID, now time.Time) ([]models.Session, error) {
+	rows, err := r.db.Query(`
+		SELECT id, user_id, COALESCE(device, ''), COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_used_at, expires_at
+		FROM sessions
+		WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ?
+		ORDER BY last_used_at DESC`, userID, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		var session models.Session
+		if err := rows.Scan(&session.ID, &session.UserID, &session.Device, &session.UserAgent, &session.IPAddress,
+			&session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt); err != nil {
+			return nil, fmt.Errorf("failed to scan session: %w", err)
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// Revoke marque une session comme révoquée, ses tokens deviennent inutilisables
+func (r *sessionRepo) Revoke(sessionID uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND revoked_at IS NULL`, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+	return nil
+}
+
+// RevokeForUser révoque une session si elle appartient à userID et indique si elle l'a été
+func (r *sessionRepo) RevokeForUser(userID, sessionID uuid.UUID) (bool, error) {
+	res, err := r.db.Exec(`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE id = ? AND user_id = ? AND revoked_at IS NULL`, sessionID, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke session: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// RevokeOthers révoque toutes les sessions de userID sauf keepSessionID (uuid.Nil : toutes)
+// et renvoie les identifiants révoqués
+func (r *sessionRepo) RevokeOthers(userID, keepSessionID uuid.UUID) ([]uuid.UUID, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	rows, err := tx.Query(`SELECT id FROM sessions WHERE user_id = ? AND id != ? AND revoked_at IS NULL`, userID, keepSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions: %w", err)
+	}
+	var ids []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	_, err = tx.Exec(`UPDATE sessions SET revoked_at = CURRENT_TIMESTAMP WHERE user_id = ? AND id != ? AND revoked_at IS NULL`, userID, keepSessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to revoke sessions: %w", err)
+	}
+	return ids, tx.Commit()
+}
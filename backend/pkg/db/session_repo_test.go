@@ -0,0 +1,50 @@
+package db_test
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// TestSessionRotate vérifie la rotation des refresh tokens : un token ne sert qu'une fois
+// et son rejeu révoque toute la session
+func TestSessionRotate(t *testing.T) {
+	dbtest.Each(t, func(t *testing.T, store *db.DBStore) {
+		sessions := store.Sessions()
+		now := time.Now()
+		session := models.Session{
+			ID: uuid.Must(uuid.NewV4()), UserID: dbtest.User(t, store, "user"), Device: "test",
+			CreatedAt: now, LastUsedAt: now, ExpiresAt: now.Add(time.Hour),
+		}
+		if err := sessions.Create(session, "first"); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := sessions.Rotate("unknown", "x", now); !errors.Is(err, db.ErrTokenInvalid) {
+			t.Errorf("unknown token: err = %v; want ErrTokenInvalid", err)
+		}
+		if _, err := sessions.Rotate("first", "x", now.Add(2*time.Hour)); !errors.Is(err, db.ErrTokenInvalid) {
+			t.Errorf("expired session: err = %v; want ErrTokenInvalid", err)
+		}
+
+		rotated, err := sessions.Rotate("first", "second", now)
+		if err != nil || rotated.ID != session.ID {
+			t.Fatalf("Rotate = %v, %v; want session %s", rotated.ID, err, session.ID)
+		}
+
+		if _, err := sessions.Rotate("first", "third", now); !errors.Is(err, db.ErrTokenReused) {
+			t.Errorf("replayed token: err = %v; want ErrTokenReused", err)
+		}
+		if _, err := sessions.Rotate("second", "third", now); !errors.Is(err, db.ErrSessionRevoked) {
+			t.Errorf("token of a revoked session: err = %v; want ErrSessionRevoked", err)
+		}
+		if got, err := sessions.Get(session.ID); err != nil || !got.RevokedAt.Valid {
+			t.Errorf("session after replay: revoked = %v, err = %v; want revoked", got.RevokedAt.Valid, err)
+		}
+	})
+}
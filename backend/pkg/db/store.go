@@ -4,61 +4,153 @@ import (
 	"database/sql"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
+	"github.com/golang-migrate/migrate/v4/database/postgres"
 	"github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	_ "github.com/mattn/go-sqlite3"
 )
 
+const (
+	DefaultPath          = "pkg/db/data.db"
+	DefaultMigrationsDir = "pkg/db/migrations/sqlite"
+)
+
+// Store donne accès au pool de connexions partagé et aux repositories.
+// Il est ouvert une seule fois au démarrage (main.run) et fermé à l'arrêt
 type Store interface {
-	OpenDatabase() (*sql.DB, error)
-	CloseDatabase(db *sql.DB) error
+	Close() error
+
+	Users() UserRepo
+	Posts() PostRepo
+	Comments() CommentRepo
+	Feed() FeedRepo
+	Follows() FollowRepo
+	Groups() GroupRepo
+	Messages() MessageRepo
+	Notifications() NotificationRepo
+	Moderation() ModerationRepo
+	Sessions() SessionRepo
+	MFA() MFARepo
+	Admin() AdminRepo
+	AccountTokens() AccountTokenRepo
+	Identities() IdentityRepo
 }
 
-type DBStore struct{}
+type DBStore struct {
+	db     *sql.DB
+	driver string
+	cfg    Config
 
-func (s *DBStore) OpenDatabase() (*sql.DB, error) {
+	users         *userRepo
+	posts         *postRepo
+	comments      *commentRepo
+	feed          *feedRepo
+	follows       *followRepo
+	groups        *groupRepo
+	messages      *messageRepo
+	notifications *notificationRepo
+	moderation    *moderationRepo
+	sessions      *sessionRepo
+	mfa           *mfaRepo
+	admin         *adminRepo
+	accountTokens *accountTokenRepo
+	identities    *identityRepo
+}
 
-	db, err := sql.Open("sqlite3", "pkg/db/data.db")
+// Open ouvre le pool de connexions vers la base choisie par cfg et vérifie qu'elle répond
+func Open(cfg Config) (*DBStore, error) {
+	var db *sql.DB
+	var err error
+	switch cfg.Driver {
+	case DriverSQLite, "":
+		db, err = sql.Open(DriverSQLite, cfg.sqliteDSN(cfg.ForeignKeys))
+	case DriverPostgres:
+		db, err = openPostgres(cfg.DSN)
+	default:
+		return nil, fmt.Errorf("unsupported database driver %q", cfg.Driver)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	err = db.Ping()
-	if err != nil {
-		return nil, fmt.Errorf("failed to ping to database: %v", err)
+	db.SetMaxOpenConns(25)
+	db.SetMaxIdleConns(25)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
-	log.Println("Ping to database")
+	log.Printf("Ping to database (%s)", cfg.Driver)
 
-	// Applique les migrations à l'ouverture de la base de données
-	if err := s.ApplyMigrations(db); err != nil {
-		return nil, fmt.Errorf("failed to apply migrations: %v", &err)
+	store := NewStore(db)
+	store.cfg = cfg
+	if cfg.Driver == DriverPostgres {
+		store.driver = DriverPostgres
 	}
+	return store, nil
+}
 
-	return db, nil
+// NewStore construit le Store autour d'un pool déjà ouvert
+func NewStore(db *sql.DB) *DBStore {
+	return &DBStore{
+		db:            db,
+		driver:        DriverSQLite,
+		users:         &userRepo{db: db},
+		posts:         &postRepo{db: db},
+		comments:      &commentRepo{db: db},
+		feed:          &feedRepo{db: db},
+		follows:       &followRepo{db: db},
+		groups:        &groupRepo{db: db},
+		messages:      &messageRepo{db: db},
+		notifications: &notificationRepo{db: db},
+		moderation:    &moderationRepo{db: db},
+		sessions:      &sessionRepo{db: db},
+		mfa:           &mfaRepo{db: db},
+		admin:         &adminRepo{db: db},
+		accountTokens: &accountTokenRepo{db: db},
+		identities:    &identityRepo{db: db},
+	}
 }
 
-func (s *DBStore) CloseDatabase(db *sql.DB) error {
-	if err := db.Close(); err != nil {
+// DB donne le pool aux outils hors repositories (limiteur de connexion, tests), pas aux handlers
+func (s *DBStore) DB() *sql.DB { return s.db }
+
+// Driver renvoie le moteur utilisé (sqlite3 ou postgres)
+func (s *DBStore) Driver() string { return s.driver }
+
+func (s *DBStore) Users() UserRepo                 { return s.users }
+func (s *DBStore) Posts() PostRepo                 { return s.posts }
+func (s *DBStore) Comments() CommentRepo           { return s.comments }
+func (s *DBStore) Feed() FeedRepo                  { return s.feed }
+func (s *DBStore) Follows() FollowRepo             { return s.follows }
+func (s *DBStore) Groups() GroupRepo               { return s.groups }
+func (s *DBStore) Messages() MessageRepo           { return s.messages }
+func (s *DBStore) Notifications() NotificationRepo { return s.notifications }
+func (s *DBStore) Moderation() ModerationRepo      { return s.moderation }
+func (s *DBStore) Sessions() SessionRepo           { return s.sessions }
+func (s *DBStore) MFA() MFARepo                    { return s.mfa }
+func (s *DBStore) Admin() AdminRepo                { return s.admin }
+func (s *DBStore) AccountTokens() AccountTokenRepo { return s.accountTokens }
+func (s *DBStore) Identities() IdentityRepo        { return s.identities }
+
+func (s *DBStore) Close() error {
+	if err := s.db.Close(); err != nil {
 		return fmt.Errorf("failed to close database: %w", err)
 	}
-
 	return nil
 }
 
-// Fonction pour appliquer les migrations à la base de données
-func (s *DBStore) ApplyMigrations(db *sql.DB) error {
-	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
-	if err != nil {
-		return err
-	}
-	m, err := migrate.NewWithDatabaseInstance(
-		"file://pkg/db/migrations/sqlite",
-		"sqlite3", driver)
+// Migrate applique les migrations du dossier dir, une seule fois au démarrage
+func (s *DBStore) Migrate(dir string) error {
+	m, done, err := s.migrator(dir)
 	if err != nil {
 		return err
 	}
+	defer done()
 
 	// applique les migrations
 	log.Println("Applying migrations...")
@@ -70,3 +162,38 @@ func (s *DBStore) ApplyMigrations(db *sql.DB) error {
 
 	return nil
 }
+
+// migrator prépare golang-migrate avec le driver du Store. Pour SQLite, les migrations passent par
+// une connexion dédiée sans clés étrangères : reconstruire une table (DROP puis RENAME) ne doit pas
+// déclencher les ON DELETE CASCADE des tables filles. done libère cette connexion
+func (s *DBStore) migrator(dir string) (m *migrate.Migrate, done func(), err error) {
+	if s.driver == DriverPostgres {
+		driver, err := postgres.WithInstance(s.db, &postgres.Config{})
+		if err != nil {
+			return nil, nil, err
+		}
+		m, err = migrate.NewWithDatabaseInstance("file://"+dir, s.driver, driver)
+		return m, func() {}, err
+	}
+
+	db := s.db
+	if s.cfg.DSN != "" {
+		if db, err = sql.Open(DriverSQLite, s.cfg.sqliteDSN(false)); err != nil {
+			return nil, nil, err
+		}
+		db.SetMaxOpenConns(1)
+	}
+	driver, err := sqlite3.WithInstance(db, &sqlite3.Config{})
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err = migrate.NewWithDatabaseInstance("file://"+dir, s.driver, driver)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, func() {
+		if db != s.db {
+			m.Close()
+		}
+	}, nil
+}
@@ -0,0 +1,142 @@
+package db_test
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/models"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+func TestRebind(t *testing.T) {
+	for _, tc := range []struct{ query, want string }{
+		{`SELECT 1`, `SELECT 1`},
+		{`SELECT * FROM users WHERE id = ? AND email = ?`, `SELECT * FROM users WHERE id = $1 AND email = $2`},
+		{`SELECT '?' , "a?b" FROM t WHERE x = ?`, `SELECT '?' , "a?b" FROM t WHERE x = $1`},
+		{"SELECT ? -- why?\nFROM t WHERE y = ?", "SELECT $1 -- why?\nFROM t WHERE y = $2"},
+		{`SELECT ? /* a ? b */, ?`, `SELECT $1 /* a ? b */, $2`},
+		{`INSERT INTO t VALUES (?, 'it''s ?', ?)`, `INSERT INTO t VALUES ($1, 'it''s ?', $2)`},
+		{`SELECT 'unterminated ?`, `SELECT 'unterminated ?`},
+	} {
+		if got := db.Rebind(tc.query); got != tc.want {
+			t.Errorf("Rebind(%q) = %q; want %q", tc.query, got, tc.want)
+		}
+	}
+}
+
+// TestStoreTimestamps relit les dates écrites par le driver et par CURRENT_TIMESTAMP,
+// puis parcourt les posts par curseur : chaque page doit reprendre exactement après la précédente
+func TestStoreTimestamps(t *testing.T) {
+	dbtest.Each(t, func(t *testing.T, store *db.DBStore) {
+		author := dbtest.User(t, store, "author")
+		base := time.Date(2024, 11, 13, 10, 30, 0, 123456000, time.UTC)
+
+		// le premier post garde la date posée par la base (CURRENT_TIMESTAMP), les autres reçoivent
+		// un time.Time du driver ; deux d'entre eux partagent la même date pour que le départage se fasse sur l'id
+		ids := []uuid.UUID{dbtest.Post(t, store, author, "public")}
+		for _, offset := range []time.Duration{0, time.Minute, time.Minute, 24 * time.Hour} {
+			id := dbtest.Post(t, store, author, "public")
+			if _, err := store.DB().Exec(`UPDATE posts SET created_at = ? WHERE id = ?`, base.Add(offset), id); err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+
+		post, err := store.Posts().Get(ids[0], author)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if post.CreatedAt.IsZero() || time.Since(post.CreatedAt).Abs() > time.Hour {
+			t.Errorf("default created_at = %s; want about now", post.CreatedAt)
+		}
+
+		var seen []models.Post
+		var after db.Cursor
+		for page := 0; ; page++ {
+			if page > len(ids) {
+				t.Fatal("pagination does not terminate")
+			}
+			posts, next, err := store.Posts().ListByUser(author, after, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			seen = append(seen, posts...)
+			if next.IsZero() {
+				break
+			}
+			// le curseur doit survivre à l'aller-retour par le client
+			if after, err = db.ParseCursor(next.String()); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		if len(seen) != len(ids) {
+			t.Fatalf("paged through %d posts; want %d", len(seen), len(ids))
+		}
+		unique := map[uuid.UUID]bool{}
+		for i, p := range seen {
+			unique[p.ID] = true
+			if i > 0 {
+				prev := seen[i-1]
+				if p.CreatedAt.After(prev.CreatedAt) || p.CreatedAt.Equal(prev.CreatedAt) && p.ID.String() > prev.ID.String() {
+					t.Errorf("post %d (%s, %s) is out of order after (%s, %s)", i, p.CreatedAt, p.ID, prev.CreatedAt, prev.ID)
+				}
+			}
+		}
+		if len(unique) != len(ids) {
+			t.Errorf("%d distinct posts; want %d", len(unique), len(ids))
+		}
+		if !seen[len(seen)-1].CreatedAt.Equal(base) {
+			t.Errorf("oldest created_at = %s; want %s", seen[len(seen)-1].CreatedAt, base)
+		}
+		if seen[0].ID != ids[0] {
+			t.Errorf("newest post = %s; want the post dated by the database %s", seen[0].ID, ids[0])
+		}
+	})
+}
+
+// TestStoreOnConflict vérifie que les écritures en ON CONFLICT DO NOTHING sont idempotentes sur les deux bases
+func TestStoreOnConflict(t *testing.T) {
+	dbtest.Each(t, func(t *testing.T, store *db.DBStore) {
+		author := dbtest.User(t, store, "author")
+		reader := dbtest.User(t, store, "reader")
+		postID := dbtest.Post(t, store, author, "public")
+		DB := store.DB()
+
+		likes := func() (total, rows int) {
+			total = dbtest.Scalar[int](t, DB, `SELECT total_likes FROM posts WHERE id = ?`, postID)
+			rows = dbtest.Scalar[int](t, DB, `SELECT COUNT(*) FROM post_interactions WHERE post_id = ? AND interaction_type = 'like'`, postID)
+			return total, rows
+		}
+		for _, step := range []struct {
+			liked bool
+			want  int
+		}{{true, 1}, {true, 1}, {false, 0}, {false, 0}} {
+			if err := store.Posts().SetPostLike(postID, reader, step.liked); err != nil {
+				t.Fatal(err)
+			}
+			if total, rows := likes(); total != step.want || rows != step.want {
+				t.Errorf("SetPostLike(%v): total_likes = %d, %d rows; want %d", step.liked, total, rows, step.want)
+			}
+		}
+
+		now := time.Now()
+		report := models.Report{
+			ID: uuid.Must(uuid.NewV4()), ReporterID: reader, TargetType: "post", TargetID: postID.String(),
+			Reason: "spam", Status: models.ReportStatusOpen, CreatedAt: now, UpdatedAt: now,
+		}
+		if err := store.Moderation().CreateReport(report); err != nil {
+			t.Fatal(err)
+		}
+		report.ID = uuid.Must(uuid.NewV4())
+		if err := store.Moderation().CreateReport(report); !errors.Is(err, db.ErrAlreadyExists) {
+			t.Errorf("duplicate CreateReport = %v; want ErrAlreadyExists", err)
+		}
+		if n := dbtest.Scalar[int](t, DB, `SELECT COUNT(*) FROM reports WHERE target_id = ?`, postID.String()); n != 1 {
+			t.Errorf("%d reports stored; want 1", n)
+		}
+	})
+}
@@ -0,0 +1,296 @@
+package db
+
+import (
+	"backend/pkg/models"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+type userRepo struct {
+	db *sql.DB
+}
+
+// Create insère un utilisateur après avoir vérifié l'unicité de l'email et du nom d'utilisateur
+func (r *userRepo) Create(user models.User) error {
+	var exists bool
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE email = ?)`, user.Email).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check email existence: %w", err)
+	}
+	if exists {
+		return ErrEmailTaken
+	}
+	if err := r.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM users WHERE username = ?)`, user.Username).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check username existence: %w", err)
+	}
+	if exists {
+		return ErrUsernameTaken
+	}
+
+	query := `INSERT INTO users
+        (id, username, age, email, password_hash, first_name, last_name, role, gender, date_of_birth, avatar, bio, phone_number, address, is_private, created_at, updated_at)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	now := time.Now()
+	_, err := r.db.Exec(query,
+		user.ID,
+		user.Username,
+		user.Age,
+		user.Email,
+		user.Password,
+		sql.NullString{String: user.FirstName.String, Valid: user.FirstName.Valid},
+		sql.NullString{String: user.LastName.String, Valid: user.LastName.Valid},
+		user.Role,
+		user.Gender,
+		sql.NullTime{Time: user.DateOfBirth.Time, Valid: user.DateOfBirth.Valid},
+		sql.NullString{String: user.Avatar.String, Valid: user.Avatar.Valid},
+		sql.NullString{String: user.Bio.String, Valid: user.Bio.Valid},
+		sql.NullString{String: user.PhoneNumber.String, Valid: user.PhoneNumber.Valid},
+		sql.NullString{String: user.Address.String, Valid: user.Address.Valid},
+		user.IsPrivate,
+		now,
+		now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+	return nil
+}
+
+// ByEmail relit un compte par son email, ErrNotFound s'il n'existe pas
+func (r *userRepo) ByEmail(email string) (models.User, error) {
+	var user models.User
+	err := r.db.QueryRow(`
+		SELECT id, username, age, email, password_hash, first_name, last_name, role, gender, date_of_birth, avatar, bio, phone_number, address, is_private, created_at, updated_at
+		FROM users
+		WHERE email = ?`, email).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Age,
+		&user.Email,
+		&user.Password,
+		&user.FirstName,
+		&user.LastName,
+		&user.Role,
+		&user.Gender,
+		&user.DateOfBirth,
+		&user.Avatar,
+		&user.Bio,
+		&user.PhoneNumber,
+		&user.Address,
+		&user.IsPrivate,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+	return user, notFound(err)
+}
+
+func (r *userRepo) Email(id uuid.UUID) (string, error) {
+	var email string
+	err := r.db.QueryRow(`SELECT email FROM users WHERE id = ?`, id).Scan(&email)
+	return email, notFound(err)
+}
+
+// IDByEmail renvoie le compte d'une adresse email, sans tenir compte de la casse
+func (r *userRepo) IDByEmail(email string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(`SELECT id FROM users WHERE LOWER(email) = LOWER(?)`, email).Scan(&id)
+	return id, notFound(err)
+}
+
+// EmailVerified indique si l'utilisateur a confirmé son adresse email
+func (r *userRepo) EmailVerified(id uuid.UUID) (bool, error) {
+	var verifiedAt sql.NullTime
+	err := r.db.QueryRow(`SELECT email_verified_at FROM users WHERE id = ?`, id).Scan(&verifiedAt)
+	return verifiedAt.Valid, notFound(err)
+}
+
+// Credentials renvoie l'identifiant, l'empreinte du mot de passe et le nom d'un compte
+// désigné par son email (identifier contient un @) ou son nom d'utilisateur
+func (r *userRepo) Credentials(identifier string) (uuid.UUID, string, string, error) {
+	query := `SELECT id, password_hash, username FROM users WHERE username = ?`
+	if strings.Contains(identifier, "@") {
+		query = `SELECT id, password_hash, username FROM users WHERE email = ?`
+	}
+	var id uuid.UUID
+	var passwordHash, username string
+	err := r.db.QueryRow(query, identifier).Scan(&id, &passwordHash, &username)
+	return id, passwordHash, username, notFound(err)
+}
+
+// Role renvoie le rôle du compte (colonne users.role)
+func (r *userRepo) Role(id uuid.UUID) (string, error) {
+	var role string
+	err := r.db.QueryRow(`SELECT role FROM users WHERE id = ?`, id).Scan(&role)
+	return role, notFound(err)
+}
+
+func (r *userRepo) SetRole(id uuid.UUID, role string) error {
+	_, err := r.db.Exec(`UPDATE users SET role = ?, updated_at = ? WHERE id = ?`, role, time.Now(), id)
+	return err
+}
+
+// ClearPassword vide l'empreinte du mot de passe : elle ne correspond plus à aucun mot de passe
+// et seule la réinitialisation permet de se reconnecter
+func (r *userRepo) ClearPassword(id uuid.UUID) error {
+	_, err := r.db.Exec(`UPDATE users SET password_hash = '', updated_at = ? WHERE id = ?`, time.Now(), id)
+	return err
+}
+
+func (r *userRepo) UsernameByID(id uuid.UUID) (string, error) {
+	var username string
+	err := r.db.QueryRow(`SELECT username FROM users WHERE id = ?`, id).Scan(&username)
+	return username, notFound(err)
+}
+
+func (r *userRepo) IDByUsername(username string) (uuid.UUID, error) {
+	var id uuid.UUID
+	err := r.db.QueryRow(`SELECT id FROM users WHERE username = ?`, username).Scan(&id)
+	return id, notFound(err)
+}
+
+func (r *userRepo) Avatar(id uuid.UUID) (sql.NullString, error) {
+	var avatar sql.NullString
+	err := r.db.QueryRow(`SELECT avatar FROM users WHERE id = ?`, id).Scan(&avatar)
+	return avatar, notFound(err)
+}
+
+func (r *userRepo) IsPrivate(id uuid.UUID) (bool, error) {
+	var isPrivate bool
+	err := r.db.QueryRow(`SELECT is_private FROM users WHERE id = ?`, id).Scan(&isPrivate)
+	return isPrivate, notFound(err)
+}
+
+// Profile lit les informations publiques du profil (sans abonnés ni posts)
+func (r *userRepo) Profile(id uuid.UUID) (models.UserProfil, error) {
+	var profil models.UserProfil
+	err := r.db.QueryRow(`SELECT id, username, first_name, last_name, bio, is_private, avatar FROM users WHERE id = ?`, id).Scan(
+		&profil.UserID, &profil.Username, &profil.FirstName, &profil.LastName, &profil.Bio, &profil.IsPrivate, &profil.Avatar,
+	)
+	return profil, notFound(err)
+}
+
+// UpdateProfile ne met à jour que les champs renseignés, ErrNoFields s'il n'y en a aucun
+func (r *userRepo) UpdateProfile(id uuid.UUID, profile models.UserProfil) error {
+	var updates []string
+	var params []interface{}
+
+	if profile.FirstName.Valid {
+		updates = append(updates, "first_name = ?")
+		params = append(params, profile.FirstName.String)
+	}
+	if profile.LastName.Valid {
+		updates = append(updates, "last_name = ?")
+		params = append(params, profile.LastName.String)
+	}
+	if profile.Email != "" {
+		updates = append(updates, "email = ?")
+		params = append(params, profile.Email)
+	}
+	if profile.Gender != "" {
+		updates = append(updates, "gender = ?")
+		params = append(params, profile.Gender)
+	}
+	if profile.Avatar.Valid {
+		updates = append(updates, "avatar = ?")
+		params = append(params, profile.Avatar.String)
+	}
+	if profile.Bio.Valid {
+		updates = append(updates, "bio = ?")
+		params = append(params, profile.Bio.String)
+	}
+	if profile.PhoneNumber.Valid {
+		updates = append(updates, "phone_number = ?")
+		params = append(params, profile.PhoneNumber.String)
+	}
+	if profile.Address.Valid {
+		updates = append(updates, "address = ?")
+		params = append(params, profile.Address.String)
+	}
+
+	if len(updates) == 0 {
+		return ErrNoFields
+	}
+
+	updates = append(updates, "updated_at = CURRENT_TIMESTAMP")
+	params = append(params, id)
+	_, err := r.db.Exec("UPDATE users SET "+strings.Join(updates, ", ")+" WHERE id = ?", params...)
+	return err
+}
+
+// List renvoie les autres utilisateurs avec l'état de la relation vis-à-vis de viewerID
+func (r *userRepo) List(viewerID uuid.UUID, limit, offset int) ([]models.UserSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			u.id,
+			u.username,
+			COALESCE(u.avatar, ''),
+			EXISTS (
+				SELECT 1 FROM follow_requests fr
+				WHERE fr.sender_id = ? AND fr.receiver_id = u.id
+			) AS is_request_pending,
+			EXISTS (
+				SELECT 1 FROM followers f
+				WHERE f.follower_id = ? AND f.followed_id = u.id
+			) AS is_following
+		FROM users u
+		WHERE u.id != ?
+		LIMIT ? OFFSET ?`, viewerID, viewerID, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.UserSummary
+	for rows.Next() {
+		var u models.UserSummary
+		if err := rows.Scan(&u.ID, &u.Username, &u.Avatar, &u.IsRequestPending, &u.IsFollowing); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// Search cherche les utilisateurs dont le nom commence par prefix
+func (r *userRepo) Search(viewerID uuid.UUID, prefix string, limit int) ([]models.UserSummary, error) {
+	rows, err := r.db.Query(`
+		SELECT
+			u.id,
+			u.username,
+			COALESCE(u.avatar, ''),
+			CASE
+				WHEN fr.sender_id = ? THEN 1
+				ELSE 0
+			END AS is_request_pending
+		FROM users u
+		LEFT JOIN follow_requests fr ON fr.receiver_id = u.id AND fr.sender_id = ?
+		WHERE u.id != ? AND LOWER(u.username) LIKE LOWER(?)
+		LIMIT ?`, viewerID, viewerID, viewerID, prefix+"%", limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.UserSummary
+	for rows.Next() {
+		var u models.UserSummary
+		if err := rows.Scan(&u.ID, &u.Username, &u.Avatar, &u.IsRequestPending); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// RecordLoginAttempt ajoute une ligne au journal login_attempts
+func (r *userRepo) RecordLoginAttempt(attempt models.LoginAttempt) error {
+	_, err := r.db.Exec(`INSERT INTO login_attempts (id, identifier, user_id, ip_address, user_agent, success, reason, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		attempt.ID, attempt.Identifier, uuid.NullUUID{UUID: attempt.UserID, Valid: attempt.UserID != uuid.Nil},
+		attempt.IPAddress, attempt.UserAgent, attempt.Success, attempt.Reason, attempt.CreatedAt)
+	return err
+}
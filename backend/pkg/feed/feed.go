@@ -0,0 +1,82 @@
+// Package feed classe les entrées du fil d'actualité. Il ne lit pas la base : les candidats
+// viennent de db.FeedRepo et l'heure courante est passée en paramètre, le classement est donc déterministe
+package feed
+
+import (
+	"backend/pkg/models"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Modes de sélection des candidats
+const (
+	ModeFollowing = "following" // abonnements acceptés, posts du lecteur et de ses groupes
+	ModeDiscover  = "discover"  // en plus tout post que le lecteur peut voir
+)
+
+// Modes de classement
+const (
+	SortRecent     = "recent"     // du plus récent au plus ancien
+	SortEngagement = "engagement" // score d'engagement amorti par l'âge
+)
+
+const (
+	// EngagementWindow borne l'âge des candidats du classement par engagement
+	EngagementWindow = 7 * 24 * time.Hour
+	// MaxCandidates est le nombre maximal de candidats lus par source
+	MaxCandidates = 500
+)
+
+// poids du score d'engagement : un commentaire compte plus qu'un like,
+// et le score est divisé par deux toutes les HalfLife
+const (
+	LikeWeight    = 1.0
+	CommentWeight = 2.0
+	HalfLife      = 24 * time.Hour
+)
+
+// Score renvoie le score d'engagement de l'entrée à l'instant now
+func Score(item models.FeedItem, now time.Time) float64 {
+	engagement := 1 + LikeWeight*float64(item.TotalLikes) + CommentWeight*float64(item.TotalComments)
+	age := max(now.Sub(item.CreatedAt), 0)
+	return engagement * math.Pow(0.5, age.Hours()/HalfLife.Hours())
+}
+
+// Rank trie items selon sortMode (SortRecent ou SortEngagement) et renseigne Score en mode engagement.
+// À score ou date égaux, l'entrée la plus récente puis l'identifiant le plus grand passent devant
+func Rank(items []models.FeedItem, sortMode string, now time.Time) {
+	if sortMode == SortEngagement {
+		for i := range items {
+			items[i].Score = Score(items[i], now)
+		}
+	}
+	slices.SortStableFunc(items, func(a, b models.FeedItem) int {
+		if sortMode == SortEngagement && a.Score != b.Score {
+			if a.Score > b.Score {
+				return -1
+			}
+			return 1
+		}
+		if c := b.CreatedAt.Compare(a.CreatedAt); c != 0 {
+			return c
+		}
+		return slices.Compare(b.ID.Bytes(), a.ID.Bytes())
+	})
+}
+
+// After renvoie la position qui suit l'entrée id dans items classés, false si elle n'y figure plus
+func After(items []models.FeedItem, id uuid.UUID) (int, bool) {
+	i := slices.IndexFunc(items, func(item models.FeedItem) bool { return item.ID == id })
+	return i + 1, i >= 0
+}
+
+// Page renvoie la tranche [offset, offset+limit) de items, vide au-delà de la fin
+func Page(items []models.FeedItem, offset, limit int) []models.FeedItem {
+	if offset >= len(items) {
+		return []models.FeedItem{}
+	}
+	return items[offset:min(offset+limit, len(items))]
+}
@@ -0,0 +1,181 @@
+package feed_test
+
+import (
+	"backend/pkg/db"
+	"backend/pkg/db/dbtest"
+	"backend/pkg/feed"
+	"backend/pkg/models"
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+var now = time.Date(2024, 11, 13, 12, 0, 0, 0, time.UTC)
+
+func TestScore(t *testing.T) {
+	item := models.FeedItem{CreatedAt: now.Add(-feed.HalfLife), TotalLikes: 3, TotalComments: 2}
+	// (1 + 3 likes + 2*2 commentaires) divisé par deux après une demi-vie
+	if got := feed.Score(item, now); math.Abs(got-4) > 1e-9 {
+		t.Errorf("Score = %v; want 4", got)
+	}
+	// une date dans le futur ne donne pas de bonus
+	item.CreatedAt = now.Add(time.Hour)
+	if got := feed.Score(item, now); got != 8 {
+		t.Errorf("Score of a future item = %v; want 8", got)
+	}
+}
+
+func TestRankTies(t *testing.T) {
+	low := uuid.FromStringOrNil("00000000-0000-0000-0000-000000000001")
+	high := uuid.FromStringOrNil("00000000-0000-0000-0000-000000000002")
+	older := uuid.FromStringOrNil("00000000-0000-0000-0000-000000000003")
+	for _, sortMode := range []string{feed.SortRecent, feed.SortEngagement} {
+		items := []models.FeedItem{
+			{ID: older, CreatedAt: now.Add(-time.Hour)},
+			{ID: low, CreatedAt: now},
+			{ID: high, CreatedAt: now},
+		}
+		feed.Rank(items, sortMode, now)
+		if got := ids(items); !slices.Equal(got, []uuid.UUID{high, low, older}) {
+			t.Errorf("%s: order = %v; want the newest then the greatest id first", sortMode, got)
+		}
+	}
+}
+
+func TestPage(t *testing.T) {
+	items := make([]models.FeedItem, 5)
+	for i := range items {
+		items[i].ID = uuid.Must(uuid.NewV4())
+	}
+	offset, ok := feed.After(items, items[1].ID)
+	if !ok || offset != 2 {
+		t.Fatalf("After = %d, %v; want 2, true", offset, ok)
+	}
+	if page := feed.Page(items, offset, 2); !slices.Equal(ids(page), ids(items[2:4])) {
+		t.Errorf("Page(2, 2) = %v; want items 2 and 3", ids(page))
+	}
+	if page := feed.Page(items, 4, 2); len(page) != 1 {
+		t.Errorf("Page(4, 2) returned %d items; want 1", len(page))
+	}
+	if page := feed.Page(items, 5, 2); page == nil || len(page) != 0 {
+		t.Errorf("Page past the end = %v; want an empty page", page)
+	}
+	if _, ok := feed.After(items, uuid.Must(uuid.NewV4())); ok {
+		t.Error("After found an unknown id")
+	}
+}
+
+// TestModes construit les candidats en base avec des dates et des engagements fixés, puis vérifie
+// l'ordre exact de chaque combinaison mode / classement, posts de groupe compris
+func TestModes(t *testing.T) {
+	store := dbtest.SQLite(t)
+	viewer := dbtest.User(t, store, "viewer")
+	followed := dbtest.User(t, store, "followed")
+	stranger := dbtest.User(t, store, "stranger")
+	if err := store.Follows().Follow(viewer, followed); err != nil {
+		t.Fatal(err)
+	}
+	var fans []uuid.UUID
+	for i := range 10 {
+		fans = append(fans, dbtest.User(t, store, fmt.Sprintf("fan%d", i)))
+	}
+
+	// post crée un post daté de now-age avec likes likes et comments commentaires
+	post := func(author uuid.UUID, visibility string, age time.Duration, likes, comments int) uuid.UUID {
+		id := dbtest.Post(t, store, author, visibility)
+		if _, err := store.DB().Exec(`UPDATE posts SET created_at = ? WHERE id = ?`, now.Add(-age), id); err != nil {
+			t.Fatal(err)
+		}
+		for _, fan := range fans[:likes] {
+			if err := store.Posts().SetPostLike(id, fan, true); err != nil {
+				t.Fatal(err)
+			}
+		}
+		for _, fan := range fans[:comments] {
+			dbtest.Comment(t, store, id, fan, "comment")
+		}
+		return id
+	}
+	// groupPost crée dans un nouveau groupe de stranger un post daté de now-age avec comments commentaires
+	groupPost := func(member bool, age time.Duration, comments int) uuid.UUID {
+		groupID, id := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+		if err := store.Groups().Create(models.Group{ID: groupID, Name: "group", Description: "group", CreatorID: stranger}); err != nil {
+			t.Fatal(err)
+		}
+		if member {
+			if err := store.Groups().AddPendingMember(groupID, viewer); err != nil {
+				t.Fatal(err)
+			}
+			if err := store.Groups().AcceptMember(groupID, viewer); err != nil {
+				t.Fatal(err)
+			}
+		}
+		at := now.Add(-age)
+		err := store.Groups().CreatePost(models.PostGroup{ID: id, GroupID: groupID, UserID: stranger, Title: "t", Content: "c", CreatedAt: at, UpdatedAt: at})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, fan := range fans[:comments] {
+			err := store.Groups().CreateComment(models.CommentPostGroup{ID: uuid.Must(uuid.NewV4()), PostID: id, UserID: fan, Content: "comment", CreatedAt: at})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		return id
+	}
+
+	// scores à now : own 0.97, group 4.59, followedPublic 4.33, followedPrivate 2.81, strangerPublic 8.24
+	own := post(viewer, "public", time.Hour, 0, 0)
+	group := groupPost(true, 3*time.Hour, 2)
+	followedPublic := post(followed, "public", 5*time.Hour, 4, 0)
+	strangerPublic := post(stranger, "public", 10*time.Hour, 10, 0)
+	followedPrivate := post(followed, "private", 20*time.Hour, 2, 1)
+	// exclus de tous les modes : privé d'un inconnu et groupe dont le lecteur n'est pas membre
+	post(stranger, "private", 2*time.Hour, 5, 5)
+	groupPost(false, 2*time.Hour, 5)
+
+	for _, tc := range []struct {
+		mode, sort string
+		want       []uuid.UUID
+	}{
+		{feed.ModeFollowing, feed.SortRecent, []uuid.UUID{own, group, followedPublic, followedPrivate}},
+		{feed.ModeFollowing, feed.SortEngagement, []uuid.UUID{group, followedPublic, followedPrivate, own}},
+		{feed.ModeDiscover, feed.SortRecent, []uuid.UUID{own, group, followedPublic, strangerPublic, followedPrivate}},
+		{feed.ModeDiscover, feed.SortEngagement, []uuid.UUID{strangerPublic, group, followedPublic, followedPrivate, own}},
+	} {
+		t.Run(tc.mode+"/"+tc.sort, func(t *testing.T) {
+			items, err := store.Feed().Candidates(viewer, db.FeedQuery{Discover: tc.mode == feed.ModeDiscover, Limit: feed.MaxCandidates})
+			if err != nil {
+				t.Fatal(err)
+			}
+			feed.Rank(items, tc.sort, now)
+			if got := ids(items); !slices.Equal(got, tc.want) {
+				t.Errorf("order = %v; want %v", got, tc.want)
+			}
+			for _, item := range items {
+				wantType := "post"
+				if item.ID == group {
+					wantType = "group_post"
+				}
+				if item.Type != wantType {
+					t.Errorf("item %s has type %q; want %q", item.ID, item.Type, wantType)
+				}
+				if tc.sort == feed.SortEngagement && item.Score != feed.Score(item, now) {
+					t.Errorf("item %s has score %v; want %v", item.ID, item.Score, feed.Score(item, now))
+				}
+			}
+		})
+	}
+}
+
+func ids(items []models.FeedItem) []uuid.UUID {
+	out := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		out[i] = item.ID
+	}
+	return out
+}
@@ -0,0 +1,57 @@
+package mail
+
+import (
+	"log"
+	"time"
+)
+
+// Message est un email texte simple
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+	SentAt  time.Time
+}
+
+// Mailer envoie les emails transactionnels (vérification, réinitialisation du mot de passe)
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config choisit l'implémentation :
+//   - SMTPHost (+ SMTPPort, SMTPUsername, SMTPPassword) : envoi réel
+//   - Dir : écriture de chaque email dans un fichier (développement)
+//   - sinon : emails gardés en mémoire et affichés dans les logs
+type Config struct {
+	From         string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	Dir          string
+}
+
+// New construit le mailer décrit par une configuration déjà validée
+func New(cfg Config) (Mailer, error) {
+	if cfg.SMTPHost != "" {
+		return &SMTPMailer{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.From,
+		}, nil
+	}
+
+	if cfg.Dir != "" {
+		m, err := NewFileMailer(cfg.Dir)
+		if err != nil {
+			return nil, err
+		}
+		m.From = cfg.From
+		return m, nil
+	}
+
+	log.Println("⚠️ No SMTP host configured, emails are only logged")
+	return NewMemoryMailer(true), nil
+}
@@ -0,0 +1,86 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryMailer garde les emails envoyés en mémoire (tests, développement local)
+type MemoryMailer struct {
+	mu      sync.Mutex
+	sent    []Message
+	verbose bool
+}
+
+// NewMemoryMailer crée un mailer en mémoire, verbose affiche chaque email dans les logs
+func NewMemoryMailer(verbose bool) *MemoryMailer {
+	return &MemoryMailer{verbose: verbose}
+}
+
+func (m *MemoryMailer) Send(msg Message) error {
+	msg.SentAt = time.Now()
+
+	m.mu.Lock()
+	m.sent = append(m.sent, msg)
+	m.mu.Unlock()
+
+	if m.verbose {
+		log.Printf("📧 Mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+	}
+	return nil
+}
+
+// Sent retourne une copie des emails envoyés
+func (m *MemoryMailer) Sent() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Message(nil), m.sent...)
+}
+
+// Last retourne le dernier email envoyé à l'adresse donnée
+func (m *MemoryMailer) Last(to string) (Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.sent) - 1; i >= 0; i-- {
+		if strings.EqualFold(m.sent[i].To, to) {
+			return m.sent[i], true
+		}
+	}
+	return Message{}, false
+}
+
+// FileMailer écrit chaque email dans un fichier .eml du répertoire donné
+type FileMailer struct {
+	Dir  string
+	From string
+}
+
+// NewFileMailer crée le répertoire de destination si nécessaire
+func NewFileMailer(dir string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create mail directory: %w", err)
+	}
+	return &FileMailer{Dir: dir, From: "no-reply@social-network.local"}, nil
+}
+
+func (m *FileMailer) Send(msg Message) error {
+	name := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), sanitize(msg.To))
+	if err := os.WriteFile(filepath.Join(m.Dir, name), format(m.From, msg), 0o644); err != nil {
+		return fmt.Errorf("failed to write email: %w", err)
+	}
+	return nil
+}
+
+func sanitize(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == ':' {
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,46 @@
+package mail
+
+import (
+	"fmt"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer envoie les emails via un serveur SMTP (STARTTLS négocié par net/smtp si disponible)
+type SMTPMailer struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+
+	addr := fmt.Sprintf("%s:%d", m.Host, m.Port)
+	if err := smtp.SendMail(addr, auth, m.From, []string{headerSafe.Replace(msg.To)}, format(m.From, msg)); err != nil {
+		return fmt.Errorf("failed to send email to %s: %w", msg.To, err)
+	}
+	return nil
+}
+
+// headerSafe retire les retours à la ligne pour empêcher l'injection d'en-têtes
+var headerSafe = strings.NewReplacer("\r", "", "\n", "")
+
+// format construit le message RFC 5322 envoyé au serveur
+func format(from string, msg Message) []byte {
+	var b strings.Builder
+	b.WriteString("From: " + headerSafe.Replace(from) + "\r\n")
+	b.WriteString("To: " + headerSafe.Replace(msg.To) + "\r\n")
+	b.WriteString("Subject: " + headerSafe.Replace(msg.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// AdminUser est la vue d'un compte dans la console d'administration
+type AdminUser struct {
+	ID            uuid.UUID   `json:"id"`
+	Username      string      `json:"username"`
+	Email         string      `json:"email"`
+	Role          string      `json:"role"`
+	EmailVerified bool        `json:"email_verified"`
+	CreatedAt     time.Time   `json:"created_at"`
+	Suspension    *Suspension `json:"suspension,omitempty"` // restriction en cours, s'il y en a une
+}
+
+// Suspension est une suspension temporaire ou un bannissement (ExpiresAt nul = définitif)
+type Suspension struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Kind      string     `json:"kind"` // "suspension" ou "ban"
+	Reason    string     `json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedBy uuid.UUID  `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// AuditEntry est une ligne du journal des actions d'administration
+type AuditEntry struct {
+	ID           uuid.UUID       `json:"id"`
+	ActorID      uuid.UUID       `json:"actor_id"`
+	Action       string          `json:"action"`
+	TargetUserID *uuid.UUID      `json:"target_user_id,omitempty"`
+	Details      json.RawMessage `json:"details,omitempty"`
+	IPAddress    string          `json:"ip_address"`
+	CreatedAt    time.Time       `json:"created_at"`
+}
@@ -8,11 +8,11 @@ import (
 )
 
 type Comment struct {
-	ID          uuid.UUID      `json:"id" validate:"required"`
+	ID          uuid.UUID      `json:"id"`
 	PostID      uuid.UUID      `json:"post_id" validate:"required"`
-	Content     string         `json:"content" validate:"required"`
-	UserID      uuid.UUID      `json:"user_id" validate:"required"`
-	Username    string         `json:"username" validate:"required"`
+	Content     string         `json:"content" validate:"required,max=2000"`
+	UserID      uuid.UUID      `json:"user_id"`
+	Username    string         `json:"username"`
 	CreatedAt   time.Time      `json:"created_at" default:"CURRENT_TIMESTAMP"`
 	Avatar      sql.NullString `json:"avatar,omitempty"`
 	TotalLikes  int            `json:"total_likes"`
@@ -20,10 +20,10 @@ type Comment struct {
 }
 
 type CommentPostGroup struct {
-	ID        uuid.UUID `json:"id" validate:"required"`
+	ID        uuid.UUID `json:"id"`
 	PostID    uuid.UUID `json:"post_id" validate:"required"`
-	Content   string    `json:"content" validate:"required"`
-	UserID    uuid.UUID `json:"user_id" validate:"required"`
-	Username  string    `json:"username" validate:"required"`
+	Content   string    `json:"content" validate:"required,max=2000"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
 	CreatedAt time.Time `json:"created_at" default:"CURRENT_TIMESTAMP"`
 }
@@ -8,11 +8,11 @@ import (
 
 type GroupEvent struct {
 	ID          uuid.UUID    `json:"id"`
-	GroupID     uuid.UUID    `json:"group_id"`
+	GroupID     uuid.UUID    `json:"group_id" validate:"required"`
 	UserID      uuid.UUID    `json:"user_id"`
-	Title       string       `json:"title" validate:"required"`
-	Description string       `json:"description" validate:"required"`
-	EventDate   time.Time    `json:"event_date" validate:"required"`
+	Title       string       `json:"title" validate:"required,max=200"`
+	Description string       `json:"description" validate:"required,max=2000"`
+	EventDate   time.Time    `json:"event_date" validate:"required,future,within=17520h"`
 	Options     EventOptions `json:"options"`
 	CreatedAt   time.Time    `json:"created_at"`
 }
@@ -23,7 +23,7 @@ type EventOptions struct {
 }
 
 type EventResponse struct {
-	EventID  uuid.UUID `json:"event_id"`
+	EventID  uuid.UUID `json:"event_id" validate:"required"`
 	UserID   uuid.UUID `json:"user_id"`
-	Response string    `json:"response" validate:"oneof=Going 'Not going'"`
+	Response string    `json:"response" validate:"required,oneof=Going 'Not going'"`
 }
@@ -10,12 +10,12 @@ import (
 // structure de base d'un groupe
 type Group struct {
 	ID          uuid.UUID     `json:"id"`
-	Name        string        `json:"name" validate:"required"`
-	Description string        `json:"description" validate:"required"`
+	Name        string        `json:"name" validate:"required,max=100"`
+	Description string        `json:"description" validate:"required,max=1000"`
 	CreatorID   uuid.UUID     `json:"creator_id"`
 	Members     []GroupMember `json:"members,omitempty"`
 	Events      []GroupEvent  `json:"events,omitempty"` // Liste des événements
-	CreatedAt   string        `json:"created_at"`
+	CreatedAt   time.Time     `json:"created_at"`
 }
 
 // structure pour les membres du groupe
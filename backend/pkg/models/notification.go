@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Notification est une notification non lue, avec le nom et l'avatar de l'expéditeur
+type Notification struct {
+	ID         string    `json:"id"`
+	Content    string    `json:"content"`
+	CreatedAt  time.Time `json:"created_at"`
+	Read       bool      `json:"read"`
+	Type       string    `json:"type"`
+	SenderName string    `json:"sender_name"`
+	Avatar     string    `json:"avatar"`
+}
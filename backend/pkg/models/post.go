@@ -8,27 +8,73 @@ import (
 )
 
 type Post struct {
-	ID           uuid.UUID      `json:"id" validate:"required"`
-	Title        string         `json:"title" validate:"required"`
-	Category     string         `json:"category" validate:"required"`
-	Content      string         `json:"content" validate:"required"`
-	UserID       uuid.UUID      `json:"user_id" validate:"required"`
-	Visibility   string         `json:"visibility" validate:"oneof=public private limited" default:"public"`
+	ID           uuid.UUID      `json:"id"`
+	Title        string         `json:"title" validate:"required,max=200"`
+	Category     string         `json:"category" validate:"max=50"`
+	Content      string         `json:"content" validate:"required,max=10000"`
+	UserID       uuid.UUID      `json:"user_id"`
+	Visibility   string         `json:"visibility" validate:"required,oneof=public private almost_private" default:"public"`
 	CreatedAt    time.Time      `json:"created_at" default:"CURRENT_TIMESTAMP"`
+	EditedAt     *time.Time     `json:"edited_at,omitempty"`
 	ImagePath    string         `json:"image_path,omitempty"`
-	Username     string         `json:"username" validate:"required"`
-	AllowedUsers []uuid.UUID    `json:"allowed_users,omitempty"`
+	Username     string         `json:"username"`
+	AllowedUsers []uuid.UUID    `json:"allowed_users,omitempty" validate:"max=100"`
 	Avatar       sql.NullString `json:"image_profil,omitempty"`
 	TotalLikes   int            `json:"total_likes"`
 	LikedByUser  bool           `json:"liked_by_user"`
 }
 
+// PostRevision est l'état d'un post avant une modification
+type PostRevision struct {
+	ID           uuid.UUID   `json:"id"`
+	PostID       uuid.UUID   `json:"post_id"`
+	Title        string      `json:"title"`
+	Content      string      `json:"content"`
+	Visibility   string      `json:"visibility"`
+	ImagePath    string      `json:"image_path,omitempty"`
+	AllowedUsers []uuid.UUID `json:"allowed_users,omitempty"`
+	EditedBy     uuid.UUID   `json:"edited_by"`
+	ReplacedAt   time.Time   `json:"replaced_at"` // date de la modification qui a remplacé cette version
+}
+
+// PostPreview reprend les balises Open Graph (og:*) d'un post pour l'aperçu des liens partagés
+type PostPreview struct {
+	Title         string     `json:"title"`
+	Description   string     `json:"description"`
+	Image         string     `json:"image,omitempty"`
+	URL           string     `json:"url"`
+	Type          string     `json:"type"` // toujours "article"
+	Author        string     `json:"author"`
+	PublishedTime time.Time  `json:"published_time"`
+	ModifiedTime  *time.Time `json:"modified_time,omitempty"`
+}
+
+// FeedItem est une entrée du fil d'actualité : un post ("post") ou un post de groupe ("group_post")
+type FeedItem struct {
+	Type          string         `json:"type"`
+	ID            uuid.UUID      `json:"id"`
+	UserID        uuid.UUID      `json:"user_id"`
+	Username      string         `json:"username"`
+	Avatar        sql.NullString `json:"image_profil,omitempty"`
+	Title         string         `json:"title"`
+	Content       string         `json:"content"`
+	ImagePath     string         `json:"image_path,omitempty"`
+	Visibility    string         `json:"visibility,omitempty"` // posts uniquement
+	GroupID       *uuid.UUID     `json:"group_id,omitempty"`   // posts de groupe uniquement
+	GroupName     string         `json:"group_name,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+	TotalLikes    int            `json:"total_likes"`
+	TotalComments int            `json:"total_comments"`
+	LikedByUser   bool           `json:"liked_by_user"`
+	Score         float64        `json:"score,omitempty"` // classement par engagement uniquement
+}
+
 type PostGroup struct {
 	ID        uuid.UUID `json:"id"`
-	GroupID   uuid.UUID `json:"group_id"`
+	GroupID   uuid.UUID `json:"group_id" validate:"required"`
 	UserID    uuid.UUID `json:"user_id"`
-	Title     string    `json:"title" validate:"required"`
-	Content   string    `json:"content" validate:"required"`
+	Title     string    `json:"title" validate:"required,max=200"`
+	Content   string    `json:"content" validate:"required,max=10000"`
 	Likes     int       `json:"likes"`    // Total des likes
 	Username  string    `json:"username"` // Nom d'utilisateur de l'auteur
 	Avatar    string    `json:"avatar"`   // Avatar de l'auteur
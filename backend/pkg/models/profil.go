@@ -9,19 +9,19 @@ import (
 type UserProfil struct {
 	UserID      uuid.UUID    `json:"user_id"`
 	Username    string       `json:"username"`
-	FirstName   NullString   `json:"firstName"`
-	LastName    NullString   `json:"lastName"`
-	Email       string       `json:"email"`
-	Gender      string       `json:"gender"`
-	Bio         NullString   `json:"bio"`
+	FirstName   NullString   `json:"firstName" validate:"max=50"`
+	LastName    NullString   `json:"lastName" validate:"max=50"`
+	Email       string       `json:"email" validate:"omitempty,email,max=254"`
+	Gender      string       `json:"gender" validate:"max=20"`
+	Bio         NullString   `json:"bio" validate:"max=500"`
 	IsPrivate   bool         `json:"is_private"`
-	Avatar      NullString   `json:"image_profil,omitempty"`
-	PhoneNumber NullString   `json:"phoneNumber"`
+	Avatar      NullString   `json:"image_profil,omitempty" validate:"max=500"`
+	PhoneNumber NullString   `json:"phoneNumber" validate:"max=20"`
 	Followers   []SimpleUser `json:"followers,omitempty"`
 	Following   []SimpleUser `json:"following,omitempty"`
 	Posts       []Post       `json:"posts,omitempty"`
 	Role        string       `json:"role"`
-	Address     NullString   `json:"address"`
+	Address     NullString   `json:"address" validate:"max=200"`
 	UpdatedAt   time.Time    `json:"updated_at"`
 }
 
@@ -44,6 +44,7 @@ type UserProfilJSON struct {
 	Followers      []SimpleUser `json:"followers,omitempty"`
 	Following      []SimpleUser `json:"following,omitempty"`
 	Posts          []Post       `json:"posts,omitempty"`
+	NextCursor     string       `json:"next_cursor,omitempty"` // page suivante de posts, absent sur la dernière
 }
 type UserProfilResponse struct {
 	UserID      uuid.UUID `json:"user_id"`
@@ -60,3 +61,20 @@ type UserProfilResponse struct {
 	UpdatedAt   time.Time `json:"updated_at"`
 	Posts       []Post    `json:"posts,omitempty"`
 }
+
+// UserSummary est un utilisateur dans une liste (annuaire, recherche, amis)
+type UserSummary struct {
+	ID               uuid.UUID `json:"id"`
+	Username         string    `json:"username"`
+	Avatar           string    `json:"avatar"`
+	IsRequestPending bool      `json:"is_request_pending"`
+	IsFollowing      bool      `json:"is_following"`
+}
+
+// PendingFollowRequest est une demande d'abonnement reçue
+type PendingFollowRequest struct {
+	ID       uuid.UUID `json:"id"`
+	SenderID uuid.UUID `json:"sender_id"`
+	Username string    `json:"username"`
+	Avatar   string    `json:"avatar"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// états d'un signalement : ouvert, en cours de traitement, puis clos (résolu ou rejeté)
+const (
+	ReportStatusOpen      = "open"
+	ReportStatusInReview  = "in_review"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+// Report est un signalement de contenu par un utilisateur
+type Report struct {
+	ID            uuid.UUID  `json:"id"`
+	ReporterID    uuid.UUID  `json:"reporter_id"`
+	TargetType    string     `json:"target_type"` // post, comment, group_post, group_comment, private_message, group_message
+	TargetID      string     `json:"target_id"`
+	Reason        string     `json:"reason"`
+	Details       string     `json:"details,omitempty"`
+	Status        string     `json:"status"` // open, in_review, resolved, dismissed
+	AssignedTo    *uuid.UUID `json:"assigned_to,omitempty"`
+	Resolution    string     `json:"resolution,omitempty"`
+	ResolvedBy    *uuid.UUID `json:"resolved_by,omitempty"`
+	ResolvedAt    *time.Time `json:"resolved_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	TargetContent string     `json:"target_content,omitempty"` // aperçu du contenu signalé, pour la modération
+	TargetHidden  bool       `json:"target_hidden"`
+}
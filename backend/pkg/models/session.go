@@ -0,0 +1,35 @@
+package models
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Session représente un appareil connecté, identifié par le claim "sid" des tokens d'accès
+type Session struct {
+	ID         uuid.UUID    `json:"id"`
+	UserID     uuid.UUID    `json:"user_id"`
+	Device     string       `json:"device"`
+	UserAgent  string       `json:"user_agent"`
+	IPAddress  string       `json:"ip_address"`
+	CreatedAt  time.Time    `json:"created_at"`
+	LastUsedAt time.Time    `json:"last_used_at"`
+	ExpiresAt  time.Time    `json:"expires_at"`
+	RevokedAt  sql.NullTime `json:"-"`
+	Client     string       `json:"client"`  // description approximative de l'appareil
+	Current    bool         `json:"current"` // session de la requête en cours
+}
+
+// LoginAttempt est une ligne du journal login_attempts (UserID nul pour un identifiant inconnu)
+type LoginAttempt struct {
+	ID         uuid.UUID
+	Identifier string
+	UserID     uuid.UUID
+	IPAddress  string
+	UserAgent  string
+	Success    bool
+	Reason     string
+	CreatedAt  time.Time
+}
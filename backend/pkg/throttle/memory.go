@@ -0,0 +1,37 @@
+package throttle
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryLimiter garde les compteurs en mémoire, ils sont perdus au redémarrage
+type MemoryLimiter struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{records: make(map[string]Record)}
+}
+
+func (m *MemoryLimiter) Allow(key string, now time.Time) (time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return retryAfter(m.records[key].BlockedUntil, now), nil
+}
+
+func (m *MemoryLimiter) Fail(key string, policy Policy, now time.Time) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	res := policy.apply(m.records[key], now)
+	m.records[key] = res.Record
+	return res, nil
+}
+
+func (m *MemoryLimiter) Reset(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.records, key)
+	return nil
+}
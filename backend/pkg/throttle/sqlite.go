@@ -0,0 +1,61 @@
+package throttle
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLiteLimiter conserve les compteurs dans la table login_throttle, ils survivent aux redémarrages
+type SQLiteLimiter struct {
+	DB *sql.DB
+}
+
+func NewSQLiteLimiter(db *sql.DB) *SQLiteLimiter {
+	return &SQLiteLimiter{DB: db}
+}
+
+func (l *SQLiteLimiter) Allow(key string, now time.Time) (time.Duration, error) {
+	var blockedUntil sql.NullTime
+	err := l.DB.QueryRow(`SELECT blocked_until FROM login_throttle WHERE key = ?`, key).Scan(&blockedUntil)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read throttle state: %w", err)
+	}
+	return retryAfter(blockedUntil.Time, now), nil
+}
+
+func (l *SQLiteLimiter) Fail(key string, policy Policy, now time.Time) (Result, error) {
+	tx, err := l.DB.Begin()
+	if err != nil {
+		return Result{}, err
+	}
+	defer tx.Rollback()
+
+	var rec Record
+	var lastFailure, blockedUntil sql.NullTime
+	err = tx.QueryRow(`SELECT failures, last_failure_at, blocked_until FROM login_throttle WHERE key = ?`, key).
+		Scan(&rec.Failures, &lastFailure, &blockedUntil)
+	if err != nil && err != sql.ErrNoRows {
+		return Result{}, fmt.Errorf("failed to read throttle state: %w", err)
+	}
+	rec.LastFailure, rec.BlockedUntil = lastFailure.Time, blockedUntil.Time
+
+	res := policy.apply(rec, now)
+
+	_, err = tx.Exec(`INSERT INTO login_throttle (key, failures, last_failure_at, blocked_until) VALUES (?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET failures = excluded.failures, last_failure_at = excluded.last_failure_at, blocked_until = excluded.blocked_until`,
+		key, res.Failures, res.LastFailure, sql.NullTime{Time: res.BlockedUntil, Valid: !res.BlockedUntil.IsZero()})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to store throttle state: %w", err)
+	}
+
+	return res, tx.Commit()
+}
+
+func (l *SQLiteLimiter) Reset(key string) error {
+	_, err := l.DB.Exec(`DELETE FROM login_throttle WHERE key = ?`, key)
+	return err
+}
@@ -0,0 +1,93 @@
+// Package throttle limite les tentatives répétées (connexion, codes 2FA) par clé :
+// backoff exponentiel après quelques échecs puis verrouillage temporaire
+package throttle
+
+import (
+	"time"
+)
+
+// Policy décrit la tolérance accordée à une clé
+type Policy struct {
+	FreeAttempts     int           // échecs tolérés sans délai
+	BaseDelay        time.Duration // délai après le premier échec au-delà de FreeAttempts, doublé ensuite
+	MaxDelay         time.Duration
+	LockoutThreshold int // nombre d'échecs déclenchant le verrouillage
+	LockoutDuration  time.Duration
+	Window           time.Duration // au-delà de cette durée sans échec, le compteur repart de zéro
+}
+
+// AccountPolicy s'applique à un identifiant de compte
+var AccountPolicy = Policy{
+	FreeAttempts:     3,
+	BaseDelay:        time.Second,
+	MaxDelay:         5 * time.Minute,
+	LockoutThreshold: 10,
+	LockoutDuration:  30 * time.Minute,
+	Window:           24 * time.Hour,
+}
+
+// AddressPolicy s'applique à une adresse IP, plus tolérante car partagée (NAT, proxy)
+var AddressPolicy = Policy{
+	FreeAttempts:     10,
+	BaseDelay:        time.Second,
+	MaxDelay:         time.Minute,
+	LockoutThreshold: 100,
+	LockoutDuration:  15 * time.Minute,
+	Window:           time.Hour,
+}
+
+// Record est l'état conservé pour une clé
+type Record struct {
+	Failures     int
+	LastFailure  time.Time
+	BlockedUntil time.Time
+}
+
+// Result est retourné après un échec
+type Result struct {
+	Record
+	LockedOut bool // le verrouillage vient d'être déclenché par cet échec
+}
+
+// Limiter conserve les compteurs d'échecs, en mémoire ou en base
+type Limiter interface {
+	// Allow retourne le temps restant avant qu'une nouvelle tentative soit acceptée (0 si autorisée)
+	Allow(key string, now time.Time) (time.Duration, error)
+	// Fail enregistre un échec et calcule le prochain blocage selon la politique
+	Fail(key string, policy Policy, now time.Time) (Result, error)
+	// Reset efface le compteur, après une tentative réussie
+	Reset(key string) error
+}
+
+// apply calcule l'état après un nouvel échec
+func (p Policy) apply(rec Record, now time.Time) Result {
+	if !rec.LastFailure.IsZero() && now.Sub(rec.LastFailure) > p.Window {
+		rec = Record{}
+	}
+
+	rec.Failures++
+	rec.LastFailure = now
+
+	var res Result
+	switch {
+	case p.LockoutThreshold > 0 && rec.Failures >= p.LockoutThreshold:
+		rec.BlockedUntil = now.Add(p.LockoutDuration)
+		res.LockedOut = rec.Failures == p.LockoutThreshold
+	case rec.Failures > p.FreeAttempts:
+		delay := p.BaseDelay << (rec.Failures - p.FreeAttempts - 1)
+		if delay <= 0 || delay > p.MaxDelay {
+			delay = p.MaxDelay
+		}
+		rec.BlockedUntil = now.Add(delay)
+	}
+
+	res.Record = rec
+	return res
+}
+
+func retryAfter(blockedUntil, now time.Time) time.Duration {
+	if blockedUntil.After(now) {
+		return blockedUntil.Sub(now)
+	}
+	return 0
+}
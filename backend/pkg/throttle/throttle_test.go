@@ -0,0 +1,106 @@
+package throttle
+
+import (
+	"testing"
+	"time"
+)
+
+var testPolicy = Policy{
+	FreeAttempts:     2,
+	BaseDelay:        time.Second,
+	MaxDelay:         5 * time.Second,
+	LockoutThreshold: 8,
+	LockoutDuration:  time.Hour,
+	Window:           10 * time.Minute,
+}
+
+func TestPolicyApply(t *testing.T) {
+	now := time.Date(2024, 11, 13, 12, 0, 0, 0, time.UTC)
+	want := []struct {
+		delay     time.Duration
+		lockedOut bool
+	}{
+		{0, false}, {0, false}, // échecs gratuits
+		{time.Second, false}, {2 * time.Second, false}, {4 * time.Second, false},
+		{5 * time.Second, false}, {5 * time.Second, false}, // plafonné à MaxDelay
+		{time.Hour, true},  // seuil de verrouillage atteint
+		{time.Hour, false}, // toujours verrouillé, mais LockedOut n'est signalé qu'une fois
+		{time.Hour, false},
+	}
+
+	var rec Record
+	for i, w := range want {
+		res := testPolicy.apply(rec, now)
+		rec = res.Record
+
+		if res.Failures != i+1 || !res.LastFailure.Equal(now) {
+			t.Errorf("failure %d: Failures = %d, LastFailure = %s", i+1, res.Failures, res.LastFailure)
+		}
+		if got := retryAfter(res.BlockedUntil, now); got != w.delay {
+			t.Errorf("failure %d: blocked for %s; want %s", i+1, got, w.delay)
+		}
+		if res.LockedOut != w.lockedOut {
+			t.Errorf("failure %d: LockedOut = %v; want %v", i+1, res.LockedOut, w.lockedOut)
+		}
+		now = now.Add(time.Second)
+	}
+}
+
+func TestPolicyApplyWindow(t *testing.T) {
+	now := time.Date(2024, 11, 13, 12, 0, 0, 0, time.UTC)
+	rec := Record{Failures: 5, LastFailure: now, BlockedUntil: now.Add(4 * time.Second)}
+
+	// exactement Window après le dernier échec, le compteur est conservé
+	if res := testPolicy.apply(rec, now.Add(testPolicy.Window)); res.Failures != 6 {
+		t.Errorf("at Window: Failures = %d; want 6", res.Failures)
+	}
+
+	// au-delà, il repart de zéro et le premier échec est de nouveau gratuit
+	later := now.Add(testPolicy.Window + time.Second)
+	res := testPolicy.apply(rec, later)
+	if res.Failures != 1 || !res.BlockedUntil.IsZero() || res.LockedOut {
+		t.Errorf("after Window: %+v; want a single free failure", res)
+	}
+
+	// un compte verrouillé est aussi remis à zéro après Window
+	locked := Record{Failures: 9, LastFailure: now, BlockedUntil: now.Add(testPolicy.LockoutDuration)}
+	if res := testPolicy.apply(locked, now.Add(2*testPolicy.LockoutDuration)); res.Failures != 1 || !res.BlockedUntil.IsZero() {
+		t.Errorf("locked account after Window: %+v; want a single free failure", res)
+	}
+}
+
+func TestPolicyApplyDelayOverflow(t *testing.T) {
+	// sans seuil de verrouillage le décalage finit par déborder : le délai reste plafonné
+	p := testPolicy
+	p.LockoutThreshold = 0
+	now := time.Date(2024, 11, 13, 12, 0, 0, 0, time.UTC)
+	for _, failures := range []int{40, 62, 63, 64, 100} {
+		res := p.apply(Record{Failures: failures, LastFailure: now}, now)
+		if got := retryAfter(res.BlockedUntil, now); got != p.MaxDelay {
+			t.Errorf("failure %d: blocked for %s; want %s", failures+1, got, p.MaxDelay)
+		}
+	}
+}
+
+func TestMemoryLimiter(t *testing.T) {
+	l := NewMemoryLimiter()
+	now := time.Date(2024, 11, 13, 12, 0, 0, 0, time.UTC)
+	for range testPolicy.FreeAttempts + 1 {
+		if _, err := l.Fail("key", testPolicy, now); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if wait, _ := l.Allow("key", now); wait != testPolicy.BaseDelay {
+		t.Errorf("Allow = %s; want %s", wait, testPolicy.BaseDelay)
+	}
+	if wait, _ := l.Allow("other", now); wait != 0 {
+		t.Errorf("Allow(other) = %s; want 0", wait)
+	}
+	if wait, _ := l.Allow("key", now.Add(testPolicy.BaseDelay)); wait != 0 {
+		t.Errorf("Allow after the delay = %s; want 0", wait)
+	}
+	l.Reset("key")
+	if res, _ := l.Fail("key", testPolicy, now); res.Failures != 1 {
+		t.Errorf("Failures after Reset = %d; want 1", res.Failures)
+	}
+}
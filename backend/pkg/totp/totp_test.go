@@ -0,0 +1,97 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+)
+
+// secret SHA-1 des vecteurs de test de la RFC 6238 (annexe B), "12345678901234567890" en ASCII
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// vecteurs de la RFC 6238 réduits à 6 chiffres (les 6 derniers des codes à 8 chiffres)
+var rfcVectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestCodeAtRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v.code {
+			t.Errorf("CodeAt(T=%d) = %s; want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, v := range rfcVectors {
+		at := time.Unix(v.unix, 0)
+		if step, ok := Validate(rfcSecret, v.code, at, 1); !ok || step != Step(at) {
+			t.Errorf("Validate(T=%d) = %d, %v; want %d, true", v.unix, step, ok, Step(at))
+		}
+	}
+
+	at := time.Unix(1111111111, 0)
+	tests := []struct {
+		name string
+		code string
+		at   time.Time
+		skew int
+		ok   bool
+	}{
+		{"spaces ignored", "050 471", at, 0, true},
+		{"previous window within skew", "050471", at.Add(Period), 1, true},
+		{"previous window without skew", "050471", at.Add(Period), 0, false},
+		{"two windows late", "050471", at.Add(2 * Period), 1, false},
+		{"wrong code", "050472", at, 1, false},
+		{"too short", "05047", at, 1, false},
+		{"too long", "0504710", at, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := Validate(rfcSecret, tt.code, tt.at, tt.skew); ok != tt.ok {
+				t.Errorf("Validate(%q) ok = %v; want %v", tt.code, ok, tt.ok)
+			}
+		})
+	}
+
+	if _, ok := Validate("not base32!", "050471", at, 1); ok {
+		t.Error("Validate accepted an invalid secret")
+	}
+}
+
+// TestValidateReplay vérifie que la fenêtre renvoyée est celle du code et non l'heure courante :
+// c'est elle que l'appelant compare à la dernière fenêtre utilisée pour refuser un rejeu
+func TestValidateReplay(t *testing.T) {
+	at := time.Unix(1111111111, 0)
+	first, ok := Validate(rfcSecret, "050471", at, 1)
+	if !ok {
+		t.Fatal("code rejected")
+	}
+	replayed, ok := Validate(rfcSecret, "050471", at.Add(Period), 1)
+	if !ok {
+		t.Fatal("code rejected within skew")
+	}
+	if replayed != first {
+		t.Errorf("replayed code matched window %d; want %d, the window it was issued for", replayed, first)
+	}
+
+	next, err := CodeAt(rfcSecret, first+1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if step, ok := Validate(rfcSecret, next, at.Add(Period), 1); !ok || step <= first {
+		t.Errorf("next code matched window %d, %v; want a window after %d", step, ok, first)
+	}
+}
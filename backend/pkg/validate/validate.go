@@ -0,0 +1,254 @@
+// Package validate applique les tags `validate` des modèles aux données envoyées par les clients.
+//
+// Règles disponibles, séparées par des virgules :
+//
+//	required         le champ doit être renseigné (chaîne non blanche, UUID non nul, date non nulle...)
+//	omitempty        les règles suivantes sont ignorées si le champ est vide
+//	min=N, max=N     longueur d'une chaîne (en caractères) ou d'une liste, valeur d'un nombre
+//	oneof=a b 'c d'  valeur parmi une liste, entre apostrophes si elle contient des espaces
+//	uuid             chaîne au format UUID
+//	email            adresse email
+//	future, past     date dans le futur ou dans le passé
+//	within=D         date à moins de D (durée Go, ex. "8760h") de maintenant
+package validate
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"net/mail"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofrs/uuid"
+)
+
+// FieldError décrit la règle non respectée par un champ, nommé comme dans le JSON
+type FieldError struct {
+	Field   string
+	Code    string // required, too_short, too_long, invalid, out_of_range
+	Message string
+}
+
+// Errors est renvoyée par Struct quand au moins un champ est invalide
+type Errors []FieldError
+
+func (e Errors) Error() string {
+	parts := make([]string, len(e))
+	for i, fe := range e {
+		parts[i] = fe.Field + ": " + fe.Message
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Struct vérifie les champs de v (struct ou pointeur de struct) qui portent un tag `validate`.
+// Elle renvoie Errors avec un élément par champ invalide, nil si tout est valide.
+// Un tag mal écrit est une erreur de programmation et provoque un panic
+func Struct(v any) error {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("validate: Struct expects a struct, got %T", v))
+	}
+
+	var errs Errors
+	now := time.Now()
+	rt := rv.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag := f.Tag.Get("validate")
+		if tag == "" || !f.IsExported() {
+			continue
+		}
+		if fe := checkField(fieldName(f), rv.Field(i), tag, now); fe != nil {
+			errs = append(errs, *fe)
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// fieldName renvoie le nom JSON du champ, le nom Go à défaut
+func fieldName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return f.Name
+	}
+	return name
+}
+
+// length représente la taille d'une liste pour min et max
+type length int
+
+// normalize ramène le champ à une valeur simple (string, int64, float64, time.Time ou length)
+// et indique s'il est vide. Les types nullables (NullString, uuid.UUID...) passent par driver.Valuer
+func normalize(rv reflect.Value) (any, bool) {
+	if rv.IsZero() {
+		return nil, true
+	}
+	if valuer, ok := rv.Interface().(driver.Valuer); ok {
+		v, err := valuer.Value()
+		if err != nil || v == nil {
+			return nil, true
+		}
+		rv = reflect.ValueOf(v)
+	}
+
+	switch rv.Kind() {
+	case reflect.String:
+		s := rv.String()
+		return s, strings.TrimSpace(s) == ""
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), false
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), false
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), false
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return length(rv.Len()), rv.Len() == 0
+	}
+	return rv.Interface(), false
+}
+
+// checkTag vérifie la syntaxe du tag indépendamment de la valeur du champ,
+// pour qu'un tag mal écrit provoque un panic même quand le champ est vide
+func checkTag(name, tag string) {
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch rule {
+		case "required", "omitempty", "uuid", "email", "future", "past":
+		case "min", "max":
+			if _, err := strconv.ParseFloat(param, 64); err != nil {
+				panic(fmt.Sprintf("validate: invalid %s=%q on %s", rule, param, name))
+			}
+		case "oneof":
+			if len(splitOneOf(param)) == 0 {
+				panic(fmt.Sprintf("validate: empty oneof on %s", name))
+			}
+		case "within":
+			if _, err := time.ParseDuration(param); err != nil {
+				panic(fmt.Sprintf("validate: invalid within=%q on %s", param, name))
+			}
+		default:
+			panic(fmt.Sprintf("validate: unknown rule %q on %s", rule, name))
+		}
+	}
+}
+
+func checkField(name string, rv reflect.Value, tag string, now time.Time) *FieldError {
+	checkTag(name, tag)
+	v, empty := normalize(rv)
+	fail := func(code, format string, args ...any) *FieldError {
+		return &FieldError{Field: name, Code: code, Message: fmt.Sprintf(format, args...)}
+	}
+
+	for _, rule := range strings.Split(tag, ",") {
+		rule, param, _ := strings.Cut(strings.TrimSpace(rule), "=")
+		switch rule {
+		case "required":
+			if empty {
+				return fail("required", "required")
+			}
+			continue
+		case "omitempty":
+			if empty {
+				return nil
+			}
+			continue
+		}
+		// un champ vide sans "required" n'est vérifié que par required
+		if empty {
+			return nil
+		}
+
+		switch rule {
+		case "min", "max":
+			limit, _ := strconv.ParseFloat(param, 64)
+			size, unit := measure(v, name)
+			short, long := "too_short", "too_long"
+			if unit == "" {
+				short, long = "too_small", "too_large"
+			}
+			if rule == "min" && size < limit {
+				return fail(short, "must be at least %s%s", param, unit)
+			}
+			if rule == "max" && size > limit {
+				return fail(long, "must be at most %s%s", param, unit)
+			}
+		case "oneof":
+			allowed := splitOneOf(param)
+			if !slices.Contains(allowed, fmt.Sprint(v)) {
+				return fail("invalid", "must be one of: %s", strings.Join(allowed, ", "))
+			}
+		case "uuid":
+			if _, err := uuid.FromString(fmt.Sprint(v)); err != nil {
+				return fail("invalid", "must be a valid UUID")
+			}
+		case "email":
+			s := fmt.Sprint(v)
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return fail("invalid", "must be a valid email address")
+			}
+		case "future", "past", "within":
+			t, ok := v.(time.Time)
+			if !ok {
+				panic(fmt.Sprintf("validate: %s on non-time field %s", rule, name))
+			}
+			switch rule {
+			case "future":
+				if !t.After(now) {
+					return fail("out_of_range", "must be in the future")
+				}
+			case "past":
+				if !t.Before(now) {
+					return fail("out_of_range", "must be in the past")
+				}
+			case "within":
+				d, _ := time.ParseDuration(param)
+				if t.Sub(now) > d || now.Sub(t) > d {
+					return fail("out_of_range", "must be within %s of now", d)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// measure renvoie la grandeur comparée par min et max et son unité pour le message
+func measure(v any, name string) (float64, string) {
+	switch v := v.(type) {
+	case string:
+		return float64(utf8.RuneCountInString(v)), " characters"
+	case length:
+		return float64(v), " items"
+	case int64:
+		return float64(v), ""
+	case float64:
+		return v, ""
+	}
+	panic(fmt.Sprintf("validate: min/max on unsupported field %s (%T)", name, v))
+}
+
+// splitOneOf découpe "Going 'Not going'" en ["Going", "Not going"]
+func splitOneOf(param string) []string {
+	var values []string
+	for param = strings.TrimSpace(param); param != ""; param = strings.TrimSpace(param) {
+		if param[0] == '\'' {
+			end := strings.IndexByte(param[1:], '\'')
+			if end < 0 {
+				panic("validate: unterminated quote in oneof=" + param)
+			}
+			values = append(values, param[1:end+1])
+			param = param[end+2:]
+			continue
+		}
+		value, rest, _ := strings.Cut(param, " ")
+		values = append(values, value)
+		param = rest
+	}
+	return values
+}
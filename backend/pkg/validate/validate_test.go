@@ -0,0 +1,363 @@
+package validate_test
+
+import (
+	"backend/pkg/models"
+	"backend/pkg/validate"
+	"database/sql"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// fieldErrors renvoie les erreurs de Struct(v) par nom de champ
+func fieldErrors(t *testing.T, v any) map[string]validate.FieldError {
+	t.Helper()
+	err := validate.Struct(v)
+	if err == nil {
+		return nil
+	}
+	var errs validate.Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Struct() error = %T; want validate.Errors", err)
+	}
+	byField := map[string]validate.FieldError{}
+	for _, fe := range errs {
+		byField[fe.Field] = fe
+	}
+	return byField
+}
+
+// ruleCase vérifie le code d'erreur renvoyé pour une valeur, vide si elle est valide
+type ruleCase struct {
+	name string
+	v    any
+	want string
+}
+
+func runRuleCases(t *testing.T, cases []ruleCase) {
+	t.Helper()
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			errs := fieldErrors(t, tc.v)
+			if got := errs["f"].Code; got != tc.want {
+				t.Errorf("code = %q; want %q (errors: %v)", got, tc.want, errs)
+			}
+		})
+	}
+}
+
+func TestRequired(t *testing.T) {
+	type str struct {
+		F string `json:"f" validate:"required"`
+	}
+	type id struct {
+		F uuid.UUID `json:"f" validate:"required"`
+	}
+	type date struct {
+		F time.Time `json:"f" validate:"required"`
+	}
+	type nullable struct {
+		F models.NullString `json:"f" validate:"required"`
+	}
+	type list struct {
+		F []string `json:"f" validate:"required"`
+	}
+	runRuleCases(t, []ruleCase{
+		{"empty string", str{}, "required"},
+		{"blank string", str{F: "  \t"}, "required"},
+		{"string", str{F: "x"}, ""},
+		{"nil UUID", id{}, "required"},
+		{"UUID", id{F: uuid.Must(uuid.NewV4())}, ""},
+		{"zero time", date{}, "required"},
+		{"time", date{F: time.Now()}, ""},
+		{"null string", nullable{}, "required"},
+		{"valid string", nullable{F: models.NullString{NullString: sql.NullString{String: "x", Valid: true}}}, ""},
+		{"empty list", list{F: []string{}}, "required"},
+		{"list", list{F: []string{"x"}}, ""},
+	})
+}
+
+func TestOmitEmpty(t *testing.T) {
+	type v struct {
+		F string `json:"f" validate:"omitempty,email"`
+	}
+	runRuleCases(t, []ruleCase{
+		{"empty skips the rules", v{}, ""},
+		{"set value is checked", v{F: "nope"}, "invalid"},
+	})
+}
+
+func TestMinMax(t *testing.T) {
+	type str struct {
+		F string `json:"f" validate:"min=2,max=3"`
+	}
+	type list struct {
+		F []int `json:"f" validate:"max=2"`
+	}
+	type number struct {
+		F int `json:"f" validate:"min=1,max=10"`
+	}
+	type float struct {
+		F float64 `json:"f" validate:"max=1.5"`
+	}
+	type nullable struct {
+		F models.NullString `json:"f" validate:"max=3"`
+	}
+	runRuleCases(t, []ruleCase{
+		{"string too short", str{F: "a"}, "too_short"},
+		{"string in range", str{F: "ab"}, ""},
+		{"characters, not bytes", str{F: "été"}, ""},
+		{"string too long", str{F: "abcd"}, "too_long"},
+		{"empty string is not measured", str{}, ""},
+		{"list in range", list{F: []int{1, 2}}, ""},
+		{"list too long", list{F: []int{1, 2, 3}}, "too_long"},
+		{"number too small", number{F: -1}, "too_small"},
+		{"number in range", number{F: 10}, ""},
+		{"number too large", number{F: 11}, "too_large"},
+		{"float too large", float{F: 1.6}, "too_large"},
+		{"null string", nullable{}, ""},
+		{"nullable too long", nullable{F: models.NullString{NullString: sql.NullString{String: "abcd", Valid: true}}}, "too_long"},
+	})
+}
+
+func TestOneOf(t *testing.T) {
+	type v struct {
+		F string `json:"f" validate:"oneof=Going 'Not going'"`
+	}
+	runRuleCases(t, []ruleCase{
+		{"plain value", v{F: "Going"}, ""},
+		{"quoted value", v{F: "Not going"}, ""},
+		{"part of a quoted value", v{F: "Not"}, "invalid"},
+		{"other value", v{F: "Maybe"}, "invalid"},
+	})
+}
+
+func TestUUID(t *testing.T) {
+	type v struct {
+		F string `json:"f" validate:"uuid"`
+	}
+	runRuleCases(t, []ruleCase{
+		{"UUID", v{F: uuid.Must(uuid.NewV4()).String()}, ""},
+		{"not a UUID", v{F: "1234"}, "invalid"},
+	})
+}
+
+func TestEmail(t *testing.T) {
+	type v struct {
+		F string `json:"f" validate:"email"`
+	}
+	runRuleCases(t, []ruleCase{
+		{"address", v{F: "alice@example.com"}, ""},
+		{"no domain", v{F: "alice@"}, "invalid"},
+		{"display name", v{F: "Alice <alice@example.com>"}, "invalid"},
+		{"no at sign", v{F: "alice"}, "invalid"},
+	})
+}
+
+func TestFuturePast(t *testing.T) {
+	type future struct {
+		F time.Time `json:"f" validate:"future"`
+	}
+	type past struct {
+		F time.Time `json:"f" validate:"past"`
+	}
+	type nullable struct {
+		F models.NullTime `json:"f" validate:"past"`
+	}
+	hour := time.Hour
+	runRuleCases(t, []ruleCase{
+		{"future date", future{F: time.Now().Add(hour)}, ""},
+		{"past date for future", future{F: time.Now().Add(-hour)}, "out_of_range"},
+		{"past date", past{F: time.Now().Add(-hour)}, ""},
+		{"future date for past", past{F: time.Now().Add(hour)}, "out_of_range"},
+		{"null time", nullable{}, ""},
+		{"nullable future date", nullable{F: models.NullTime{NullTime: sql.NullTime{Time: time.Now().Add(hour), Valid: true}}}, "out_of_range"},
+	})
+}
+
+func TestWithin(t *testing.T) {
+	type v struct {
+		F time.Time `json:"f" validate:"within=24h"`
+	}
+	runRuleCases(t, []ruleCase{
+		{"soon", v{F: time.Now().Add(time.Hour)}, ""},
+		{"recent", v{F: time.Now().Add(-time.Hour)}, ""},
+		{"too far ahead", v{F: time.Now().Add(25 * time.Hour)}, "out_of_range"},
+		{"too long ago", v{F: time.Now().Add(-25 * time.Hour)}, "out_of_range"},
+	})
+}
+
+func TestFieldNames(t *testing.T) {
+	var v struct {
+		JSONName string `json:"json_name,omitempty" validate:"required"`
+		GoName   string `validate:"required"`
+		Ignored  string `json:"-" validate:"required"`
+		Untagged string
+	}
+	errs := fieldErrors(t, &v)
+	for _, name := range []string{"json_name", "GoName", "Ignored"} {
+		if _, ok := errs[name]; !ok {
+			t.Errorf("no error for %s (errors: %v)", name, errs)
+		}
+	}
+	if len(errs) != 3 {
+		t.Errorf("%d errors; want 3 (errors: %v)", len(errs), errs)
+	}
+}
+
+func TestMalformedTags(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"unknown rule", struct {
+			F string `validate:"requred"`
+		}{}},
+		{"invalid min", struct {
+			F string `validate:"min=two"`
+		}{}},
+		{"missing max", struct {
+			F string `validate:"max"`
+		}{}},
+		{"invalid within", struct {
+			F time.Time `validate:"within=1year"`
+		}{}},
+		{"unterminated quote", struct {
+			F string `validate:"oneof=a 'b c"`
+		}{}},
+		{"empty oneof", struct {
+			F string `validate:"oneof="`
+		}{}},
+		{"time rule on a string", struct {
+			F string `validate:"future"`
+		}{F: "tomorrow"}},
+		{"min on a bool", struct {
+			F bool `validate:"min=1"`
+		}{F: true}},
+		{"not a struct", "string"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Error("Struct() did not panic")
+				}
+			}()
+			validate.Struct(tt.v)
+		})
+	}
+}
+
+// validatedModels recense les modèles validés, TestModelTags vérifie que la liste est complète
+var validatedModels = map[string]any{
+	"Comment":          models.Comment{},
+	"CommentPostGroup": models.CommentPostGroup{},
+	"EventResponse":    models.EventResponse{},
+	"Group":            models.Group{},
+	"GroupEvent":       models.GroupEvent{},
+	"Message":          models.Message{},
+	"Post":             models.Post{},
+	"PostGroup":        models.PostGroup{},
+	"UserProfil":       models.UserProfil{},
+}
+
+// TestModelTags passe chaque modèle portant des tags `validate` dans Struct, vide puis rempli,
+// pour qu'un tag mal écrit (qui provoque un panic à l'exécution) échoue ici
+func TestModelTags(t *testing.T) {
+	for _, name := range taggedModels(t, "../models") {
+		if _, ok := validatedModels[name]; !ok {
+			t.Errorf("models.%s has validate tags but is missing from validatedModels", name)
+		}
+	}
+
+	for name, model := range validatedModels {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Errorf("Struct() panicked: %v", r)
+				}
+			}()
+			validate.Struct(model)
+
+			filled := reflect.New(reflect.TypeOf(model)).Elem()
+			fill(filled)
+			validate.Struct(filled.Interface())
+		})
+	}
+}
+
+// taggedModels renvoie les structs du paquet dir dont au moins un champ porte un tag `validate`
+func taggedModels(t *testing.T, dir string) []string {
+	t.Helper()
+	pkgs, err := parser.ParseDir(token.NewFileSet(), dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			spec, ok := n.(*ast.TypeSpec)
+			if !ok {
+				return true
+			}
+			st, ok := spec.Type.(*ast.StructType)
+			if !ok {
+				return false
+			}
+			for _, field := range st.Fields.List {
+				if field.Tag != nil && strings.Contains(field.Tag.Value, `validate:"`) {
+					names = append(names, spec.Name.Name)
+					break
+				}
+			}
+			return false
+		})
+	}
+	return names
+}
+
+// fill donne une valeur non vide à chaque champ exporté, pour que toutes les règles soient évaluées
+func fill(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString("x")
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1)
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			fill(v.Index(i))
+		}
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fill(v.Index(0))
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fill(v.Elem())
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Now().Add(time.Hour)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				fill(v.Field(i))
+			}
+		}
+	}
+}
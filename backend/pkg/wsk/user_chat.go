@@ -7,13 +7,15 @@ import (
 type UserChat struct {
 	channels   *Channel
 	Username   string
+	SessionID  string // session du token utilisé au handshake
 	Connection *websocket.Conn
 }
 
-func NewUserChat(channels *Channel, username string, conn *websocket.Conn) *UserChat {
+func NewUserChat(channels *Channel, username, sessionID string, conn *websocket.Conn) *UserChat {
 	return &UserChat{
 		channels:   channels,
 		Username:   username,
+		SessionID:  sessionID,
 		Connection: conn,
 	}
 }
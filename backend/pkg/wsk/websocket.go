@@ -2,9 +2,11 @@ package wsk
 
 import (
 	"backend/pkg/models"
+	"backend/pkg/zwt"
 	"log"
 	"net/http"
 	"sync"
+	"time"
 
 	"github.com/gorilla/websocket"
 )
@@ -24,10 +26,14 @@ type WebsocketChat struct {
 	MessageChannel messageChannel
 	MessageHistory map[string][]*models.Message
 	Mu             sync.Mutex
+	Tokens         *zwt.Service // vérifie le token fourni lors du handshake /ws
+	// SessionActive indique si la session d'un token est toujours valide et son compte non suspendu (branché par le serveur HTTP)
+	SessionActive func(sessionID string) (bool, error)
 }
 
-func NewWebsocketChat() *WebsocketChat {
+func NewWebsocketChat(tokens *zwt.Service) *WebsocketChat {
 	w := &WebsocketChat{
+		Tokens:         tokens,
 		Users:          make(map[string]*UserChat),
 		JoinChannel:    make(userChannel),
 		LeaveChannel:   make(userChannel),
@@ -113,6 +119,22 @@ func (w *WebsocketChat) UsersChatManager() {
 	}
 }
 
+// CloseSession ferme immédiatement les connexions ouvertes avec une session révoquée
+func (w *WebsocketChat) CloseSession(sessionID string) {
+	w.Mu.Lock()
+	defer w.Mu.Unlock()
+
+	for _, user := range w.Users {
+		if user.SessionID == sessionID && user.Connection != nil {
+			log.Printf("Closing connection of revoked session for user: %s", user.Username)
+			user.Connection.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked"),
+				time.Now().Add(time.Second))
+			user.Connection.Close()
+		}
+	}
+}
+
 func (w *WebsocketChat) sendHistory(user *UserChat) {
 	if messages, ok := w.MessageHistory[user.Username]; ok {
 		for _, msg := range messages {
@@ -2,7 +2,6 @@ package wsk
 
 import (
 	"backend/pkg/models"
-	"backend/pkg/zwt"
 	"encoding/json"
 	"log"
 	"net/http"
@@ -10,24 +9,34 @@ import (
 )
 
 func (w *WebsocketChat) HanderUsersConnection(wr http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(wr, r, nil)
-	if err != nil {
-		log.Printf("Échec de l'upgrade WebSocket : %v", err)
-		http.Error(wr, "Failed to establish WebSocket connection", http.StatusInternalServerError)
-		return
-	}
-
+	// le token est vérifié avant l'upgrade pour pouvoir répondre 401 en HTTP
 	token := r.URL.Query().Get("token")
 	if token == "" {
 		log.Println("Token manquant dans la query string")
-		http.Error(wr, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(wr)
 		return
 	}
 
-	claims, err := zwt.VerifyJWT(token)
-	if err != nil || claims.Username == "" {
+	claims, err := w.Tokens.VerifyJWT(token)
+	// les tokens d'étape 2FA et d'assistance (lecture seule) ne permettent pas d'ouvrir le chat
+	if err != nil || claims.Username == "" || claims.Purpose != "" || claims.Impersonator != "" {
 		log.Printf("Token invalide ou utilisateur non défini : %v", err)
-		http.Error(wr, "Unauthorized", http.StatusUnauthorized)
+		writeUnauthorized(wr)
+		return
+	}
+
+	if w.SessionActive != nil {
+		active, err := w.SessionActive(claims.SessionID)
+		if err != nil || !active {
+			log.Printf("Session révoquée, invalide ou compte suspendu pour %s : %v", claims.Username, err)
+			writeUnauthorized(wr)
+			return
+		}
+	}
+
+	conn, err := upgrader.Upgrade(wr, r, nil)
+	if err != nil {
+		log.Printf("Échec de l'upgrade WebSocket : %v", err)
 		return
 	}
 
@@ -47,7 +56,7 @@ func (w *WebsocketChat) HanderUsersConnection(wr http.ResponseWriter, r *http.Re
 	userChat := NewUserChat(&Channel{
 		messageChannel: w.MessageChannel,
 		leaveChannel:   w.LeaveChannel,
-	}, username, conn)
+	}, username, claims.SessionID, conn)
 
 	w.JoinChannel <- userChat
 
@@ -115,3 +124,14 @@ func (u *UserChat) listenForMessages() {
 		}
 	}
 }
+
+// writeUnauthorized répond avec la même enveloppe d'erreur que le reste de l'API
+func writeUnauthorized(wr http.ResponseWriter) {
+	wr.Header().Set("Content-Type", "application/json")
+	wr.WriteHeader(http.StatusUnauthorized)
+	json.NewEncoder(wr).Encode(map[string]string{
+		"code":       "unauthorized",
+		"error":      "Unauthorized",
+		"request_id": wr.Header().Get("X-Request-ID"),
+	})
+}
@@ -0,0 +1,85 @@
+package zwt
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// KeyConfig décrit une clé du jeu de clés
+// HS256 : secret (base64 standard), RS256/EdDSA : chemin d'un fichier PEM privé ou public
+type KeyConfig struct {
+	ID      string `json:"kid"`
+	Alg     string `json:"alg"`
+	Secret  string `json:"secret,omitempty"`
+	PEMFile string `json:"pem_file,omitempty"`
+}
+
+// Config regroupe les paramètres du service de tokens
+type Config struct {
+	Issuer       string        `json:"issuer"`
+	Audience     string        `json:"audience"`
+	TTL          time.Duration `json:"-"`
+	Leeway       time.Duration `json:"-"`
+	SigningKeyID string        `json:"signing_kid"`
+	Keys         []KeyConfig   `json:"keys"`
+}
+
+// LoadKeysFile lit un jeu de clés {"signing_kid": "...", "keys": [...]} (rotation)
+func LoadKeysFile(path string) (string, []KeyConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to read keys file: %w", err)
+	}
+	var set struct {
+		SigningKeyID string      `json:"signing_kid"`
+		Keys         []KeyConfig `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return "", nil, fmt.Errorf("invalid keys file %s: %w", path, err)
+	}
+	return set.SigningKeyID, set.Keys, nil
+}
+
+// EphemeralKey génère une clé HS256 aléatoire (développement uniquement : les tokens ne survivent pas à un redémarrage)
+func EphemeralKey() (KeyConfig, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return KeyConfig{}, fmt.Errorf("failed to generate ephemeral key: %w", err)
+	}
+	return KeyConfig{ID: "ephemeral", Alg: AlgHS256, Secret: base64.StdEncoding.EncodeToString(secret)}, nil
+}
+
+// loadKeys construit les clés décrites dans la configuration
+func (cfg Config) loadKeys() ([]*Key, error) {
+	keys := make([]*Key, 0, len(cfg.Keys))
+	for _, kc := range cfg.Keys {
+		if kc.ID == "" {
+			return nil, fmt.Errorf("token key without kid")
+		}
+		switch kc.Alg {
+		case AlgHS256:
+			secret, err := base64.StdEncoding.DecodeString(kc.Secret)
+			if err != nil {
+				return nil, fmt.Errorf("key %q: secret must be base64: %w", kc.ID, err)
+			}
+			k, err := NewHMACKey(kc.ID, secret)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		case AlgRS256, AlgEdDSA:
+			k, err := LoadPEMKey(kc.ID, kc.Alg, kc.PEMFile)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, k)
+		default:
+			return nil, fmt.Errorf("key %q: unsupported algorithm %q", kc.ID, kc.Alg)
+		}
+	}
+	return keys, nil
+}
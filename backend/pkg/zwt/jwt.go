@@ -1,99 +1,105 @@
 package zwt
 
 import (
-	"crypto/hmac"
-	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
 	"strings"
-	"time"
 
 	"github.com/gofrs/uuid"
 )
 
+// Claims contient les informations portées par un token
 type Claims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
-	Exp      int64     `json:"exp"`
+	UserID       uuid.UUID `json:"user_id"`
+	Username     string    `json:"username"`
+	Role         string    `json:"role,omitempty"`
+	SessionID    string    `json:"sid,omitempty"`
+	Purpose      string    `json:"purpose,omitempty"` // vide pour un token d'accès, sinon usage restreint (ex. "mfa_pending")
+	Impersonator string    `json:"imp,omitempty"`     // administrateur à l'origine d'un token d'assistance en lecture seule
+	Issuer       string    `json:"iss,omitempty"`
+	Audience     Audience  `json:"aud,omitempty"`
+	IssuedAt     int64     `json:"iat"`
+	NotBefore    int64     `json:"nbf,omitempty"`
+	Exp          int64     `json:"exp"`
 }
 
-var jwtKey = []byte("my_secret_key")
+// Audience accepte la forme chaîne ou tableau du claim "aud" (RFC 7519)
+type Audience []string
 
-// GenerateJWT génère un JWT avec UserID, Username et une date d'expiration
-func GenerateJWT(userID uuid.UUID, username string) (string, error) {
-	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
-
-	expirationTime := time.Now().Add(24 * time.Hour).Unix()
-	claims := Claims{
-		UserID:   userID,
-		Username: username,
-		Exp:      expirationTime,
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
 	}
-	claimsJSON, err := json.Marshal(claims)
-	if err != nil {
-		return "", err
+	var many []string
+	if err := json.Unmarshal(data, &many); err != nil {
+		return fmt.Errorf("invalid aud claim: %w", err)
 	}
-	payload := base64.RawURLEncoding.EncodeToString(claimsJSON)
-
-	signature := GenerateHMACSHA256(header + "." + payload)
-	token := fmt.Sprintf("%s.%s.%s", header, payload, signature)
-
-	// Log parts pour verification
-	log.Println("Token generation:")
-	log.Println("Header:", header)
-	log.Println("Payload:", payload)
-	log.Println("Signature:", signature)
-
-	return token, nil
+	*a = many
+	return nil
 }
 
-// generateHMACSHA256 génère la signature HMAC-SHA256
-func GenerateHMACSHA256(data string) string {
-	h := hmac.New(sha256.New, jwtKey)
-	h.Write([]byte(data))
-	return strings.TrimRight(base64.URLEncoding.EncodeToString(h.Sum(nil)), "=")
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
 }
 
-// VerifyJWT vérifie le token JWT et retourne les claims si valides
-func VerifyJWT(token string) (*Claims, error) {
-	parts := strings.Split(token, ".")
-	if len(parts) != 3 {
-		log.Println("Invalid token format")
-		return nil, fmt.Errorf("invalid token format")
+// Contains indique si l'audience attendue fait partie du claim
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
 	}
+	return false
+}
 
-	header := parts[0]
-	payload := parts[1]
-	signature := parts[2]
+// header JOSE d'un token
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
 
-	expectedSignature := GenerateHMACSHA256(header + "." + payload)
-	if signature != expectedSignature {
-		log.Println("Invalid token signature")
-		log.Println("Expected signature:", expectedSignature)
-		log.Println("Provided signature:", signature)
-		return nil, fmt.Errorf("invalid token signature")
-	}
+var (
+	ErrInvalidToken     = errors.New("invalid token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrUnknownKey       = errors.New("unknown signing key")
+	ErrExpired          = errors.New("token has expired")
+	ErrNotYetValid      = errors.New("token is not valid yet")
+	ErrInvalidIssuer    = errors.New("invalid token issuer")
+	ErrInvalidAudience  = errors.New("invalid token audience")
+)
 
-	payloadData, err := base64.RawURLEncoding.DecodeString(payload)
+func encodeSegment(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
 	if err != nil {
-		log.Println("Invalid token payload:", err)
-		return nil, fmt.Errorf("invalid token payload")
+		return "", err
 	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
 
-	var claims Claims
-	err = json.Unmarshal(payloadData, &claims)
+func decodeSegment(seg string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(seg)
 	if err != nil {
-		log.Println("Invalid token claims:", err)
-		return nil, fmt.Errorf("invalid token claims")
+		return ErrInvalidToken
 	}
-
-	if time.Now().Unix() > claims.Exp {
-		log.Println("Token has expired")
-		return nil, fmt.Errorf("token has expired")
+	if err := json.Unmarshal(data, v); err != nil {
+		return ErrInvalidToken
 	}
+	return nil
+}
 
-	log.Println("Token is valid, UserID:", claims.UserID)
-	return &claims, nil
+// splitToken découpe un token compact en header, payload et signature
+func splitToken(token string) (string, string, string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return "", "", "", ErrInvalidToken
+	}
+	return parts[0], parts[1], parts[2], nil
 }
@@ -0,0 +1,153 @@
+package zwt
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+const (
+	AlgHS256 = "HS256"
+	AlgRS256 = "RS256"
+	AlgEdDSA = "EdDSA"
+)
+
+// Key représente une clé de signature ou de vérification identifiée par son kid
+type Key struct {
+	ID  string
+	Alg string
+
+	secret  []byte
+	rsaPriv *rsa.PrivateKey
+	rsaPub  *rsa.PublicKey
+	edPriv  ed25519.PrivateKey
+	edPub   ed25519.PublicKey
+	canSign bool
+}
+
+// NewHMACKey crée une clé HS256 à partir d'un secret partagé
+func NewHMACKey(id string, secret []byte) (*Key, error) {
+	if len(secret) < 32 {
+		return nil, fmt.Errorf("HS256 key %q must be at least 32 bytes", id)
+	}
+	return &Key{ID: id, Alg: AlgHS256, secret: secret, canSign: true}, nil
+}
+
+// NewRSAKey crée une clé RS256, la clé privée est optionnelle (vérification seule)
+func NewRSAKey(id string, priv *rsa.PrivateKey, pub *rsa.PublicKey) (*Key, error) {
+	if priv != nil {
+		pub = &priv.PublicKey
+	}
+	if pub == nil {
+		return nil, fmt.Errorf("RS256 key %q has no key material", id)
+	}
+	if pub.N.BitLen() < 2048 {
+		return nil, fmt.Errorf("RS256 key %q must be at least 2048 bits", id)
+	}
+	return &Key{ID: id, Alg: AlgRS256, rsaPriv: priv, rsaPub: pub, canSign: priv != nil}, nil
+}
+
+// NewEdDSAKey crée une clé Ed25519, la clé privée est optionnelle (vérification seule)
+func NewEdDSAKey(id string, priv ed25519.PrivateKey, pub ed25519.PublicKey) (*Key, error) {
+	if priv != nil {
+		pub = priv.Public().(ed25519.PublicKey)
+	}
+	if len(pub) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("EdDSA key %q has no key material", id)
+	}
+	return &Key{ID: id, Alg: AlgEdDSA, edPriv: priv, edPub: pub, canSign: priv != nil}, nil
+}
+
+// CanSign indique si la clé contient le matériel privé nécessaire à la signature
+func (k *Key) CanSign() bool {
+	return k.canSign
+}
+
+func (k *Key) sign(signingInput []byte) ([]byte, error) {
+	if !k.canSign {
+		return nil, fmt.Errorf("key %q cannot sign", k.ID)
+	}
+	switch k.Alg {
+	case AlgHS256:
+		h := hmac.New(sha256.New, k.secret)
+		h.Write(signingInput)
+		return h.Sum(nil), nil
+	case AlgRS256:
+		digest := sha256.Sum256(signingInput)
+		return rsa.SignPKCS1v15(rand.Reader, k.rsaPriv, crypto.SHA256, digest[:])
+	case AlgEdDSA:
+		return ed25519.Sign(k.edPriv, signingInput), nil
+	}
+	return nil, fmt.Errorf("unsupported algorithm %q", k.Alg)
+}
+
+// verify compare la signature en temps constant pour HS256
+func (k *Key) verify(signingInput, signature []byte) bool {
+	switch k.Alg {
+	case AlgHS256:
+		h := hmac.New(sha256.New, k.secret)
+		h.Write(signingInput)
+		return hmac.Equal(h.Sum(nil), signature)
+	case AlgRS256:
+		digest := sha256.Sum256(signingInput)
+		return rsa.VerifyPKCS1v15(k.rsaPub, crypto.SHA256, digest[:], signature) == nil
+	case AlgEdDSA:
+		return ed25519.Verify(k.edPub, signingInput, signature)
+	}
+	return false
+}
+
+// LoadPEMKey charge une clé RS256 ou EdDSA depuis un fichier PEM (PKCS#1, PKCS#8 ou PKIX)
+func LoadPEMKey(id, alg, path string) (*Key, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %q: %w", id, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %q: no PEM block found in %s", id, path)
+	}
+
+	var parsed interface{}
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %q: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %q: %w", id, err)
+	}
+
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		return checkAlg(alg, AlgRS256, id, func() (*Key, error) { return NewRSAKey(id, k, nil) })
+	case *rsa.PublicKey:
+		return checkAlg(alg, AlgRS256, id, func() (*Key, error) { return NewRSAKey(id, nil, k) })
+	case ed25519.PrivateKey:
+		return checkAlg(alg, AlgEdDSA, id, func() (*Key, error) { return NewEdDSAKey(id, k, nil) })
+	case ed25519.PublicKey:
+		return checkAlg(alg, AlgEdDSA, id, func() (*Key, error) { return NewEdDSAKey(id, nil, k) })
+	}
+	return nil, fmt.Errorf("key %q: unsupported key type %T", id, parsed)
+}
+
+func checkAlg(want, got, id string, build func() (*Key, error)) (*Key, error) {
+	if want != "" && want != got {
+		return nil, fmt.Errorf("key %q: algorithm %s does not match key material (%s)", id, want, got)
+	}
+	return build()
+}
@@ -0,0 +1,171 @@
+package zwt
+
+import (
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Service signe et vérifie les tokens avec un jeu de clés rotatif :
+// une clé active pour la signature et toutes les clés connues pour la vérification
+type Service struct {
+	signing  *Key
+	keys     map[string]*Key
+	issuer   string
+	audience string
+	ttl      time.Duration
+	leeway   time.Duration
+	now      func() time.Time
+}
+
+// NewService construit le service à partir d'une configuration déjà validée
+func NewService(cfg Config) (*Service, error) {
+	keys, err := cfg.loadKeys()
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no token keys configured")
+	}
+
+	s := &Service{
+		keys:     make(map[string]*Key, len(keys)),
+		issuer:   cfg.Issuer,
+		audience: cfg.Audience,
+		ttl:      cfg.TTL,
+		leeway:   cfg.Leeway,
+		now:      time.Now,
+	}
+	for _, k := range keys {
+		if _, exists := s.keys[k.ID]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", k.ID)
+		}
+		s.keys[k.ID] = k
+	}
+
+	signing, ok := s.keys[cfg.SigningKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %q is not configured", cfg.SigningKeyID)
+	}
+	if !signing.CanSign() {
+		return nil, fmt.Errorf("signing key %q has no private material", cfg.SigningKeyID)
+	}
+	s.signing = signing
+
+	if s.ttl <= 0 {
+		s.ttl = 15 * time.Minute
+	}
+	return s, nil
+}
+
+// TTL retourne la durée de vie par défaut des tokens émis
+func (s *Service) TTL() time.Duration {
+	return s.ttl
+}
+
+// GenerateJWT génère un token d'accès rattaché à une session avec la durée de vie par défaut
+func (s *Service) GenerateJWT(userID uuid.UUID, username, role, sessionID string) (string, error) {
+	return s.Issue(Claims{UserID: userID, Username: username, Role: role, SessionID: sessionID})
+}
+
+// Issue signe les claims avec la clé active, iat/nbf/exp/iss/aud sont complétés si absents
+func (s *Service) Issue(claims Claims) (string, error) {
+	now := s.now()
+	if claims.IssuedAt == 0 {
+		claims.IssuedAt = now.Unix()
+	}
+	if claims.NotBefore == 0 {
+		claims.NotBefore = claims.IssuedAt
+	}
+	if claims.Exp == 0 {
+		claims.Exp = now.Add(s.ttl).Unix()
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = s.issuer
+	}
+	if len(claims.Audience) == 0 && s.audience != "" {
+		claims.Audience = Audience{s.audience}
+	}
+
+	h, err := encodeSegment(header{Alg: s.signing.Alg, Typ: "JWT", Kid: s.signing.ID})
+	if err != nil {
+		return "", err
+	}
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := h + "." + payload
+	sig, err := s.signing.sign([]byte(signingInput))
+	if err != nil {
+		return "", err
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// VerifyJWT vérifie la signature et les claims temporels, iss et aud du token
+func (s *Service) VerifyJWT(token string) (*Claims, error) {
+	rawHeader, rawPayload, rawSig, err := splitToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	var h header
+	if err := decodeSegment(rawHeader, &h); err != nil {
+		return nil, err
+	}
+
+	// le kid désigne la clé, l'alg annoncé doit correspondre à celle-ci (pas de "none" ni de confusion HS/RS)
+	key, ok := s.keys[h.Kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	if h.Alg != key.Alg {
+		return nil, ErrInvalidSignature
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(rawSig)
+	if err != nil {
+		return nil, ErrInvalidSignature
+	}
+	if !key.verify([]byte(rawHeader+"."+rawPayload), sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	var claims Claims
+	if err := decodeSegment(rawPayload, &claims); err != nil {
+		return nil, err
+	}
+	if err := s.validate(&claims); err != nil {
+		return nil, err
+	}
+	return &claims, nil
+}
+
+func (s *Service) validate(c *Claims) error {
+	now := s.now()
+	leeway := int64(s.leeway / time.Second)
+
+	if c.Exp == 0 || now.Unix() > c.Exp+leeway {
+		return ErrExpired
+	}
+	if c.NotBefore != 0 && now.Unix()+leeway < c.NotBefore {
+		return ErrNotYetValid
+	}
+	if c.IssuedAt != 0 && now.Unix()+leeway < c.IssuedAt {
+		return ErrNotYetValid
+	}
+	if s.issuer != "" && c.Issuer != s.issuer {
+		return ErrInvalidIssuer
+	}
+	if s.audience != "" && !c.Audience.Contains(s.audience) {
+		return ErrInvalidAudience
+	}
+	if c.UserID == uuid.Nil {
+		return ErrInvalidToken
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package zwt
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+var testNow = time.Date(2024, 11, 14, 12, 0, 0, 0, time.UTC)
+
+func hmacKeyConfig(t *testing.T, kid string) KeyConfig {
+	t.Helper()
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		t.Fatal(err)
+	}
+	return KeyConfig{ID: kid, Alg: AlgHS256, Secret: base64.StdEncoding.EncodeToString(secret)}
+}
+
+// newTestService construit un service à l'horloge figée sur testNow
+func newTestService(t *testing.T, signingKid string, keys ...KeyConfig) *Service {
+	t.Helper()
+	s, err := NewService(Config{
+		Issuer: "social-network", Audience: "api", TTL: 15 * time.Minute, Leeway: 30 * time.Second,
+		SigningKeyID: signingKid, Keys: keys,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+// forge signe un token au header et aux claims arbitraires, avec key ou sans signature si key est nil
+func forge(t *testing.T, key *Key, h header, c Claims) string {
+	t.Helper()
+	rawHeader, err := encodeSegment(h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rawPayload, err := encodeSegment(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := rawHeader + "." + rawPayload
+	if key == nil {
+		return input + "."
+	}
+	sig, err := key.sign([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifyJWT(t *testing.T) {
+	current := hmacKeyConfig(t, "current")
+	s := newTestService(t, "current", current)
+	key := s.keys["current"]
+
+	// clé Ed25519 connue du service, pour les confusions d'algorithme
+	edPub, edPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, err := NewEdDSAKey("ed", edPriv, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.keys["ed"] = edKey
+	// un attaquant qui signe en HS256 avec la clé publique Ed25519 comme secret
+	confused := &Key{ID: "ed", Alg: AlgHS256, secret: edPub, canSign: true}
+
+	valid := func() Claims {
+		return Claims{
+			UserID: uuid.Must(uuid.NewV4()), Username: "alice", Issuer: "social-network", Audience: Audience{"api"},
+			IssuedAt: testNow.Unix(), NotBefore: testNow.Unix(), Exp: testNow.Add(time.Minute).Unix(),
+		}
+	}
+	hs := header{Alg: AlgHS256, Typ: "JWT", Kid: "current"}
+
+	tests := []struct {
+		name  string
+		token func() string
+		want  error
+	}{
+		{"valid", func() string { return forge(t, key, hs, valid()) }, nil},
+		{"issued by the service", func() string {
+			token, err := s.GenerateJWT(uuid.Must(uuid.NewV4()), "alice", "user", "sid")
+			if err != nil {
+				t.Fatal(err)
+			}
+			return token
+		}, nil},
+		{"alg none", func() string { return forge(t, nil, header{Alg: "none", Kid: "current"}, valid()) }, ErrInvalidSignature},
+		{"alg does not match the key", func() string {
+			return forge(t, key, header{Alg: AlgRS256, Kid: "current"}, valid())
+		}, ErrInvalidSignature},
+		{"HS256 signed with an EdDSA public key", func() string {
+			return forge(t, confused, header{Alg: AlgHS256, Kid: "ed"}, valid())
+		}, ErrInvalidSignature},
+		{"tampered payload", func() string {
+			token := forge(t, key, hs, valid())
+			c := valid()
+			c.Role = "admin"
+			forged := forge(t, key, hs, c)
+			parts, forgedParts := strings.Split(token, "."), strings.Split(forged, ".")
+			return parts[0] + "." + forgedParts[1] + "." + parts[2]
+		}, ErrInvalidSignature},
+		{"unknown kid", func() string { return forge(t, key, header{Alg: AlgHS256, Kid: "missing"}, valid()) }, ErrUnknownKey},
+		{"no kid", func() string { return forge(t, key, header{Alg: AlgHS256}, valid()) }, ErrUnknownKey},
+		{"expired", func() string {
+			c := valid()
+			c.Exp = testNow.Add(-time.Minute).Unix()
+			return forge(t, key, hs, c)
+		}, ErrExpired},
+		{"expired within leeway", func() string {
+			c := valid()
+			c.Exp = testNow.Add(-10 * time.Second).Unix()
+			return forge(t, key, hs, c)
+		}, nil},
+		{"no exp", func() string {
+			c := valid()
+			c.Exp = 0
+			return forge(t, key, hs, c)
+		}, ErrExpired},
+		{"nbf in the future", func() string {
+			c := valid()
+			c.NotBefore = testNow.Add(time.Minute).Unix()
+			return forge(t, key, hs, c)
+		}, ErrNotYetValid},
+		{"iat in the future", func() string {
+			c := valid()
+			c.IssuedAt = testNow.Add(time.Minute).Unix()
+			return forge(t, key, hs, c)
+		}, ErrNotYetValid},
+		{"wrong issuer", func() string {
+			c := valid()
+			c.Issuer = "someone-else"
+			return forge(t, key, hs, c)
+		}, ErrInvalidIssuer},
+		{"wrong audience", func() string {
+			c := valid()
+			c.Audience = Audience{"other", "admin"}
+			return forge(t, key, hs, c)
+		}, ErrInvalidAudience},
+		{"audience among several", func() string {
+			c := valid()
+			c.Audience = Audience{"other", "api"}
+			return forge(t, key, hs, c)
+		}, nil},
+		{"no user", func() string {
+			c := valid()
+			c.UserID = uuid.Nil
+			return forge(t, key, hs, c)
+		}, ErrInvalidToken},
+		{"malformed", func() string { return "not.a-token" }, ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := s.VerifyJWT(tt.token())
+			if !errors.Is(err, tt.want) {
+				t.Errorf("VerifyJWT() error = %v; want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// TestKeyRotation vérifie qu'une clé retirée de la signature vérifie encore les tokens émis avant la rotation,
+// et qu'une clé supprimée du jeu ne vérifie plus rien
+func TestKeyRotation(t *testing.T) {
+	old, next := hmacKeyConfig(t, "2024-10"), hmacKeyConfig(t, "2024-11")
+	userID := uuid.Must(uuid.NewV4())
+
+	before := newTestService(t, "2024-10", old)
+	oldToken, err := before.GenerateJWT(userID, "alice", "user", "sid")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	during := newTestService(t, "2024-11", old, next)
+	claims, err := during.VerifyJWT(oldToken)
+	if err != nil {
+		t.Fatalf("token signed by the retired key: %v", err)
+	}
+	if claims.UserID != userID {
+		t.Errorf("user = %s; want %s", claims.UserID, userID)
+	}
+
+	newToken, err := during.GenerateJWT(userID, "alice", "user", "sid")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var h header
+	if err := decodeSegment(strings.Split(newToken, ".")[0], &h); err != nil {
+		t.Fatal(err)
+	}
+	if h.Kid != "2024-11" {
+		t.Errorf("new token kid = %q; want the signing key 2024-11", h.Kid)
+	}
+	if _, err := before.VerifyJWT(newToken); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("new key on a service that does not know it: err = %v; want ErrUnknownKey", err)
+	}
+
+	after := newTestService(t, "2024-11", next)
+	if _, err := after.VerifyJWT(oldToken); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("token of a rotated-out key: err = %v; want ErrUnknownKey", err)
+	}
+	if _, err := after.VerifyJWT(newToken); err != nil {
+		t.Errorf("token of the current key: %v", err)
+	}
+}
@@ -9,8 +9,8 @@ export default function GroupListPage() {
   const [loading, setLoading] = useState(true);
   const [error, setError] = useState(null);
   const [isMember, setIsMember] = useState(true);
-  const [page, setPage] = useState(1);
-  const [hasMore, setHasMore] = useState(true);
+  const [cursor, setCursor] = useState("");
+  const [nextCursor, setNextCursor] = useState("");
   const router = useRouter();
 
   useEffect(() => {
@@ -18,7 +18,7 @@ export default function GroupListPage() {
       try {
         setLoading(true);
         const response = await fetch(
-          `http://127.0.0.1:8079/list_group?page=${page}&limit=10`,
+          `http://127.0.0.1:8079/list_group?limit=10&cursor=${encodeURIComponent(cursor)}`,
           {
             headers: {
               Authorization: `Bearer ${localStorage.getItem("authToken")}`,
@@ -46,7 +46,8 @@ export default function GroupListPage() {
           );
         });
 
-        setHasMore(data.length > 0);
+        // le curseur de la page suivante est absent sur la dernière page
+        setNextCursor(response.headers.get("X-Next-Cursor") || "");
       } catch (err) {
         setError("Impossible de récupérer les groupes.");
       } finally {
@@ -55,7 +56,7 @@ export default function GroupListPage() {
     };
 
     fetchGroups();
-  }, [page]);
+  }, [cursor]);
 
   const navigateToGroup = (id) => {
     router.push(`/groups/${id}`);
@@ -63,7 +64,7 @@ export default function GroupListPage() {
 
   // Load the next page
   const handleNextPage = () => {
-    if (hasMore) setPage((prev) => prev + 1);
+    if (nextCursor) setCursor(nextCursor);
   };
 
   if (!isMember) {
@@ -111,7 +112,7 @@ export default function GroupListPage() {
           ))}
         </div>
 
-        {hasMore && !loading && (
+        {nextCursor && !loading && (
           <button
             onClick={handleNextPage}
             className="w-full mt-6 bg-cyan-600 text-white py-3 rounded-lg hover:bg-cyan-500 transition"